@@ -100,6 +100,7 @@ type Config struct {
 	RestSessionPath string
 	KeepAlive       int
 	APITimeout      time.Duration
+	APIRetryCount   int
 }
 
 // NewConfig returns a new Config from a supplied ResourceData.
@@ -133,6 +134,7 @@ func NewConfig(d *schema.ResourceData) (*Config, error) {
 		RestSessionPath: d.Get("rest_session_path").(string),
 		KeepAlive:       d.Get("vim_keep_alive").(int),
 		APITimeout:      timeout,
+		APIRetryCount:   d.Get("api_retry_count").(int),
 	}
 
 	return c, nil
@@ -172,6 +174,16 @@ func (c *Config) Client() (*Client, error) {
 
 	log.Printf("[DEBUG] VMWare vSphere Client configured for URL: %s", c.VSphereServer)
 
+	// Retry waiting for tasks on transient errors, and log in again if the
+	// session expires during the wait.
+	viapi.SetTaskRetryPolicy(client.vimClient.Client, viapi.TaskRetryPolicy{
+		Retries: c.APIRetryCount,
+		Delay:   viapi.DefaultTaskRetryPolicy.Delay,
+		Login: func(ctx context.Context) error {
+			return client.vimClient.Login(ctx, u.User)
+		},
+	})
+
 	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
 	defer cancel()
 	s := new(cache.Session)
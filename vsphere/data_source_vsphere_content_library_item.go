@@ -6,6 +6,7 @@ package vsphere
 
 import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/contentlibrary"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/provider"
 )
@@ -21,16 +22,36 @@ func dataSourceVSphereContentLibraryItem() *schema.Resource {
 				Description: "The name of the content library item.",
 			},
 			"library_id": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "ID of the content library to contain item.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				Description:  "ID of the content library to contain item.",
+				ExactlyOneOf: []string{"library_id", "library_name"},
+			},
+			"library_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "Name of the content library to contain item.",
+				ExactlyOneOf: []string{"library_id", "library_name"},
 			},
 			"type": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
-				Description: "Type of content library item.",
+				Description: "Type of content library item. If set, the item must be of this type.",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The version of the content library item.",
+			},
+			"content_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The version of the content of the content library item.",
 			},
 		},
 	}
@@ -38,12 +59,26 @@ func dataSourceVSphereContentLibraryItem() *schema.Resource {
 
 func dataSourceVSphereContentLibraryItemRead(d *schema.ResourceData, meta interface{}) error {
 	rc := meta.(*Client).restClient
-	lib, _ := contentlibrary.FromID(rc, d.Get("library_id").(string))
-	item, err := contentlibrary.ItemFromName(rc, lib, d.Get("name").(string))
+	name := d.Get("name").(string)
+	var allowedTypes []string
+	if t := d.Get("type").(string); t != "" {
+		allowedTypes = append(allowedTypes, t)
+	}
+
+	var item *library.Item
+	var err error
+	if libraryName, ok := d.GetOk("library_name"); ok {
+		item, err = contentlibrary.ResolveItem(rc, libraryName.(string), name, allowedTypes...)
+	} else {
+		item, err = contentlibrary.ResolveItemByLibraryID(rc, d.Get("library_id").(string), name, allowedTypes...)
+	}
 	if err != nil {
-		return provider.Error(d.Get("name").(string), "dataSourceVSphereContentLibraryItemRead", err)
+		return provider.Error(name, "dataSourceVSphereContentLibraryItemRead", err)
 	}
-	_ = d.Set("type", item.Type)
 	d.SetId(item.ID)
+	_ = d.Set("library_id", item.LibraryID)
+	_ = d.Set("type", item.Type)
+	_ = d.Set("version", item.Version)
+	_ = d.Set("content_version", item.ContentVersion)
 	return nil
 }
@@ -6,8 +6,10 @@ package vsphere
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/datastore"
 )
@@ -49,6 +51,11 @@ func dataSourceVSphereDatastoreRead(d *schema.ResourceData, meta interface{}) er
 		}
 	}
 	ds, err := datastore.FromPath(client, name, dc)
+	if _, ok := err.(*find.NotFoundError); ok && !strings.Contains(name, "/") {
+		// Search the whole inventory, or datacenter, for a datastore with the
+		// name, including datastores in folders and datastore clusters.
+		ds, err = datastore.FromName(client, name, dc)
+	}
 	if err != nil {
 		return fmt.Errorf("error fetching datastore: %s", err)
 	}
@@ -6,36 +6,176 @@ package vsphere
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"log"
 	"regexp"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
+const (
+	dynamicSortByName = "name"
+	dynamicSortByMOID = "moid"
+
+	dynamicMatchAll = "all"
+	dynamicMatchAny = "any"
+)
+
+var dynamicSortByAllowedValues = []string{
+	dynamicSortByName,
+	dynamicSortByMOID,
+}
+
+var dynamicMatchAllowedValues = []string{
+	dynamicMatchAll,
+	dynamicMatchAny,
+}
+
 func dataSourceVSphereDynamic() *schema.Resource {
 	return &schema.Resource{
 		Read: dataSourceVSphereDynamicRead,
 
 		Schema: map[string]*schema.Schema{
 			"filter": {
-				Type:        schema.TypeSet,
-				Required:    true,
-				Description: "List of tag IDs to match target.",
-				Elem:        &schema.Schema{Type: schema.TypeString},
+				Type:         schema.TypeSet,
+				Optional:     true,
+				Description:  "List of tag IDs to match target.",
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				AtLeastOneOf: []string{"filter", "tag"},
+			},
+			"tag": {
+				Type:         schema.TypeSet,
+				Optional:     true,
+				Description:  "Tags to match target, by category and tag name. Resolved to tag IDs and combined with filter.",
+				AtLeastOneOf: []string{"filter", "tag"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"category": {
+							Type:         schema.TypeString,
+							Required:     true,
+							Description:  "The name of the tag category.",
+							ValidateFunc: validation.StringIsNotEmpty,
+						},
+						"name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							Description:  "The name of the tag in the category.",
+							ValidateFunc: validation.StringIsNotEmpty,
+						},
+					},
+				},
+			},
+			"match": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      dynamicMatchAll,
+				Description:  "Whether objects must carry all of the tags in filter, or any of them. Can be one of all or any.",
+				ValidateFunc: validation.StringInSlice(dynamicMatchAllowedValues, false),
 			},
 			"name_regex": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: "A regular expression used to match against managed object names.",
+				Description: "A regular expression used to match against managed object names. Matches all objects if empty.",
+			},
+			"name_regex_case_insensitive": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Match name_regex against managed object names without regard to case.",
+			},
+			"name_regex_anchored": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Require name_regex to match the entire managed object name, rather than any part of it.",
 			},
 			"type": {
 				Type:        schema.TypeString,
 				Optional:    true,
 				Description: "The type of managed object to return.",
 			},
+			"created_after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Only match objects created after this time, in RFC 3339 format. Objects without a creation time are not filtered.",
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"modified_after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Only match objects modified after this time, in RFC 3339 format. Objects without a modification time are not filtered.",
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"multiple": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Return all matching objects in ids instead of failing when more than one object matches.",
+			},
+			"sort_by": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      dynamicSortByName,
+				Description:  "The attribute to sort matching objects by. Can be one of name or moid.",
+				ValidateFunc: validation.StringInSlice(dynamicSortByAllowedValues, false),
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "The maximum number of matching objects to return, applied after sorting. 0 means no limit.",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the matching object, if multiple is not set.",
+			},
+			"managed_object_type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The managed object type of the matching object, if multiple is not set.",
+			},
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The managed object reference IDs of all matching objects, if multiple is set.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"matches": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "All matching objects, in the order given by sort_by.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The managed object reference ID of the object.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the object.",
+						},
+						"type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The managed object type of the object.",
+						},
+					},
+				},
+			},
 		},
 	}
 }
@@ -46,8 +186,11 @@ func dataSourceVSphereDynamicRead(d *schema.ResourceData, meta interface{}) erro
 	if err != nil {
 		return err
 	}
-	tagIDs := d.Get("filter").(*schema.Set).List()
-	matches, err := filterObjectsByTag(tm, tagIDs)
+	tagIDs, err := expandDynamicTagIDs(tm, d)
+	if err != nil {
+		return err
+	}
+	matches, err := filterObjectsByTag(tm, tagIDs, d.Get("match").(string))
 	if err != nil {
 		return err
 	}
@@ -55,22 +198,161 @@ func dataSourceVSphereDynamicRead(d *schema.ResourceData, meta interface{}) erro
 	if err != nil {
 		return err
 	}
+	filtered = sortAndLimitDynamicObjects(filtered, d.Get("sort_by").(string), d.Get("limit").(int))
 	switch {
 	case len(filtered) < 1:
 		return fmt.Errorf("no matching resources found")
-	case len(filtered) > 1:
+	case len(filtered) > 1 && !d.Get("multiple").(bool):
 		log.Printf("dataSourceVSphereDynamic: Multiple matches found: %v", filtered)
 		return fmt.Errorf("multiple objects match the supplied criteria")
 	}
-	d.SetId(filtered[0])
-	log.Printf("[DEBUG] dataSourceDynamic: Read complete. Resource located: %s", filtered[0])
+	if err := d.Set("matches", flattenDynamicObjects(filtered)); err != nil {
+		return err
+	}
+	if !d.Get("multiple").(bool) {
+		d.SetId(filtered[0].ref.Value)
+		_ = d.Set("name", filtered[0].name)
+		_ = d.Set("managed_object_type", filtered[0].ref.Type)
+		log.Printf("[DEBUG] dataSourceDynamic: Read complete. Resource located: %s", filtered[0].ref.Value)
+		return nil
+	}
+
+	ids := make([]string, len(filtered))
+	for i, obj := range filtered {
+		ids[i] = obj.ref.Value
+	}
+	if err := d.Set("ids", ids); err != nil {
+		return err
+	}
+	// Create unique ID based on the matching objects
+	idsum := sha256.New()
+	if _, err := fmt.Fprintf(idsum, "%#v", ids); err != nil {
+		return err
+	}
+	d.SetId(fmt.Sprintf("%x", idsum.Sum(nil)))
+	log.Printf("[DEBUG] dataSourceDynamic: Read complete. Resources located: %v", ids)
 	return nil
 }
 
-func filterObjectsByName(d *schema.ResourceData, meta interface{}, matches []tags.AttachedObjects) ([]string, error) {
+// expandDynamicTagIDs returns the tag IDs in filter, followed by the IDs of
+// the tags in tag, which are looked up by category and tag name. Tags that
+// are given both ways are only returned once.
+func expandDynamicTagIDs(tm *tags.Manager, d *schema.ResourceData) ([]interface{}, error) {
+	tagIDs := d.Get("filter").(*schema.Set).List()
+	categoryIDs := make(map[string]string)
+	for _, v := range d.Get("tag").(*schema.Set).List() {
+		t := v.(map[string]interface{})
+		category, name := t["category"].(string), t["name"].(string)
+		categoryID, ok := categoryIDs[category]
+		if !ok {
+			var err error
+			if categoryID, err = tagCategoryByName(tm, category); err != nil {
+				return nil, err
+			}
+			categoryIDs[category] = categoryID
+		}
+		tagID, err := tagByName(tm, name, categoryID)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[DEBUG] dataSourceDynamic: Resolved tag %q in category %q to %s", name, category, tagID)
+		tagIDs = appendDynamicTagID(tagIDs, tagID)
+	}
+	return tagIDs, nil
+}
+
+// appendDynamicTagID appends tagID to tagIDs unless it is already present.
+func appendDynamicTagID(tagIDs []interface{}, tagID string) []interface{} {
+	for _, v := range tagIDs {
+		if v.(string) == tagID {
+			return tagIDs
+		}
+	}
+	return append(tagIDs, tagID)
+}
+
+// dynamicObject is a managed object matched by the dynamic data source, along
+// with its name.
+type dynamicObject struct {
+	ref  types.ManagedObjectReference
+	name string
+}
+
+// flattenDynamicObjects returns the matched objects in the form of the
+// matches attribute.
+func flattenDynamicObjects(objs []dynamicObject) []interface{} {
+	matches := make([]interface{}, len(objs))
+	for i, obj := range objs {
+		matches[i] = map[string]interface{}{
+			"id":   obj.ref.Value,
+			"name": obj.name,
+			"type": obj.ref.Type,
+		}
+	}
+	return matches
+}
+
+// sortAndLimitDynamicObjects sorts the matched objects by name or managed
+// object ID and returns at most limit of them. A limit of 0 returns all
+// objects.
+func sortAndLimitDynamicObjects(objs []dynamicObject, sortBy string, limit int) []dynamicObject {
+	sorted := make([]dynamicObject, len(objs))
+	copy(sorted, objs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sortBy == dynamicSortByName && sorted[i].name != sorted[j].name {
+			return sorted[i].name < sorted[j].name
+		}
+		return dynamicMOIDLess(sorted[i].ref.Value, sorted[j].ref.Value)
+	})
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// dynamicMOIDLess reports whether managed object ID a sorts before b. IDs with
+// the same prefix are compared by their numeric suffix, so that vm-20 sorts
+// before vm-100.
+func dynamicMOIDLess(a, b string) bool {
+	ap, an := splitDynamicMOID(a)
+	bp, bn := splitDynamicMOID(b)
+	if ap != bp {
+		return ap < bp
+	}
+	ai, aerr := strconv.ParseUint(an, 10, 64)
+	bi, berr := strconv.ParseUint(bn, 10, 64)
+	if aerr == nil && berr == nil && ai != bi {
+		return ai < bi
+	}
+	return a < b
+}
+
+// splitDynamicMOID splits a managed object ID into its prefix and the
+// trailing digits, such as vm- and 100 for vm-100.
+func splitDynamicMOID(id string) (string, string) {
+	i := len(id)
+	for i > 0 && id[i-1] >= '0' && id[i-1] <= '9' {
+		i--
+	}
+	return id[:i], id[i:]
+}
+
+func filterObjectsByName(d *schema.ResourceData, meta interface{}, matches []tags.AttachedObjects) ([]dynamicObject, error) {
 	log.Printf("[DEBUG] dataSourceDynamic: Filtering objects by name.")
-	var filtered []string
-	re, err := regexp.Compile(d.Get("name_regex").(string))
+	var filtered []dynamicObject
+	re, err := compileDynamicNameRegex(
+		d.Get("name_regex").(string),
+		d.Get("name_regex_case_insensitive").(bool),
+		d.Get("name_regex_anchored").(bool),
+	)
+	if err != nil {
+		return nil, err
+	}
+	createdAfter, err := parseDynamicTimeFilter(d, "created_after")
+	if err != nil {
+		return nil, err
+	}
+	modifiedAfter, err := parseDynamicTimeFilter(d, "modified_after")
 	if err != nil {
 		return nil, err
 	}
@@ -85,15 +367,95 @@ func filterObjectsByName(d *schema.ResourceData, meta interface{}, matches []tag
 		if err != nil {
 			return nil, err
 		}
-		if re.Match([]byte(name)) {
-			log.Printf("[DEBUG] dataSourceDynamic: Match found: %s", name)
-			filtered = append(filtered, match.Reference().Value)
+		if !re.Match([]byte(name)) {
+			continue
+		}
+		if createdAfter != nil || modifiedAfter != nil {
+			created, modified, err := dynamicObjectTimestamps(meta.(*Client).vimClient, match.Reference())
+			if err != nil {
+				return nil, err
+			}
+			if !dynamicObjectInTimeWindow(created, modified, createdAfter, modifiedAfter) {
+				log.Printf("[DEBUG] dataSourceDynamic: Skipping %s outside of time window", name)
+				continue
+			}
 		}
+		log.Printf("[DEBUG] dataSourceDynamic: Match found: %s", name)
+		filtered = append(filtered, dynamicObject{ref: match.Reference(), name: name})
 	}
 	return filtered, nil
 }
 
-func filterObjectsByTag(tm *tags.Manager, t []interface{}) ([]tags.AttachedObjects, error) {
+// parseDynamicTimeFilter returns the time in the RFC 3339 formatted key, or
+// nil if the key is not set.
+func parseDynamicTimeFilter(d *schema.ResourceData, key string) (*time.Time, error) {
+	v := d.Get(key).(string)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %s", key, err)
+	}
+	return &t, nil
+}
+
+// dynamicObjectTimestamps returns the creation and modification time of a
+// managed object. Only virtual machines expose these times; nil is returned
+// for any time that is not known.
+func dynamicObjectTimestamps(client *govmomi.Client, ref types.ManagedObjectReference) (*time.Time, *time.Time, error) {
+	if ref.Type != "VirtualMachine" {
+		return nil, nil, nil
+	}
+	var vm mo.VirtualMachine
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := property.DefaultCollector(client.Client).RetrieveOne(ctx, ref, []string{"config.createDate", "config.modified"}, &vm); err != nil {
+		return nil, nil, fmt.Errorf("error fetching timestamps of %s: %s", ref.Value, err)
+	}
+	if vm.Config == nil {
+		return nil, nil, nil
+	}
+	var modified *time.Time
+	if !vm.Config.Modified.IsZero() {
+		modified = &vm.Config.Modified
+	}
+	return vm.Config.CreateDate, modified, nil
+}
+
+// dynamicObjectInTimeWindow returns true if an object with the supplied
+// creation and modification times was created after createdAfter and
+// modified after modifiedAfter. Filters that are nil, and times that are not
+// known, are skipped.
+func dynamicObjectInTimeWindow(created, modified, createdAfter, modifiedAfter *time.Time) bool {
+	if created != nil && createdAfter != nil && !created.After(*createdAfter) {
+		return false
+	}
+	if modified != nil && modifiedAfter != nil && !modified.After(*modifiedAfter) {
+		return false
+	}
+	return true
+}
+
+// compileDynamicNameRegex compiles the name_regex pattern, optionally matching
+// without regard to case or only matching entire names. An empty pattern
+// matches all names.
+func compileDynamicNameRegex(pattern string, caseInsensitive, anchored bool) (*regexp.Regexp, error) {
+	expr := pattern
+	if anchored && expr != "" {
+		expr = "^(?:" + expr + ")$"
+	}
+	if caseInsensitive {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling name_regex %q: %s", pattern, err)
+	}
+	return re, nil
+}
+
+func filterObjectsByTag(tm *tags.Manager, t []interface{}, match string) ([]tags.AttachedObjects, error) {
 	log.Printf("[DEBUG] dataSourceDynamic: Filtering objects by tags.")
 	var tagIDs []string
 	for _, ti := range t {
@@ -103,8 +465,15 @@ func filterObjectsByTag(tm *tags.Manager, t []interface{}) ([]tags.AttachedObjec
 	if err != nil {
 		return nil, err
 	}
-	for _, match := range matches {
-		matches[0] = attachedObjectsIntersection(matches[0], match)
+	if len(matches) < 1 {
+		return nil, fmt.Errorf("no resources match filter")
+	}
+	for _, m := range matches[1:] {
+		if match == dynamicMatchAny {
+			matches[0] = attachedObjectsUnion(matches[0], m)
+		} else {
+			matches[0] = attachedObjectsIntersection(matches[0], m)
+		}
 	}
 	if len(matches[0].ObjectIDs) < 1 {
 		return nil, fmt.Errorf("no resources match filter")
@@ -124,3 +493,18 @@ func attachedObjectsIntersection(a, b tags.AttachedObjects) tags.AttachedObjects
 	}
 	return inter
 }
+
+func attachedObjectsUnion(a, b tags.AttachedObjects) tags.AttachedObjects {
+	var union tags.AttachedObjects
+	seen := make(map[types.ManagedObjectReference]bool)
+	for _, objs := range [][]mo.Reference{a.ObjectIDs, b.ObjectIDs} {
+		for _, val := range objs {
+			if seen[val.Reference()] {
+				continue
+			}
+			seen[val.Reference()] = true
+			union.ObjectIDs = append(union.ObjectIDs, val)
+		}
+	}
+	return union
+}
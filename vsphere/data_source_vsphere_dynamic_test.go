@@ -6,11 +6,16 @@ package vsphere
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
 )
 
@@ -29,6 +34,8 @@ func TestAccDataSourceVSphereDynamic_regexAndTag(t *testing.T) {
 				Config: testAccDataSourceVSphereConfigRegexAndTag(),
 				Check: resource.ComposeTestCheckFunc(
 					testMatchDatacenterIDs("vsphere_datacenter.dc2", "data.vsphere_dynamic.dyn1"),
+					resource.TestCheckResourceAttrPair("data.vsphere_dynamic.dyn1", "name", "vsphere_datacenter.dc2", "name"),
+					resource.TestCheckResourceAttr("data.vsphere_dynamic.dyn1", "managed_object_type", "Datacenter"),
 				),
 			},
 			{
@@ -105,6 +112,134 @@ func TestAccDataSourceVSphereDynamic_typeFilter(t *testing.T) {
 	})
 }
 
+func TestAccDataSourceVSphereDynamic_sortAndLimit(t *testing.T) {
+	t.Cleanup(RunSweepers)
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceVSphereDynamicConfigBase(),
+			},
+			{
+				Config: testAccDataSourceVSphereConfigSortAndLimit(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.vsphere_dynamic.dyn5", "ids.#", "2"),
+					resource.TestCheckResourceAttrPair("data.vsphere_dynamic.dyn5", "ids.0", "vsphere_datacenter.dc1", "moid"),
+					resource.TestCheckResourceAttrPair("data.vsphere_dynamic.dyn5", "ids.1", "vsphere_datacenter.dc2", "moid"),
+					resource.TestCheckResourceAttr("data.vsphere_dynamic.dyn5", "matches.#", "2"),
+					resource.TestCheckResourceAttrPair("data.vsphere_dynamic.dyn5", "matches.0.id", "vsphere_datacenter.dc1", "moid"),
+					resource.TestCheckResourceAttrPair("data.vsphere_dynamic.dyn5", "matches.0.name", "vsphere_datacenter.dc1", "name"),
+					resource.TestCheckResourceAttr("data.vsphere_dynamic.dyn5", "matches.0.type", "Datacenter"),
+					resource.TestCheckResourceAttr("data.vsphere_dynamic.dyn6", "ids.#", "1"),
+					resource.TestCheckResourceAttrPair("data.vsphere_dynamic.dyn6", "ids.0", "vsphere_datacenter.dc1", "moid"),
+				),
+			},
+			{
+				Config: testAccDataSourceVSphereDynamicConfigBase(),
+			},
+		},
+	})
+}
+
+func TestAccDataSourceVSphereDynamic_tagByName(t *testing.T) {
+	t.Cleanup(RunSweepers)
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceVSphereDynamicConfigBase(),
+			},
+			{
+				Config: testAccDataSourceVSphereConfigTagByName(),
+				Check: resource.ComposeTestCheckFunc(
+					testMatchDatacenterIDs("vsphere_datacenter.dc2", "data.vsphere_dynamic.dyn7"),
+				),
+			},
+			{
+				Config: testAccDataSourceVSphereDynamicConfigBase(),
+			},
+		},
+	})
+}
+
+func TestAppendDynamicTagID(t *testing.T) {
+	tagIDs := []interface{}{"urn:vmomi:InventoryServiceTag:1:GLOBAL"}
+	tagIDs = appendDynamicTagID(tagIDs, "urn:vmomi:InventoryServiceTag:2:GLOBAL")
+	tagIDs = appendDynamicTagID(tagIDs, "urn:vmomi:InventoryServiceTag:1:GLOBAL")
+	expected := []interface{}{"urn:vmomi:InventoryServiceTag:1:GLOBAL", "urn:vmomi:InventoryServiceTag:2:GLOBAL"}
+	if !reflect.DeepEqual(expected, tagIDs) {
+		t.Fatalf("expected %v, got %v", expected, tagIDs)
+	}
+}
+
+func TestSortAndLimitDynamicObjects(t *testing.T) {
+	objs := []dynamicObject{
+		{ref: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-30"}, name: "b"},
+		{ref: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-10"}, name: "c"},
+		{ref: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-20"}, name: "a"},
+		{ref: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-15"}, name: "a"},
+		{ref: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-100"}, name: "a"},
+	}
+	cases := []struct {
+		name     string
+		sortBy   string
+		limit    int
+		expected []string
+	}{
+		{
+			name:     "sort by name",
+			sortBy:   dynamicSortByName,
+			expected: []string{"vm-15", "vm-20", "vm-100", "vm-30", "vm-10"},
+		},
+		{
+			name:     "sort by moid",
+			sortBy:   dynamicSortByMOID,
+			expected: []string{"vm-10", "vm-15", "vm-20", "vm-30", "vm-100"},
+		},
+		{
+			name:     "limit after sorting by name",
+			sortBy:   dynamicSortByName,
+			limit:    2,
+			expected: []string{"vm-15", "vm-20"},
+		},
+		{
+			name:     "limit after sorting by moid",
+			sortBy:   dynamicSortByMOID,
+			limit:    1,
+			expected: []string{"vm-10"},
+		},
+		{
+			name:     "limit larger than results",
+			sortBy:   dynamicSortByMOID,
+			limit:    10,
+			expected: []string{"vm-10", "vm-15", "vm-20", "vm-30", "vm-100"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := sortAndLimitDynamicObjects(objs, tc.sortBy, tc.limit)
+			if len(actual) != len(tc.expected) {
+				t.Fatalf("expected %d objects, got %d", len(tc.expected), len(actual))
+			}
+			for i, id := range tc.expected {
+				if actual[i].ref.Value != id {
+					t.Fatalf("expected object %d to be %s, got %s", i, id, actual[i].ref.Value)
+				}
+			}
+		})
+	}
+	if objs[0].ref.Value != "vm-30" {
+		t.Fatalf("expected input to be left unsorted")
+	}
+}
+
 func testMatchDatacenterIDs(a, b string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		ida := s.RootModule().Resources[a].Primary.Attributes["moid"]
@@ -184,3 +319,224 @@ data "vsphere_dynamic" "dyn4" {
 		testhelper.ConfigDataDC1(),
 	)
 }
+
+func testAccDataSourceVSphereConfigTagByName() string {
+	conf := `
+data "vsphere_dynamic" "dyn7" {
+  tag {
+    category = vsphere_tag_category.category1.name
+    name     = vsphere_tag.tag1.name
+  }
+  name_regex = "dc2"
+}
+	`
+	return testhelper.CombineConfigs(
+		testAccDataSourceVSphereDynamicConfigBase(),
+		conf,
+		testhelper.ConfigDataDC2(),
+	)
+}
+
+func testAccDataSourceVSphereConfigSortAndLimit() string {
+	conf := `
+data "vsphere_dynamic" "dyn5" {
+  filter     = [vsphere_tag.tag1.id]
+  name_regex = ""
+  multiple   = true
+  sort_by    = "name"
+}
+
+data "vsphere_dynamic" "dyn6" {
+  filter     = [vsphere_tag.tag1.id]
+  name_regex = ""
+  multiple   = true
+  sort_by    = "name"
+  limit      = 1
+}
+	`
+	return testhelper.CombineConfigs(
+		testAccDataSourceVSphereDynamicConfigBase(),
+		conf,
+	)
+}
+
+func TestFlattenDynamicObjects(t *testing.T) {
+	objs := []dynamicObject{
+		{ref: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-10"}, name: "a"},
+		{ref: types.ManagedObjectReference{Type: "Datastore", Value: "datastore-20"}, name: "b"},
+	}
+	actual := flattenDynamicObjects(objs)
+	if len(actual) != len(objs) {
+		t.Fatalf("expected %d matches, got %d", len(objs), len(actual))
+	}
+	for i, obj := range objs {
+		m := actual[i].(map[string]interface{})
+		if m["id"] != obj.ref.Value || m["name"] != obj.name || m["type"] != obj.ref.Type {
+			t.Fatalf("expected match %d to be %s/%s/%s, got %v", i, obj.ref.Value, obj.name, obj.ref.Type, m)
+		}
+	}
+}
+
+func TestAttachedObjectsUnionAndIntersection(t *testing.T) {
+	vm1 := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-10"}
+	vm2 := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-20"}
+	ds1 := types.ManagedObjectReference{Type: "Datastore", Value: "datastore-10"}
+	a := tags.AttachedObjects{ObjectIDs: []mo.Reference{vm1, vm2}}
+	b := tags.AttachedObjects{ObjectIDs: []mo.Reference{vm2, ds1}}
+
+	cases := []struct {
+		name     string
+		actual   tags.AttachedObjects
+		expected []string
+	}{
+		{
+			name:     "intersection",
+			actual:   attachedObjectsIntersection(a, b),
+			expected: []string{"vm-20"},
+		},
+		{
+			name:     "union",
+			actual:   attachedObjectsUnion(a, b),
+			expected: []string{"vm-10", "vm-20", "datastore-10"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual []string
+			for _, obj := range tc.actual.ObjectIDs {
+				actual = append(actual, obj.Reference().Value)
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCompileDynamicNameRegex(t *testing.T) {
+	names := []string{"prod", "Prod-01", "preprod", "dev"}
+	cases := []struct {
+		name            string
+		pattern         string
+		caseInsensitive bool
+		anchored        bool
+		expected        []string
+	}{
+		{
+			name:     "empty matches all",
+			pattern:  "",
+			anchored: true,
+			expected: []string{"prod", "Prod-01", "preprod", "dev"},
+		},
+		{
+			name:     "unanchored and case-sensitive",
+			pattern:  "prod",
+			expected: []string{"prod", "preprod"},
+		},
+		{
+			name:            "case-insensitive",
+			pattern:         "prod",
+			caseInsensitive: true,
+			expected:        []string{"prod", "Prod-01", "preprod"},
+		},
+		{
+			name:     "anchored",
+			pattern:  "prod",
+			anchored: true,
+			expected: []string{"prod"},
+		},
+		{
+			name:            "anchored alternation and case-insensitive",
+			pattern:         "prod|dev",
+			caseInsensitive: true,
+			anchored:        true,
+			expected:        []string{"prod", "dev"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			re, err := compileDynamicNameRegex(tc.pattern, tc.caseInsensitive, tc.anchored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []string
+			for _, name := range names {
+				if re.MatchString(name) {
+					actual = append(actual, name)
+				}
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+
+	if _, err := compileDynamicNameRegex("prod(", false, false); err == nil || !regexp.MustCompile(`error compiling name_regex "prod\("`).MatchString(err.Error()) {
+		t.Fatalf("expected name_regex compile error, got %v", err)
+	}
+}
+
+func TestDynamicObjectInTimeWindow(t *testing.T) {
+	lastWeek := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	before := lastWeek.Add(-time.Hour)
+	after := lastWeek.Add(time.Hour)
+
+	cases := []struct {
+		name          string
+		created       *time.Time
+		modified      *time.Time
+		createdAfter  *time.Time
+		modifiedAfter *time.Time
+		expected      bool
+	}{
+		{
+			name:     "no filters",
+			created:  &before,
+			modified: &before,
+			expected: true,
+		},
+		{
+			name:         "created in window",
+			created:      &after,
+			createdAfter: &lastWeek,
+			expected:     true,
+		},
+		{
+			name:         "created before window",
+			created:      &before,
+			createdAfter: &lastWeek,
+			expected:     false,
+		},
+		{
+			name:          "modified in window",
+			modified:      &after,
+			modifiedAfter: &lastWeek,
+			expected:      true,
+		},
+		{
+			name:          "modified before window",
+			created:       &after,
+			modified:      &before,
+			createdAfter:  &lastWeek,
+			modifiedAfter: &lastWeek,
+			expected:      false,
+		},
+		{
+			name:          "no timestamps",
+			createdAfter:  &lastWeek,
+			modifiedAfter: &lastWeek,
+			expected:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := dynamicObjectInTimeWindow(tc.created, tc.modified, tc.createdAfter, tc.modifiedAfter)
+			if tc.expected != actual {
+				t.Fatalf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+)
+
+func dataSourceVSphereHardwareVersions() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereHardwareVersionsRead,
+
+		Schema: map[string]*schema.Schema{
+			"max_hardware_version": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The latest virtual machine hardware version supported by the connected vCenter Server or ESXi host.",
+			},
+			"hardware_versions": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The virtual machine hardware versions that can be set in hardware_version, up to max_hardware_version.",
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+			},
+		},
+	}
+}
+
+func dataSourceVSphereHardwareVersionsRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	version := viapi.ParseVersionFromClient(client)
+	maxVersion := viapi.MaxHardwareVersion(version)
+	log.Printf("[DEBUG] Latest hardware version supported by %s: %d", version, maxVersion)
+
+	d.SetId(version.String())
+	if err := d.Set("max_hardware_version", maxVersion); err != nil {
+		return err
+	}
+	return d.Set("hardware_versions", supportedHardwareVersions(maxVersion))
+}
+
+// supportedHardwareVersions returns the hardware versions accepted by the
+// hardware_version attribute of vsphere_virtual_machine, up to and including
+// maxVersion.
+func supportedHardwareVersions(maxVersion int) []int {
+	var versions []int
+	for _, r := range virtualMachineHardwareVersionValidRanges {
+		for v := r[0]; v <= r[1] && v <= maxVersion; v++ {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
@@ -0,0 +1,69 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDataSourceVSphereHardwareVersions_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceVSphereHardwareVersionsConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.vsphere_hardware_versions.versions", "max_hardware_version"),
+					resource.TestCheckResourceAttrSet("data.vsphere_hardware_versions.versions", "hardware_versions.#"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceVSphereHardwareVersionsConfig() string {
+	return `
+data "vsphere_hardware_versions" "versions" {}
+`
+}
+
+func TestSupportedHardwareVersions(t *testing.T) {
+	cases := []struct {
+		name       string
+		maxVersion int
+		expected   []int
+	}{
+		{
+			name:       "vSphere 6.0",
+			maxVersion: 11,
+			expected:   []int{4, 7, 8, 9, 10, 11},
+		},
+		{
+			name:       "vSphere 6.7",
+			maxVersion: 14,
+			expected:   []int{4, 7, 8, 9, 10, 11, 13, 14},
+		},
+		{
+			name:       "vSphere 8.0 Update 2",
+			maxVersion: 21,
+			expected:   []int{4, 7, 8, 9, 10, 11, 13, 14, 15, 17, 18, 19, 20, 21},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := supportedHardwareVersions(tc.maxVersion); !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
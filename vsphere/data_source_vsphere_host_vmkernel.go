@@ -0,0 +1,96 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/provider"
+)
+
+func dataSourceVSphereHostVmkernel() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereHostVmkernelRead,
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The managed object ID of the host to look for the vmkernel adapter on.",
+			},
+			"service": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "The service that is enabled on the vmkernel adapter. One of 'management', 'vmotion', or 'vsan'.",
+				ValidateFunc: validation.StringInSlice(vnicServiceTypeAllowedValues, false),
+			},
+			"device": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The device name of the vmkernel adapter, such as vmk0.",
+			},
+			"ip_address": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The IPv4 address of the vmkernel adapter.",
+			},
+			"netstack": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The TCP/IP stack of the vmkernel adapter.",
+			},
+		},
+	}
+}
+
+func dataSourceVSphereHostVmkernelRead(d *schema.ResourceData, meta interface{}) error {
+	ctx := context.TODO()
+	client := meta.(*Client).vimClient
+	hostID := d.Get("host_system_id").(string)
+	service := d.Get("service").(string)
+
+	services, err := getVnicServicesFromHost(ctx, client, hostID)
+	if err != nil {
+		return provider.Error(hostID, "dataSourceVSphereHostVmkernelRead", err)
+	}
+	device, err := vnicDeviceForService(services, service)
+	if err != nil {
+		return provider.Error(hostID, "dataSourceVSphereHostVmkernelRead", err)
+	}
+	vnic, err := getVnicFromHost(ctx, client, hostID, device)
+	if err != nil {
+		return provider.Error(hostID, "dataSourceVSphereHostVmkernelRead", err)
+	}
+
+	d.SetId(fmt.Sprintf("%s_%s", hostID, device))
+	_ = d.Set("device", device)
+	_ = d.Set("netstack", vnic.Spec.NetStackInstanceKey)
+	if vnic.Spec.Ip != nil {
+		_ = d.Set("ip_address", vnic.Spec.Ip.IpAddress)
+	}
+	return nil
+}
+
+// vnicDeviceForService returns the device name of the vmkernel adapter that
+// has service enabled, from the services of the adapters of a host keyed by
+// device name. If more than one adapter has the service enabled, the first
+// one in the order of the device names is returned.
+func vnicDeviceForService(services map[string][]string, service string) (string, error) {
+	var devices []string
+	for device, s := range services {
+		if slices.Contains(s, service) {
+			devices = append(devices, device)
+		}
+	}
+	if len(devices) < 1 {
+		return "", fmt.Errorf("no vmkernel adapter has the %s service enabled", service)
+	}
+	sort.Strings(devices)
+	return devices[0], nil
+}
@@ -0,0 +1,55 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"testing"
+)
+
+func TestVnicDeviceForService(t *testing.T) {
+	services := map[string][]string{
+		"vmk0": {vnicServiceTypeManagement},
+		"vmk1": {vnicServiceTypeVmotion, vnicServiceTypeVsan},
+		"vmk2": {vnicServiceTypeVsan},
+	}
+	cases := []struct {
+		name        string
+		service     string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "management",
+			service:  vnicServiceTypeManagement,
+			expected: "vmk0",
+		},
+		{
+			name:     "one of several services",
+			service:  vnicServiceTypeVmotion,
+			expected: "vmk1",
+		},
+		{
+			name:     "several adapters",
+			service:  vnicServiceTypeVsan,
+			expected: "vmk1",
+		},
+		{
+			name:        "no adapter",
+			service:     "faultToleranceLogging",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := vnicDeviceForService(services, tc.service)
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectedErr, err)
+			}
+			if tc.expected != actual {
+				t.Fatalf("expected device %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -167,6 +167,40 @@ func dataSourceVSphereVirtualMachine() *schema.Resource {
 			Computed:    true,
 			Elem:        &schema.Schema{Type: schema.TypeString},
 		},
+		"guest_network_interfaces": schemaVirtualMachineGuestNetworkInterfaces(),
+		"guest_dns_servers": {
+			Type:        schema.TypeList,
+			Description: "The DNS servers reported by VMware Tools for the guest.",
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"guest_dns_search_domains": {
+			Type:        schema.TypeList,
+			Description: "The DNS search domains reported by VMware Tools for the guest.",
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"guest_hostname": {
+			Type:        schema.TypeString,
+			Description: "The hostname reported by VMware Tools for the guest.",
+			Computed:    true,
+		},
+		"guest_domain": {
+			Type:        schema.TypeString,
+			Description: "The DNS domain name reported by VMware Tools for the guest.",
+			Computed:    true,
+		},
+		"include_link_local_guest_ips": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Include link-local and loopback addresses in guest_ip_addresses and the selection of default_ip_address.",
+		},
+		"tools_status":         schemaVirtualMachineToolsStatus(),
+		"tools_running_status": schemaVirtualMachineToolsRunningStatus(),
+		"tools_version_status": schemaVirtualMachineToolsVersionStatus(),
+		"guest_state":          schemaVirtualMachineGuestState(),
+		"power_state":          schemaVirtualMachinePowerState(),
 		"instance_uuid": {
 			Type:        schema.TypeString,
 			Computed:    true,
@@ -290,10 +324,16 @@ func dataSourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{
 	if err := d.Set("network_interfaces", networkInterfaces); err != nil {
 		return fmt.Errorf("error setting network interfaces: %s", err)
 	}
+	flattenVirtualMachinePowerState(d, props.Runtime.PowerState)
 	if props.Guest != nil {
-		if err := buildAndSelectGuestIPs(d, *props.Guest); err != nil {
+		if err := buildAndSelectGuestIPs(d, *props.Guest, guestIPSelectionOptions{
+			IncludeLinkLocal: d.Get("include_link_local_guest_ips").(bool),
+		}); err != nil {
 			return fmt.Errorf("error setting guest IP addresses: %s", err)
 		}
+		if err := flattenGuestReadiness(d, *props.Guest); err != nil {
+			return fmt.Errorf("error setting guest state: %s", err)
+		}
 	}
 
 	var isVTPMPresent bool
@@ -0,0 +1,136 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
+)
+
+func dataSourceVSphereVirtualMachineSnapshots() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereVirtualMachineSnapshotsRead,
+		Schema: map[string]*schema.Schema{
+			"virtual_machine_uuid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The UUID of the virtual machine.",
+			},
+			"snapshots": {
+				Type:        schema.TypeList,
+				Description: "The snapshots of the virtual machine, with each snapshot followed by its children.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The managed object ID of the snapshot.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the snapshot.",
+						},
+						"description": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The description of the snapshot.",
+						},
+						"create_time": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The time, in RFC 3339 format, at which the snapshot was taken.",
+						},
+						"size": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The size of the snapshot files on the datastore, in bytes.",
+						},
+						"is_current": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether this is the current snapshot of the virtual machine.",
+						},
+						"parent_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The managed object ID of the parent snapshot. Empty for root snapshots.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVSphereVirtualMachineSnapshotsRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	uuid := d.Get("virtual_machine_uuid").(string)
+	log.Printf("[DEBUG] DataVirtualMachineSnapshots: Reading snapshots of virtual machine %s", uuid)
+
+	vm, err := virtualmachine.FromUUID(client, uuid)
+	if err != nil {
+		return fmt.Errorf("error while getting the virtual machine :%s", err)
+	}
+	props, err := virtualmachine.Properties(vm)
+	if err != nil {
+		return fmt.Errorf("error while getting the virtual machine properties :%s", err)
+	}
+
+	snapshots := flattenVirtualMachineSnapshotTree(props)
+	if err := d.Set("snapshots", snapshots); err != nil {
+		return err
+	}
+	d.SetId(uuid)
+
+	log.Printf("[DEBUG] DataVirtualMachineSnapshots: Found %d snapshots on virtual machine %s", len(snapshots), uuid)
+	return nil
+}
+
+// flattenVirtualMachineSnapshotTree walks the snapshot tree of a virtual
+// machine and returns a flat list of its snapshots, with each snapshot
+// followed by its children. An empty list is returned if the virtual machine
+// has no snapshots.
+func flattenVirtualMachineSnapshotTree(props *mo.VirtualMachine) []interface{} {
+	snapshots := make([]interface{}, 0)
+	if props.Snapshot == nil {
+		return snapshots
+	}
+	var walk func(trees []types.VirtualMachineSnapshotTree, parent *types.ManagedObjectReference)
+	walk = func(trees []types.VirtualMachineSnapshotTree, parent *types.ManagedObjectReference) {
+		for i := range trees {
+			tree := trees[i]
+			isCurrent := props.Snapshot.CurrentSnapshot != nil && props.Snapshot.CurrentSnapshot.Value == tree.Snapshot.Value
+			var size int
+			if props.LayoutEx != nil {
+				size = object.SnapshotSize(tree.Snapshot, parent, props.LayoutEx, isCurrent)
+			}
+			var parentID string
+			if parent != nil {
+				parentID = parent.Value
+			}
+			snapshots = append(snapshots, map[string]interface{}{
+				"id":          tree.Snapshot.Value,
+				"name":        tree.Name,
+				"description": tree.Description,
+				"create_time": tree.CreateTime.UTC().Format(time.RFC3339),
+				"size":        size,
+				"is_current":  isCurrent,
+				"parent_id":   parentID,
+			})
+			walk(tree.ChildSnapshotList, &tree.Snapshot)
+		}
+	}
+	walk(props.Snapshot.RootSnapshotList, nil)
+	return snapshots
+}
@@ -0,0 +1,106 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestAccDataSourceVSphereVirtualMachineSnapshots_basic(t *testing.T) {
+	testAccSkipUnstable(t)
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereVirtualMachineSnapshotPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceVSphereVirtualMachineSnapshotsConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.vsphere_virtual_machine_snapshots.snapshots", "snapshots.#", "1"),
+					resource.TestCheckResourceAttrPair(
+						"data.vsphere_virtual_machine_snapshots.snapshots", "snapshots.0.id",
+						"vsphere_virtual_machine_snapshot.snapshot.0", "id",
+					),
+					resource.TestCheckResourceAttr("data.vsphere_virtual_machine_snapshots.snapshots", "snapshots.0.name", "terraform-test-snapshot"),
+					resource.TestCheckResourceAttr("data.vsphere_virtual_machine_snapshots.snapshots", "snapshots.0.is_current", "true"),
+					resource.TestCheckResourceAttr("data.vsphere_virtual_machine_snapshots.snapshots", "snapshots.0.parent_id", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestFlattenVirtualMachineSnapshotTree(t *testing.T) {
+	ref := func(id string) types.ManagedObjectReference {
+		return types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: id}
+	}
+	current := ref("snapshot-3")
+	props := &mo.VirtualMachine{
+		Snapshot: &types.VirtualMachineSnapshotInfo{
+			CurrentSnapshot: &current,
+			RootSnapshotList: []types.VirtualMachineSnapshotTree{
+				{
+					Snapshot: ref("snapshot-1"),
+					Name:     "root",
+					ChildSnapshotList: []types.VirtualMachineSnapshotTree{
+						{
+							Snapshot: ref("snapshot-2"),
+							Name:     "child-1",
+						},
+						{
+							Snapshot: ref("snapshot-3"),
+							Name:     "child-2",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := []struct {
+		id        string
+		name      string
+		parentID  string
+		isCurrent bool
+	}{
+		{id: "snapshot-1", name: "root", parentID: "", isCurrent: false},
+		{id: "snapshot-2", name: "child-1", parentID: "snapshot-1", isCurrent: false},
+		{id: "snapshot-3", name: "child-2", parentID: "snapshot-1", isCurrent: true},
+	}
+	actual := flattenVirtualMachineSnapshotTree(props)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d snapshots, got %d", len(expected), len(actual))
+	}
+	for i, e := range expected {
+		a := actual[i].(map[string]interface{})
+		if a["id"] != e.id || a["name"] != e.name || a["parent_id"] != e.parentID || a["is_current"] != e.isCurrent {
+			t.Fatalf("expected snapshot %d to be %+v, got %#v", i, e, a)
+		}
+	}
+
+	if actual := flattenVirtualMachineSnapshotTree(&mo.VirtualMachine{}); len(actual) != 0 {
+		t.Fatalf("expected no snapshots, got %d", len(actual))
+	}
+}
+
+func testAccDataSourceVSphereVirtualMachineSnapshotsConfig() string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_virtual_machine_snapshots" "snapshots" {
+  virtual_machine_uuid = vsphere_virtual_machine_snapshot.snapshot[0].virtual_machine_uuid
+}
+`,
+		testAccResourceVSphereVirtualMachineSnapshotConfig(true),
+	)
+}
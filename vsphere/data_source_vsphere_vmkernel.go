@@ -0,0 +1,184 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceVSphereVmkernel() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereVmkernelRead,
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The managed object ID of the host system.",
+			},
+			"vmkernel_adapters": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The list of vmkernel adapters on the host.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"device": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The device name of the vmkernel adapter, such as vmk0.",
+						},
+						"key": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The linkable identifier of the vmkernel adapter.",
+						},
+						"portgroup": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The standard port group the adapter is connected to, if any.",
+						},
+						"distributed_switch_port": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The UUID of the distributed switch the adapter is connected to, if any.",
+						},
+						"distributed_port_group": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The key of the distributed port group the adapter is connected to, if any.",
+						},
+						"mac": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The MAC address of the adapter.",
+						},
+						"mtu": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The MTU of the adapter.",
+						},
+						"netstack": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The TCP/IP stack instance the adapter belongs to.",
+						},
+						"services": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "The services enabled on the adapter.",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"ipv4": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "The IPv4 configuration of the adapter.",
+							Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+								"dhcp": {
+									Type:        schema.TypeBool,
+									Computed:    true,
+									Description: "Whether DHCP is used to configure the IPv4 stack.",
+								},
+								"ip": {
+									Type:        schema.TypeString,
+									Computed:    true,
+									Description: "The IPv4 address of the adapter.",
+								},
+								"netmask": {
+									Type:        schema.TypeString,
+									Computed:    true,
+									Description: "The IPv4 netmask of the adapter.",
+								},
+								"gw": {
+									Type:        schema.TypeString,
+									Computed:    true,
+									Description: "The IPv4 default gateway of the adapter.",
+								},
+							}},
+						},
+						"ipv6": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "The IPv6 configuration of the adapter.",
+							Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+								"dhcp": {
+									Type:        schema.TypeBool,
+									Computed:    true,
+									Description: "Whether DHCPv6 is used to configure the IPv6 stack.",
+								},
+								"autoconfig": {
+									Type:        schema.TypeBool,
+									Computed:    true,
+									Description: "Whether IPv6 autoconfiguration is enabled.",
+								},
+								"addresses": {
+									Type:        schema.TypeList,
+									Computed:    true,
+									Description: "The manually configured IPv6 addresses of the adapter.",
+									Elem:        &schema.Schema{Type: schema.TypeString},
+								},
+								"gw": {
+									Type:        schema.TypeString,
+									Computed:    true,
+									Description: "The IPv6 default gateway of the adapter.",
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVSphereVmkernelRead(d *schema.ResourceData, meta interface{}) error {
+	ctx := context.TODO()
+	client := meta.(*Client).vimClient
+	hostID := d.Get("host_system_id").(string)
+	log.Printf("[DEBUG] DataVmkernel: Beginning vmkernel adapter lookup on %s", hostID)
+
+	vnics, err := getVnicsFromHost(ctx, client, hostID)
+	if err != nil {
+		return err
+	}
+	services, err := getVnicServicesFromHost(ctx, client, hostID)
+	if err != nil {
+		return err
+	}
+
+	adapters := make([]interface{}, 0, len(vnics))
+	for _, vnic := range vnics {
+		log.Printf("[DEBUG] DataVmkernel: Host %s has vmkernel adapter %s", hostID, vnic.Device)
+		adapter := map[string]interface{}{
+			"device":    vnic.Device,
+			"key":       vnic.Key,
+			"portgroup": vnic.Portgroup,
+			"mac":       vnic.Spec.Mac,
+			"mtu":       vnic.Spec.Mtu,
+			"netstack":  vnic.Spec.NetStackInstanceKey,
+			"services":  services[vnic.Device],
+		}
+		if vnic.Spec.DistributedVirtualPort != nil {
+			adapter["distributed_switch_port"] = vnic.Spec.DistributedVirtualPort.SwitchUuid
+			adapter["distributed_port_group"] = vnic.Spec.DistributedVirtualPort.PortgroupKey
+		}
+		if ipv4dict := flattenHostVirtualNicIPv4(vnic.Spec); ipv4dict != nil {
+			adapter["ipv4"] = []interface{}{ipv4dict}
+		}
+		if ipv6dict := flattenHostVirtualNicIPv6(vnic.Spec); ipv6dict != nil {
+			adapter["ipv6"] = []interface{}{ipv6dict}
+		}
+		adapters = append(adapters, adapter)
+	}
+
+	d.SetId(hostID)
+	if err := d.Set("vmkernel_adapters", adapters); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] DataVmkernel: Identified %d vmkernel adapters on %s", len(adapters), hostID)
+	return nil
+}
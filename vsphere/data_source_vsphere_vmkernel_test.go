@@ -0,0 +1,230 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestAccDataSourceVSphereVmkernel_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceVSphereVmkernelConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.vsphere_vmkernel.vmk",
+						"id",
+						"data.vsphere_host.roothost1",
+						"id",
+					),
+					resource.TestMatchResourceAttr(
+						"data.vsphere_vmkernel.vmk",
+						"vmkernel_adapters.0.device",
+						regexp.MustCompile("^vmk[0-9]+$"),
+					),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceVSphereVmkernelConfig() string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_vmkernel" "vmk" {
+  host_system_id = data.vsphere_host.roothost1.id
+}
+`, testhelper.CombineConfigs(testhelper.ConfigDataRootDC1(), testhelper.ConfigDataRootHost1()))
+}
+
+func TestFlattenHostVirtualNicIPv4(t *testing.T) {
+	cases := []struct {
+		name     string
+		spec     types.HostVirtualNicSpec
+		expected map[string]interface{}
+	}{
+		{
+			name: "no ip config",
+			spec: types.HostVirtualNicSpec{},
+		},
+		{
+			name: "ipv4 disabled",
+			spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{},
+			},
+		},
+		{
+			name: "dhcp",
+			spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					Dhcp:       true,
+					IpAddress:  "198.51.100.10",
+					SubnetMask: "255.255.255.0",
+				},
+			},
+			expected: map[string]interface{}{
+				"dhcp": true,
+			},
+		},
+		{
+			name: "static with gateway",
+			spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					IpAddress:  "198.51.100.10",
+					SubnetMask: "255.255.255.0",
+				},
+				IpRouteSpec: &types.HostVirtualNicIpRouteSpec{
+					IpRouteConfig: &types.HostIpRouteConfig{
+						DefaultGateway: "198.51.100.1",
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"dhcp":    false,
+				"ip":      "198.51.100.10",
+				"netmask": "255.255.255.0",
+				"gw":      "198.51.100.1",
+			},
+		},
+		{
+			name: "static without route spec",
+			spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					IpAddress:  "198.51.100.10",
+					SubnetMask: "255.255.255.0",
+				},
+			},
+			expected: map[string]interface{}{
+				"dhcp":    false,
+				"ip":      "198.51.100.10",
+				"netmask": "255.255.255.0",
+				"gw":      "",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := flattenHostVirtualNicIPv4(tc.spec)
+			if tc.expected == nil {
+				if actual != nil {
+					t.Fatalf("expected no ipv4 block, got %v", actual)
+				}
+				return
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFlattenHostVirtualNicIPv6(t *testing.T) {
+	enabled := true
+	disabled := false
+	cases := []struct {
+		name     string
+		spec     types.HostVirtualNicSpec
+		expected map[string]interface{}
+	}{
+		{
+			name: "no ip config",
+			spec: types.HostVirtualNicSpec{},
+		},
+		{
+			name: "ipv6 disabled",
+			spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{},
+			},
+		},
+		{
+			name: "only link-local address",
+			spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					IpV6Config: &types.HostIpConfigIpV6AddressConfiguration{
+						DhcpV6Enabled:            &disabled,
+						AutoConfigurationEnabled: &disabled,
+						IpV6Address: []types.HostIpConfigIpV6Address{
+							{IpAddress: "fe80::1", PrefixLength: 64, Origin: "other"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "dhcp and autoconfig",
+			spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					IpV6Config: &types.HostIpConfigIpV6AddressConfiguration{
+						DhcpV6Enabled:            &enabled,
+						AutoConfigurationEnabled: &enabled,
+						IpV6Address: []types.HostIpConfigIpV6Address{
+							{IpAddress: "2001:db8::20", PrefixLength: 64, Origin: "dhcp"},
+						},
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"dhcp":       true,
+				"autoconfig": true,
+				"addresses":  []string{},
+				"gw":         "",
+			},
+		},
+		{
+			name: "static addresses with gateway",
+			spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					IpV6Config: &types.HostIpConfigIpV6AddressConfiguration{
+						IpV6Address: []types.HostIpConfigIpV6Address{
+							{IpAddress: "2001:DB8:0:0:0:0:0:10", PrefixLength: 64, Origin: "manual"},
+							{IpAddress: "fe80::1", PrefixLength: 64, Origin: "other"},
+						},
+					},
+				},
+				IpRouteSpec: &types.HostVirtualNicIpRouteSpec{
+					IpRouteConfig: &types.HostIpRouteConfig{
+						IpV6DefaultGateway: "2001:DB8::1",
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"dhcp":       false,
+				"autoconfig": false,
+				"addresses":  []string{"2001:db8::10/64"},
+				"gw":         "2001:db8::1",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := flattenHostVirtualNicIPv6(tc.spec)
+			if tc.expected == nil {
+				if actual != nil {
+					t.Fatalf("expected no ipv6 block, got %v", actual)
+				}
+				return
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
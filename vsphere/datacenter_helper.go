@@ -16,21 +16,23 @@ import (
 )
 
 // getDatacenter gets the higher-level datacenter object for the datacenter
-// name supplied by dc.
+// name supplied by dc. The lookup is bound by the configured api_timeout.
 //
 // The default datacenter is denoted by using an empty string. When working
 // with ESXi directly, the default datacenter is always selected.
 func getDatacenter(c *govmomi.Client, dc string) (*object.Datacenter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
 	finder := find.NewFinder(c.Client, true)
 	t := c.ServiceContent.About.ApiType
 	switch t {
 	case "HostAgent":
-		return finder.DefaultDatacenter(context.TODO())
+		return finder.DefaultDatacenter(ctx)
 	case "VirtualCenter":
 		if dc != "" {
-			return finder.Datacenter(context.TODO(), dc)
+			return finder.Datacenter(ctx, dc)
 		}
-		return finder.DefaultDatacenter(context.TODO())
+		return finder.DefaultDatacenter(ctx)
 	}
 	return nil, fmt.Errorf("unsupported ApiType: %s", t)
 }
@@ -57,7 +59,7 @@ func datacenterCustomAttributes(dc *object.Datacenter) (*mo.Datacenter, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
 	defer cancel()
 	var props mo.Datacenter
-	if err := dc.Properties(ctx, dc.Reference(), []string{"customValue"}, &props); err != nil {
+	if err := dc.Properties(ctx, dc.Reference(), []string{"customValue", "availableField"}, &props); err != nil {
 		return nil, err
 	}
 	return &props, nil
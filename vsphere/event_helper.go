@@ -84,7 +84,7 @@ func (w *virtualMachineCustomizationWaiter) wait(client *govmomi.Client, vm *obj
 		for _, be := range page {
 			switch e := be.(type) {
 			case types.BaseCustomizationFailed:
-				cbErr <- errors.New(e.GetCustomizationFailed().GetEvent().FullFormattedMessage)
+				cbErr <- customizationFailedError(e.GetCustomizationFailed())
 			case *types.CustomizationSucceeded:
 				close(cbErr)
 			}
@@ -121,6 +121,24 @@ func (w *virtualMachineCustomizationWaiter) wait(client *govmomi.Client, vm *obj
 	return err
 }
 
+// customizationFailedError returns an error for a failed customization. The
+// API does not return the contents of the customization log in the guest, so
+// the error includes the reason for the failure and the location of the log,
+// when they are reported, to help track down the cause.
+func customizationFailedError(e *types.CustomizationFailed) error {
+	msg := e.FullFormattedMessage
+	if msg == "" {
+		msg = "guest customization failed"
+	}
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s (reason: %s)", msg, e.Reason)
+	}
+	if e.LogLocation != "" {
+		msg = fmt.Sprintf("%s; see the customization log at %s in the guest for details", msg, e.LogLocation)
+	}
+	return errors.New(msg)
+}
+
 // selectEventsForReference allows you to query events for a specific
 // ManagedObjectReference.
 //
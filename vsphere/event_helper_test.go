@@ -0,0 +1,52 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestCustomizationFailedError(t *testing.T) {
+	cases := []struct {
+		name     string
+		event    types.CustomizationFailed
+		expected string
+	}{
+		{
+			name:     "no details",
+			expected: "guest customization failed",
+		},
+		{
+			name: "message only",
+			event: types.CustomizationFailed{
+				CustomizationEvent: types.CustomizationEvent{
+					VmEvent: types.VmEvent{Event: types.Event{FullFormattedMessage: "Sysprep failed"}},
+				},
+			},
+			expected: "Sysprep failed",
+		},
+		{
+			name: "reason and log location",
+			event: types.CustomizationFailed{
+				CustomizationEvent: types.CustomizationEvent{
+					VmEvent:     types.VmEvent{Event: types.Event{FullFormattedMessage: "Customization failed"}},
+					LogLocation: "/var/log/vmware-imc/toolsDeployPkg.log",
+				},
+				Reason: "timedOut",
+			},
+			expected: "Customization failed (reason: timedOut); see the customization log at /var/log/vmware-imc/toolsDeployPkg.log in the guest for details",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := customizationFailedError(&tc.event).Error(); actual != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
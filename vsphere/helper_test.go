@@ -175,6 +175,26 @@ func testGetPortGroup(s *terraform.State, resourceName string) (*types.HostPortG
 	return hostPortGroupFromName(tVars.client, ns, name)
 }
 
+// testGetHostNetStack is a convenience method to fetch a TCP/IP stack
+// instance by resource name.
+func testGetHostNetStack(s *terraform.State, resourceName string) (*types.HostNetStackInstance, error) {
+	tVars, err := testClientVariablesForResource(s, fmt.Sprintf("vsphere_host_netstack.%s", resourceName))
+	if err != nil {
+		return nil, err
+	}
+
+	hsID, key, err := splitHostNetStackID(tVars.resourceID)
+	if err != nil {
+		return nil, err
+	}
+	ns, err := hostNetworkSystemFromHostSystemID(tVars.client, hsID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading host network system: %s", err)
+	}
+
+	return hostNetStackFromKey(tVars.client, ns, key)
+}
+
 // testGetVirtualMachine is a convenience method to fetch a virtual machine by
 // resource name.
 func testGetVirtualMachine(s *terraform.State, resourceName string) (*object.VirtualMachine, error) {
@@ -0,0 +1,134 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
+)
+
+const hostNetStackIDPrefix = "tf-HostNetStack"
+
+var hostNetStackCongestionControlAlgorithmAllowedValues = []string{
+	string(types.HostNetStackInstanceCongestionControlAlgorithmTypeNewreno),
+	string(types.HostNetStackInstanceCongestionControlAlgorithmTypeCubic),
+}
+
+// schemaHostNetStackInstance returns schema items for resources that need to
+// work with a HostNetStackInstance.
+func schemaHostNetStackInstance() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		// HostNetStackInstance
+		"name": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "The name of the TCP/IP stack instance. This is also used as the key of the instance.",
+			ForceNew:    true,
+		},
+		"congestion_control_algorithm": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			Description:  "The TCP congestion control algorithm used by the instance. Can be one of newreno or cubic.",
+			ValidateFunc: validation.StringInSlice(hostNetStackCongestionControlAlgorithmAllowedValues, false),
+		},
+		"max_connections": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Computed:     true,
+			Description:  "The requested maximum number of socket connections for the instance.",
+			ValidateFunc: validation.IntAtLeast(1),
+		},
+		// HostDnsConfig
+		"dns_host_name": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The host name used by the instance.",
+		},
+		"dns_domain_name": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The domain name used by the instance.",
+		},
+		"dns_servers": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "The DNS servers used by the instance.",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"dns_search_domains": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "The domain names to search when resolving host names.",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+	}
+}
+
+// expandHostNetStackInstance reads certain ResourceData keys and returns a
+// HostNetStackInstance.
+func expandHostNetStackInstance(d *schema.ResourceData) *types.HostNetStackInstance {
+	name := d.Get("name").(string)
+	obj := &types.HostNetStackInstance{
+		Key:                             name,
+		Name:                            name,
+		CongestionControlAlgorithm:      d.Get("congestion_control_algorithm").(string),
+		RequestedMaxNumberOfConnections: int32(d.Get("max_connections").(int)),
+		DnsConfig: &types.HostDnsConfig{
+			HostName:     d.Get("dns_host_name").(string),
+			DomainName:   d.Get("dns_domain_name").(string),
+			Address:      structure.SliceInterfacesToStrings(d.Get("dns_servers").([]interface{})),
+			SearchDomain: structure.SliceInterfacesToStrings(d.Get("dns_search_domains").([]interface{})),
+		},
+	}
+	return obj
+}
+
+// flattenHostNetStackInstance reads various fields from a
+// HostNetStackInstance into the passed in ResourceData.
+func flattenHostNetStackInstance(d *schema.ResourceData, obj *types.HostNetStackInstance) error {
+	_ = d.Set("name", obj.Key)
+	_ = d.Set("congestion_control_algorithm", obj.CongestionControlAlgorithm)
+	_ = d.Set("max_connections", obj.RequestedMaxNumberOfConnections)
+	if obj.DnsConfig != nil {
+		dns := obj.DnsConfig.GetHostDnsConfig()
+		_ = d.Set("dns_host_name", dns.HostName)
+		_ = d.Set("dns_domain_name", dns.DomainName)
+		if err := d.Set("dns_servers", dns.Address); err != nil {
+			return err
+		}
+		if err := d.Set("dns_search_domains", dns.SearchDomain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveHostNetStackID sets a special ID for a host TCP/IP stack instance,
+// composed of the MOID for the concerned HostSystem and the instance's key.
+func saveHostNetStackID(d *schema.ResourceData, hsID, key string) {
+	d.SetId(fmt.Sprintf("%s:%s:%s", hostNetStackIDPrefix, hsID, key))
+}
+
+// splitHostNetStackID splits a vsphere_host_netstack resource ID into its
+// counterparts: the prefix, the HostSystem ID, and the instance key.
+func splitHostNetStackID(raw string) (string, string, error) {
+	s := strings.SplitN(raw, ":", 3)
+	if len(s) != 3 || s[0] != hostNetStackIDPrefix || s[1] == "" || s[2] == "" {
+		return "", "", fmt.Errorf("corrupt ID: %s", raw)
+	}
+	return s[1], s[2], nil
+}
+
+// netStackIDsFromResourceID passes a resource's ID through
+// splitHostNetStackID.
+func netStackIDsFromResourceID(d *schema.ResourceData) (string, string, error) {
+	return splitHostNetStackID(d.Id())
+}
@@ -76,3 +76,23 @@ func hostPortGroupFromName(client *govmomi.Client, ns *object.HostNetworkSystem,
 
 	return nil, fmt.Errorf("could not find port group %s", name)
 }
+
+// hostNetStackFromKey locates a TCP/IP stack instance on the supplied
+// HostNetworkSystem by key.
+func hostNetStackFromKey(client *govmomi.Client, ns *object.HostNetworkSystem, key string) (*types.HostNetStackInstance, error) {
+	var mns mo.HostNetworkSystem
+	pc := client.PropertyCollector()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := pc.RetrieveOne(ctx, ns.Reference(), []string{"networkInfo.netStackInstance"}, &mns); err != nil {
+		return nil, fmt.Errorf("error fetching host network properties: %s", err)
+	}
+
+	for _, nsi := range mns.NetworkInfo.NetStackInstance {
+		if nsi.Key == key {
+			return &nsi, nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not find TCP/IP stack instance %s", key)
+}
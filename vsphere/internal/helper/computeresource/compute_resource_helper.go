@@ -180,6 +180,19 @@ func OSFamily(client *govmomi.Client, ref types.ManagedObjectReference, guest st
 	return b.OSFamily(ctx, guest, hardwareVersion)
 }
 
+// MaxHardwareVersion uses the compute resource's environment browser to get
+// the latest virtual machine hardware version supported by the compute
+// resource.
+func MaxHardwareVersion(client *govmomi.Client, ref types.ManagedObjectReference) (int, error) {
+	b, err := EnvironmentBrowserFromReference(client, ref)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	return b.MaxHardwareVersion(ctx)
+}
+
 // EnvironmentBrowserFromReference loads an environment browser for the
 // specific compute resource reference. The reference can be either a
 // standalone host or cluster.
@@ -154,6 +154,72 @@ func ItemFromID(c *rest.Client, id string) (*library.Item, error) {
 	return item, nil
 }
 
+// ResolveItem accepts a Content Library name and a Content Library item name
+// and returns the matching item. If any allowedTypes are supplied, the item
+// type must match one of them.
+func ResolveItem(c *rest.Client, libraryName, itemName string, allowedTypes ...string) (*library.Item, error) {
+	log.Printf("[DEBUG] contentlibrary.ResolveItem: Resolving library item %s in library %s", itemName, libraryName)
+	if libraryName == "" {
+		return nil, fmt.Errorf("content library name must not be empty")
+	}
+	if itemName == "" {
+		return nil, fmt.Errorf("content library item name must not be empty")
+	}
+	lib, err := FromName(c, libraryName)
+	if err != nil {
+		return nil, err
+	}
+	item, err := ItemFromName(c, lib, itemName)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateItemType(item, allowedTypes...); err != nil {
+		return nil, err
+	}
+	log.Printf("[DEBUG] contentlibrary.ResolveItem: Library item %s resolved to %s (%s)", itemName, item.ID, item.Type)
+	return item, nil
+}
+
+// ResolveItemByLibraryID accepts a Content Library ID and a Content Library
+// item name and returns the matching item. If any allowedTypes are supplied,
+// the item type must match one of them.
+func ResolveItemByLibraryID(c *rest.Client, libraryID, itemName string, allowedTypes ...string) (*library.Item, error) {
+	log.Printf("[DEBUG] contentlibrary.ResolveItemByLibraryID: Resolving library item %s in library %s", itemName, libraryID)
+	if libraryID == "" {
+		return nil, fmt.Errorf("content library ID must not be empty")
+	}
+	if itemName == "" {
+		return nil, fmt.Errorf("content library item name must not be empty")
+	}
+	lib, err := FromID(c, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	item, err := ItemFromName(c, lib, itemName)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateItemType(item, allowedTypes...); err != nil {
+		return nil, err
+	}
+	log.Printf("[DEBUG] contentlibrary.ResolveItemByLibraryID: Library item %s resolved to %s (%s)", itemName, item.ID, item.Type)
+	return item, nil
+}
+
+// ValidateItemType checks that the type of a Content Library item is one of
+// allowedTypes. Any type is accepted if allowedTypes is empty.
+func ValidateItemType(item *library.Item, allowedTypes ...string) error {
+	if len(allowedTypes) == 0 {
+		return nil
+	}
+	for _, t := range allowedTypes {
+		if item.Type == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("content library item %s is of type %q, expected one of: %s", item.Name, item.Type, strings.Join(allowedTypes, ", "))
+}
+
 // IsContentLibraryItem accepts an ID and determines if that ID is associated with an item in a Content Library.
 func IsContentLibraryItem(c *rest.Client, id string) bool {
 	log.Printf("[DEBUG] contentlibrary.IsContentLibrary: Checking if %s is a content library source", id)
@@ -0,0 +1,198 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package contentlibrary
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/rest"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func TestResolveItem(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		if err := c.Login(ctx, simulator.DefaultLogin); err != nil {
+			t.Fatal(err)
+		}
+
+		ds, err := find.NewFinder(vc).DefaultDatastore(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clm := library.NewManager(c)
+		libID, err := clm.CreateLibrary(ctx, library.Library{
+			Name: "lib1",
+			Type: "LOCAL",
+			Storage: []library.StorageBacking{
+				{
+					DatastoreID: ds.Reference().Value,
+					Type:        "DATASTORE",
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		itemID, err := clm.CreateLibraryItem(ctx, library.Item{
+			Name:      "item1",
+			Type:      library.ItemTypeOVF,
+			LibraryID: libID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cases := []struct {
+			name         string
+			libraryName  string
+			itemName     string
+			allowedTypes []string
+			success      bool
+		}{
+			{
+				name:        "resolves",
+				libraryName: "lib1",
+				itemName:    "item1",
+				success:     true,
+			},
+			{
+				name:         "resolves with allowed type",
+				libraryName:  "lib1",
+				itemName:     "item1",
+				allowedTypes: []string{library.ItemTypeOVF},
+				success:      true,
+			},
+			{
+				name:         "disallowed type",
+				libraryName:  "lib1",
+				itemName:     "item1",
+				allowedTypes: []string{library.ItemTypeISO},
+				success:      false,
+			},
+			{
+				name:        "missing item",
+				libraryName: "lib1",
+				itemName:    "item2",
+				success:     false,
+			},
+			{
+				name:        "missing library",
+				libraryName: "lib2",
+				itemName:    "item1",
+				success:     false,
+			},
+			{
+				name:        "empty item name",
+				libraryName: "lib1",
+				success:     false,
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				item, err := ResolveItem(c, tc.libraryName, tc.itemName, tc.allowedTypes...)
+				if tc.success != (err == nil) {
+					t.Fatalf("expected success to be %t, got error: %v", tc.success, err)
+				}
+				if tc.success && (item.ID != itemID || item.Type != library.ItemTypeOVF) {
+					t.Fatalf("expected item %s of type %s, got %s of type %s", itemID, library.ItemTypeOVF, item.ID, item.Type)
+				}
+			})
+		}
+	})
+}
+
+func TestResolveItemByLibraryID(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		if err := c.Login(ctx, simulator.DefaultLogin); err != nil {
+			t.Fatal(err)
+		}
+
+		ds, err := find.NewFinder(vc).DefaultDatastore(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clm := library.NewManager(c)
+		libID, err := clm.CreateLibrary(ctx, library.Library{
+			Name: "lib1",
+			Type: "LOCAL",
+			Storage: []library.StorageBacking{
+				{
+					DatastoreID: ds.Reference().Value,
+					Type:        "DATASTORE",
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		itemID, err := clm.CreateLibraryItem(ctx, library.Item{
+			Name:      "item1",
+			Type:      library.ItemTypeOVF,
+			LibraryID: libID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cases := []struct {
+			name         string
+			libraryID    string
+			itemName     string
+			allowedTypes []string
+			success      bool
+		}{
+			{
+				name:      "resolves",
+				libraryID: libID,
+				itemName:  "item1",
+				success:   true,
+			},
+			{
+				name:         "disallowed type",
+				libraryID:    libID,
+				itemName:     "item1",
+				allowedTypes: []string{library.ItemTypeVMTX},
+				success:      false,
+			},
+			{
+				name:      "missing item",
+				libraryID: libID,
+				itemName:  "item2",
+				success:   false,
+			},
+			{
+				name:      "missing library",
+				libraryID: "00000000-0000-0000-0000-000000000000",
+				itemName:  "item1",
+				success:   false,
+			},
+			{
+				name:     "empty library ID",
+				itemName: "item1",
+				success:  false,
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				item, err := ResolveItemByLibraryID(c, tc.libraryID, tc.itemName, tc.allowedTypes...)
+				if tc.success != (err == nil) {
+					t.Fatalf("expected success to be %t, got error: %v", tc.success, err)
+				}
+				if tc.success && item.ID != itemID {
+					t.Fatalf("expected item %s, got %s", itemID, item.ID)
+				}
+			})
+		}
+	})
+}
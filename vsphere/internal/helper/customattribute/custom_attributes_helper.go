@@ -36,11 +36,11 @@ const ConfigKey = "custom_attributes"
 // for each resource that needs it.
 //
 // The key should be set to the ConfigKey constant and should be a
-// map of custom attribute ids to values.
+// map of custom attribute ids or names to values.
 func ConfigSchema() *schema.Schema {
 	return &schema.Schema{
 		Type:        schema.TypeMap,
-		Description: "A list of custom attributes to set on this resource.",
+		Description: "A map of custom attribute IDs or names to values to set on this resource.",
 		Optional:    true,
 		Elem:        &schema.Schema{Type: schema.TypeString},
 	}
@@ -60,20 +60,41 @@ func IsSupported(client *govmomi.Client) bool {
 // ReadFromResource reads the custom attributes from an object and saves the
 // data into the supplied ResourceData.
 //
+// Attributes are keyed by their ID, unless they are keyed by name in the
+// current state and the name is found in the available fields of the object.
+//
 // TODO: Add error handling and reporting to this method.
 func ReadFromResource(entity *mo.ManagedEntity, d *schema.ResourceData) {
+	current, _ := d.Get(ConfigKey).(map[string]interface{})
 	customAttrs := make(map[string]interface{})
 	if len(entity.CustomValue) > 0 {
 		for _, fv := range entity.CustomValue {
 			value := fv.(*types.CustomFieldStringValue).Value
 			if value != "" {
-				customAttrs[fmt.Sprint(fv.GetCustomFieldValue().Key)] = value
+				key := fv.GetCustomFieldValue().Key
+				customAttrs[attributeStateKey(entity.AvailableField, current, key)] = value
 			}
 		}
 	}
 	_ = d.Set(ConfigKey, customAttrs)
 }
 
+// attributeStateKey returns the key in the custom_attributes map for the
+// attribute with the supplied ID. This is the name of the attribute if the
+// current state uses it, and the ID otherwise.
+func attributeStateKey(fields []types.CustomFieldDef, current map[string]interface{}, key int32) string {
+	for _, f := range fields {
+		if f.Key != key {
+			continue
+		}
+		if _, ok := current[f.Name]; ok {
+			return f.Name
+		}
+		break
+	}
+	return fmt.Sprint(key)
+}
+
 type DiffProcessor struct {
 	// The field manager
 	fm *object.CustomFieldsManager
@@ -85,15 +106,28 @@ type DiffProcessor struct {
 	newAttributes map[string]interface{}
 }
 
+// fieldKey returns the ID of the custom attribute with the supplied ID or
+// name.
+func (p *DiffProcessor) fieldKey(k string) (int32, error) {
+	if key, err := strconv.ParseInt(k, 10, 32); err == nil {
+		return int32(key), nil
+	}
+	return p.fm.FindKey(context.TODO(), k)
+}
+
 func (p *DiffProcessor) clearRemovedAttributes(subject object.Reference) error {
 	for k := range p.oldAttributes {
 		_, ok := p.newAttributes[k]
 		if !ok {
-			key, err := strconv.ParseInt(k, 10, 32)
+			key, err := p.fieldKey(k)
+			if errors.Is(err, object.ErrKeyNameNotFound) {
+				// The attribute no longer exists, so there is no value to clear.
+				continue
+			}
 			if err != nil {
 				return err
 			}
-			err = p.fm.Set(context.TODO(), subject.Reference(), int32(key), "")
+			err = p.fm.Set(context.TODO(), subject.Reference(), key, "")
 			if err != nil {
 				return err
 			}
@@ -104,11 +138,11 @@ func (p *DiffProcessor) clearRemovedAttributes(subject object.Reference) error {
 
 func (p *DiffProcessor) setNewAttributes(subject object.Reference) error {
 	for k, v := range p.newAttributes {
-		key, err := strconv.ParseInt(k, 10, 32)
+		key, err := p.fieldKey(k)
 		if err != nil {
-			return err
+			return fmt.Errorf("could not find custom attribute %q: %s", k, err)
 		}
-		err = p.fm.Set(context.TODO(), subject.Reference(), int32(key), v.(string))
+		err = p.fm.Set(context.TODO(), subject.Reference(), key, v.(string))
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,107 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package customattribute
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestDiffProcessorByName(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		fm, err := object.GetCustomFieldsManager(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		def, err := fm.Add(ctx, "owner", "VirtualMachine", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm := simulator.Map(ctx).Any("VirtualMachine").Reference()
+
+		values := func() map[int32]string {
+			var props mo.ManagedEntity
+			if err := object.NewCommon(c, vm).Properties(ctx, vm, []string{"customValue"}, &props); err != nil {
+				t.Fatal(err)
+			}
+			m := make(map[int32]string)
+			for _, v := range props.CustomValue {
+				m[v.GetCustomFieldValue().Key] = v.(*types.CustomFieldStringValue).Value
+			}
+			return m
+		}
+
+		p := &DiffProcessor{
+			fm:            fm,
+			oldAttributes: map[string]interface{}{},
+			newAttributes: map[string]interface{}{"owner": "team-a"},
+		}
+		if err := p.ProcessDiff(vm); err != nil {
+			t.Fatal(err)
+		}
+		if actual := values()[def.Key]; actual != "team-a" {
+			t.Fatalf("expected attribute owner to be %q, got %q", "team-a", actual)
+		}
+
+		p = &DiffProcessor{
+			fm:            fm,
+			oldAttributes: map[string]interface{}{"owner": "team-a", "deleted": "value"},
+			newAttributes: map[string]interface{}{},
+		}
+		if err := p.ProcessDiff(vm); err != nil {
+			t.Fatal(err)
+		}
+		if actual := values()[def.Key]; actual != "" {
+			t.Fatalf("expected attribute owner to be cleared, got %q", actual)
+		}
+
+		p = &DiffProcessor{
+			fm:            fm,
+			oldAttributes: map[string]interface{}{},
+			newAttributes: map[string]interface{}{"missing": "value"},
+		}
+		if err := p.ProcessDiff(vm); err == nil {
+			t.Fatal("expected error setting unknown attribute, got none")
+		}
+	})
+}
+
+func TestReadFromResource(t *testing.T) {
+	entity := &mo.ManagedEntity{
+		ExtensibleManagedObject: mo.ExtensibleManagedObject{
+			AvailableField: []types.CustomFieldDef{
+				{Key: 101, Name: "owner"},
+				{Key: 102, Name: "cost-center"},
+			},
+		},
+		CustomValue: []types.BaseCustomFieldValue{
+			&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 101}, Value: "team-a"},
+			&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 102}, Value: "1234"},
+			&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 103}, Value: ""},
+		},
+	}
+	d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{ConfigKey: ConfigSchema()}, map[string]interface{}{
+		ConfigKey: map[string]interface{}{"owner": "team-b"},
+	})
+	ReadFromResource(entity, d)
+
+	expected := map[string]interface{}{"owner": "team-a", "102": "1234"}
+	actual := d.Get(ConfigKey).(map[string]interface{})
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for k, v := range expected {
+		if actual[k] != v {
+			t.Fatalf("expected %v, got %v", expected, actual)
+		}
+	}
+}
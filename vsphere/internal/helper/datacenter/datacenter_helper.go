@@ -6,30 +6,86 @@ package datacenter
 
 import (
 	"context"
+	"log"
 
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/folder"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/provider"
 )
 
+// FromID locates a Datacenter by its managed object reference ID.
+func FromID(client *govmomi.Client, id string) (*object.Datacenter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	return FromIDContext(ctx, client, id)
+}
+
+// FromIDContext locates a Datacenter by its managed object reference ID. The
+// lookup is bound by the deadline of ctx.
+func FromIDContext(ctx context.Context, client *govmomi.Client, id string) (*object.Datacenter, error) {
+	log.Printf("[DEBUG] Locating datacenter with ID %q", id)
+	finder := find.NewFinder(client.Client, false)
+
+	ref := types.ManagedObjectReference{
+		Type:  "Datacenter",
+		Value: id,
+	}
+
+	r, err := finder.ObjectReference(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	dc := r.(*object.Datacenter)
+	log.Printf("[DEBUG] Datacenter with ID %q found (%s)", dc.Reference().Value, dc.InventoryPath)
+	return dc, nil
+}
+
 // FromPath returns a Datacenter via its supplied path.
 func FromPath(client *govmomi.Client, path string) (*object.Datacenter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	return FromPathContext(ctx, client, path)
+}
+
+// FromPathContext returns a Datacenter via its supplied path. The lookup is
+// bound by the deadline of ctx.
+func FromPathContext(ctx context.Context, client *govmomi.Client, path string) (*object.Datacenter, error) {
 	finder := find.NewFinder(client.Client, false)
+	return finder.Datacenter(ctx, path)
+}
 
+// List returns all datacenters in the root folder of vCenter.
+func List(client *govmomi.Client) ([]*object.Datacenter, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
 	defer cancel()
-	return finder.Datacenter(ctx, path)
+	return ListContext(ctx, client)
+}
+
+// ListContext returns all datacenters in the root folder of vCenter. The
+// lookup is bound by the deadline of ctx.
+func ListContext(ctx context.Context, client *govmomi.Client) ([]*object.Datacenter, error) {
+	finder := find.NewFinder(client.Client, false)
+	return finder.DatacenterList(ctx, "*")
 }
 
 // FromInventoryPath returns the Datacenter object which is part of a given InventoryPath
 func FromInventoryPath(client *govmomi.Client, inventoryPath string) (*object.Datacenter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	return FromInventoryPathContext(ctx, client, inventoryPath)
+}
+
+// FromInventoryPathContext returns the Datacenter object which is part of a
+// given InventoryPath. The lookup is bound by the deadline of ctx.
+func FromInventoryPathContext(ctx context.Context, client *govmomi.Client, inventoryPath string) (*object.Datacenter, error) {
 	dcPath, err := folder.RootPathParticleDatastore.SplitDatacenter(inventoryPath)
 	if err != nil {
 		return nil, err
 	}
-	dc, err := FromPath(client, dcPath)
+	dc, err := FromPathContext(ctx, client, dcPath)
 	if err != nil {
 		return nil, err
 	}
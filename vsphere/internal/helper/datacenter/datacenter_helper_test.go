@@ -0,0 +1,85 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package datacenter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func TestFromID(t *testing.T) {
+	simulator.Test(func(_ context.Context, c *vim25.Client) {
+		client := &govmomi.Client{Client: c}
+
+		byPath, err := FromPath(client, "/DC0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		byID, err := FromID(client, byPath.Reference().Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if byPath.Reference() != byID.Reference() {
+			t.Fatalf("expected datacenter %s, got %s", byPath.Reference(), byID.Reference())
+		}
+		if byPath.InventoryPath != byID.InventoryPath {
+			t.Fatalf("expected inventory path %q, got %q", byPath.InventoryPath, byID.InventoryPath)
+		}
+
+		if _, err := FromID(client, "datacenter-missing"); err == nil {
+			t.Fatalf("expected error for missing datacenter")
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	model := simulator.VPX()
+	model.Datacenter = 2
+	err := model.Run(func(_ context.Context, c *vim25.Client) error {
+		client := &govmomi.Client{Client: c}
+
+		dcs, err := List(client)
+		if err != nil {
+			return err
+		}
+		expected := []string{"/DC0", "/DC1"}
+		if len(dcs) != len(expected) {
+			t.Fatalf("expected %d datacenters, got %d", len(expected), len(dcs))
+		}
+		for i, dc := range dcs {
+			if dc.InventoryPath != expected[i] {
+				t.Fatalf("expected datacenter %q, got %q", expected[i], dc.InventoryPath)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromPathContext(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		client := &govmomi.Client{Client: c}
+
+		dc, err := FromPathContext(ctx, client, "/DC0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dc.InventoryPath != "/DC0" {
+			t.Fatalf("expected datacenter %q, got %q", "/DC0", dc.InventoryPath)
+		}
+
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := FromPathContext(cctx, client, "/DC0"); err == nil {
+			t.Fatalf("expected error for a canceled context")
+		}
+	})
+}
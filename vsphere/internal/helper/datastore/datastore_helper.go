@@ -9,10 +9,12 @@ import (
 	"fmt"
 	"log"
 	"path"
+	"strings"
 
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/folder"
@@ -56,6 +58,49 @@ func FromPath(client *govmomi.Client, name string, dc *object.Datacenter) (*obje
 	return finder.Datastore(ctx, name)
 }
 
+// FromName locates a datastore by its name. The search is limited to the
+// datacenter if one is supplied, and includes datastores in folders and in
+// datastore clusters (storage pods). An error is returned if no datastore,
+// or more than one datastore, has the name.
+func FromName(client *govmomi.Client, name string, dc *object.Datacenter) (*object.Datastore, error) {
+	log.Printf("[DEBUG] Locating datastore with name %q", name)
+	root := client.ServiceContent.RootFolder
+	if dc != nil {
+		root = dc.Reference()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	m := view.NewManager(client.Client)
+	v, err := m.CreateContainerView(ctx, root, []string{"Datastore"}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		dctx, dcancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+		defer dcancel()
+		_ = v.Destroy(dctx)
+	}()
+
+	var dss []mo.Datastore
+	if err := v.Retrieve(ctx, []string{"Datastore"}, []string{"name"}, &dss); err != nil {
+		return nil, fmt.Errorf("error listing datastores: %s", err)
+	}
+	var ids []string
+	for _, ds := range dss {
+		if ds.Name == name {
+			ids = append(ids, ds.Reference().Value)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return nil, fmt.Errorf("datastore %q not found", name)
+	case 1:
+		return FromID(client, ids[0])
+	}
+	return nil, fmt.Errorf("datastore name %q is ambiguous, it matches datastores %s; specify a datacenter or the inventory path of the datastore", name, strings.Join(ids, ", "))
+}
+
 func List(client *govmomi.Client) ([]*object.Datastore, error) {
 	return getDatastores(client, "/*")
 }
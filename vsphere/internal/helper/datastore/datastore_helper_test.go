@@ -0,0 +1,69 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package datastore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestFromName(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		client := &govmomi.Client{Client: c}
+		finder := find.NewFinder(c)
+		dc, err := finder.Datacenter(ctx, "DC0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := FromPath(client, "LocalDS_0", dc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ds, err := FromName(client, "LocalDS_0", dc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ds.Reference() != expected.Reference() {
+			t.Fatalf("expected datastore %s, got %s", expected.Reference(), ds.Reference())
+		}
+
+		// Datastores in a datastore cluster are found by their name.
+		folders, err := dc.Folders(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pod, err := folders.DatastoreFolder.CreateStoragePod(ctx, "pod0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		task, err := pod.MoveInto(ctx, []types.ManagedObjectReference{expected.Reference()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := task.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		ds, err = FromName(client, "LocalDS_0", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ds.Reference() != expected.Reference() {
+			t.Fatalf("expected datastore %s, got %s", expected.Reference(), ds.Reference())
+		}
+
+		_, err = FromName(client, "missing", dc)
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+}
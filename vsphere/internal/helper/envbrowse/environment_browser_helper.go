@@ -152,3 +152,30 @@ func (b *EnvironmentBrowser) QueryConfigOptionDescriptor(ctx context.Context) ([
 	}
 	return res.Returnval, nil
 }
+
+// MaxHardwareVersion returns the latest virtual machine hardware version that
+// new virtual machines can be created with on the environment that this
+// browser targets.
+func (b *EnvironmentBrowser) MaxHardwareVersion(ctx context.Context) (int, error) {
+	descriptors, err := b.QueryConfigOptionDescriptor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return maxCreateSupportedHardwareVersion(descriptors), nil
+}
+
+// maxCreateSupportedHardwareVersion returns the latest hardware version in
+// descriptors that virtual machines can be created with, or 0 if there is
+// none.
+func maxCreateSupportedHardwareVersion(descriptors []types.VirtualMachineConfigOptionDescriptor) int {
+	var maxVersion int
+	for _, desc := range descriptors {
+		if desc.CreateSupported == nil || !*desc.CreateSupported {
+			continue
+		}
+		if v := virtualmachine.GetHardwareVersionNumber(desc.Key); v > maxVersion {
+			maxVersion = v
+		}
+	}
+	return maxVersion
+}
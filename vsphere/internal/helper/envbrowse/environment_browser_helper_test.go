@@ -0,0 +1,50 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package envbrowse
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestMaxCreateSupportedHardwareVersion(t *testing.T) {
+	cases := []struct {
+		name        string
+		descriptors []types.VirtualMachineConfigOptionDescriptor
+		expected    int
+	}{
+		{
+			name:     "no descriptors",
+			expected: 0,
+		},
+		{
+			name: "latest version supported",
+			descriptors: []types.VirtualMachineConfigOptionDescriptor{
+				{Key: "vmx-13", CreateSupported: types.NewBool(true)},
+				{Key: "vmx-19", CreateSupported: types.NewBool(true)},
+				{Key: "vmx-17", CreateSupported: types.NewBool(true)},
+			},
+			expected: 19,
+		},
+		{
+			name: "latest version not supported for create",
+			descriptors: []types.VirtualMachineConfigOptionDescriptor{
+				{Key: "vmx-19", CreateSupported: types.NewBool(true)},
+				{Key: "vmx-20", CreateSupported: types.NewBool(false)},
+				{Key: "vmx-21"},
+			},
+			expected: 19,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := maxCreateSupportedHardwareVersion(tc.descriptors); tc.expected != actual {
+				t.Fatalf("expected max hardware version %d, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -113,6 +113,20 @@ func HostInMaintenance(host *object.HostSystem) (bool, error) {
 	return hostObject.Runtime.InMaintenanceMode, nil
 }
 
+// SystemClockProtocol returns the time synchronization protocol that
+// disciplines the system clock of a host, such as ptp or ntp. An empty string
+// is returned if the host does not report it.
+func SystemClockProtocol(host *object.HostSystem) (string, error) {
+	hostObject, err := Properties(host)
+	if err != nil {
+		return "", err
+	}
+	if hostObject.Config == nil || hostObject.Config.DateTimeInfo == nil {
+		return "", nil
+	}
+	return hostObject.Config.DateTimeInfo.SystemClockProtocol, nil
+}
+
 // EnterMaintenanceMode puts a host into maintenance mode. If evacuate is set
 // to true, all powered off VMs will be removed from the host, or the task will
 // block until this is the case, depending on whether or not DRS is on or off
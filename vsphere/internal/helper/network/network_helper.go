@@ -15,10 +15,10 @@ import (
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
-	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/provider"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/utils"
 )
 
 var Type = []string{
@@ -60,6 +60,8 @@ func FromPath(client *govmomi.Client, name string, dc *object.Datacenter) (objec
 	return finder.Network(ctx, name)
 }
 
+// FromNameAndDVSUuid fetches a network by name. If dvsUUID is set, only a
+// distributed portgroup of the distributed switch with that UUID is returned.
 func FromNameAndDVSUuid(client *govmomi.Client, name string, dc *object.Datacenter, dvsUUID string) (object.NetworkReference, error) {
 	finder := find.NewFinder(client.Client, false)
 	if dc != nil {
@@ -82,11 +84,10 @@ func FromNameAndDVSUuid(client *govmomi.Client, name string, dc *object.Datacent
 	case len(networks) > 1 && dvsUUID == "":
 		return nil, fmt.Errorf("path '%s' resolves to multiple %ss, Please specify", name, "network")
 	case dvsUUID != "":
-		dvsObj, err := dvsFromUUID(client, dvsUUID)
+		dvsMoid, err := utils.GetMoidStrict(client, utils.DISTRIBUTEDVIRTUALSWITCH, dvsUUID)
 		if err != nil {
 			return nil, err
 		}
-		dvsMoid := dvsObj.Reference().Value
 		for _, network := range networks {
 			if network.Reference().Type == "DistributedVirtualPortgroup" {
 				dvPortGroup := object.NewDistributedVirtualPortgroup(client.Client, network.Reference())
@@ -251,19 +252,6 @@ func dvsFromMOID(client *govmomi.Client, id string) (*object.VmwareDistributedVi
 	// honest we should be panicking anyway.
 	return ds.(*object.VmwareDistributedVirtualSwitch), nil
 }
-func dvsFromUUID(client *govmomi.Client, uuid string) (*object.VmwareDistributedVirtualSwitch, error) {
-	dvsm := types.ManagedObjectReference{Type: "DistributedVirtualSwitchManager", Value: "DVSManager"}
-	req := &types.QueryDvsByUuid{
-		This: dvsm,
-		Uuid: uuid,
-	}
-	resp, err := methods.QueryDvsByUuid(context.TODO(), client, req)
-	if err != nil {
-		return nil, err
-	}
-
-	return dvsFromMOID(client, resp.Returnval.Reference().Value)
-}
 
 // FromName fetches a network by name and applies additional filters.
 func FromName(client *vim25.Client, name string, dc *object.Datacenter, filters map[string]string) (object.NetworkReference, error) {
@@ -6,8 +6,10 @@ package spbm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/pbm"
@@ -81,6 +83,67 @@ func PolicySpecByID(id string) []types.BaseVirtualMachineProfileSpec {
 	}
 }
 
+// ParseReplicationGroupID parses a replication group ID of the form
+// <fault-domain-id>:<device-group-id>.
+func ParseReplicationGroupID(id string) (*types.ReplicationGroupId, error) {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid replication group ID %q: must be of the form <fault-domain-id>:<device-group-id>", id)
+	}
+	return &types.ReplicationGroupId{
+		FaultDomainId: types.FaultDomainId{Id: parts[0]},
+		DeviceGroupId: types.DeviceGroupId{Id: parts[1]},
+	}, nil
+}
+
+// FormatReplicationGroupID returns the string form of a replication group ID,
+// as parsed by ParseReplicationGroupID. An empty string is returned for nil.
+func FormatReplicationGroupID(id *types.ReplicationGroupId) string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s", id.FaultDomainId.Id, id.DeviceGroupId.Id)
+}
+
+// ReplicationGroupIDByVirtualMachine fetches the replication group associated
+// with the configuration of a virtual machine. An empty string is returned if
+// the virtual machine is not in a replication group.
+func ReplicationGroupIDByVirtualMachine(client *govmomi.Client, vmMOID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	pc, err := pbmClientFromGovmomiClient(ctx, client)
+	if err != nil {
+		return "", provider.Error(vmMOID, "ReplicationGroupIDByVirtualMachine", err)
+	}
+	if pc.ServiceContent.ReplicationManager == nil {
+		log.Printf("[DEBUG] ReplicationGroupIDByVirtualMachine: Replication manager not available, skipping.")
+		return "", nil
+	}
+
+	req := pbmtypes.PbmQueryReplicationGroups{
+		This: *pc.ServiceContent.ReplicationManager,
+		Entities: []pbmtypes.PbmServerObjectRef{
+			{
+				ObjectType: string(pbmtypes.PbmObjectTypeVirtualMachine),
+				Key:        vmMOID,
+			},
+		},
+	}
+	res, err := methods.PbmQueryReplicationGroups(ctx, pc, &req)
+	if err != nil {
+		return "", provider.Error(vmMOID, "ReplicationGroupIDByVirtualMachine", err)
+	}
+	for _, result := range res.Returnval {
+		if result.Fault != nil {
+			return "", provider.Error(vmMOID, "ReplicationGroupIDByVirtualMachine", errors.New(result.Fault.LocalizedMessage))
+		}
+		if result.ReplicationGroupId != nil {
+			return FormatReplicationGroupID(result.ReplicationGroupId), nil
+		}
+	}
+	return "", nil
+}
+
 // PolicyIDByVirtualDisk fetches the storage policy associated with a virtual disk.
 func PolicyIDByVirtualDisk(client *govmomi.Client, vmMOID string, diskKey int) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
@@ -0,0 +1,60 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package spbm
+
+import (
+	"testing"
+)
+
+func TestReplicationGroupIDRoundTrip(t *testing.T) {
+	cases := []struct {
+		name      string
+		id        string
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			id:   "fd-1:dg-1",
+		},
+		{
+			name: "device group containing separator",
+			id:   "fd-1:dg:1",
+		},
+		{
+			name:      "missing separator",
+			id:        "fd-1",
+			expectErr: true,
+		},
+		{
+			name:      "empty fault domain",
+			id:        ":dg-1",
+			expectErr: true,
+		},
+		{
+			name:      "empty device group",
+			id:        "fd-1:",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rgID, err := ParseReplicationGroupID(tc.id)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if actual := FormatReplicationGroupID(rgID); actual != tc.id {
+				t.Fatalf("expected %q, got %q", tc.id, actual)
+			}
+		})
+	}
+
+	if actual := FormatReplicationGroupID(nil); actual != "" {
+		t.Fatalf("expected empty ID, got %q", actual)
+	}
+}
@@ -6,39 +6,183 @@ package utils
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"regexp"
 
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/provider"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/viapi"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
 )
 
 const VM = "VirtualMachine"
 const DISTRIBUTEDVIRTUALSWITCH = "VmwareDistributedVirtualSwitch"
+const DATASTORE = "Datastore"
+const RESOURCEPOOL = "ResourcePool"
+const HOSTSYSTEM = "HostSystem"
+const NETWORK = "Network"
+const DISTRIBUTEDVIRTUALPORTGROUP = "DistributedVirtualPortgroup"
+const FOLDER = "Folder"
 
+// entityTypeAliases lists the managed object types that can be returned for an
+// entity type, in addition to the type itself.
+var entityTypeAliases = map[string][]string{
+	NETWORK:                  {DISTRIBUTEDVIRTUALPORTGROUP, "OpaqueNetwork"},
+	DISTRIBUTEDVIRTUALSWITCH: {"DistributedVirtualSwitch"},
+}
+
+// inventoryPathEntityTypes lists the entity types that can be resolved from an
+// inventory path.
+var inventoryPathEntityTypes = map[string]bool{
+	DATASTORE:                   true,
+	RESOURCEPOOL:                true,
+	HOSTSYSTEM:                  true,
+	NETWORK:                     true,
+	DISTRIBUTEDVIRTUALPORTGROUP: true,
+	FOLDER:                      true,
+}
+
+// GetMoid returns the managed object ID of the entity of the supplied type
+// that is identified by id.
+//
+// The id can be the managed object ID of the entity for all types. Virtual
+// machines, distributed switches, and hosts can also be identified by UUID,
+// and datastores, resource pools, hosts, networks, distributed portgroups, and
+// folders by inventory path. An error is returned if no entity of the type
+// matches id.
 func GetMoid(client *govmomi.Client, entityType string, id string) (string, error) {
+	return getMoid(client, entityType, id, false)
+}
+
+// GetMoidStrict works like GetMoid, but virtual machines and distributed
+// switches must be identified by UUID. The error of the UUID lookup is
+// returned if it fails, instead of treating id as a managed object ID.
+func GetMoidStrict(client *govmomi.Client, entityType string, id string) (string, error) {
+	return getMoid(client, entityType, id, true)
+}
+
+// IsEntityUUID returns true if id is in the UUID form of entities of the
+// supplied type, such as 42a1b2c3-d4e5-f6a7-b8c9-d0e1f2a3b4c5 for a virtual
+// machine or 50 3c 2f 7b 7c 05 59 0d-ec 1a 5e 68 cc 75 39 18 for a
+// distributed switch. Managed object IDs are never in this form, so such an id
+// can be resolved with GetMoidStrict.
+func IsEntityUUID(entityType string, id string) bool {
+	switch entityType {
+	case VM:
+		return vmUUIDPattern.MatchString(id)
+	case DISTRIBUTEDVIRTUALSWITCH:
+		return dvsUUIDPattern.MatchString(id)
+	}
+	return false
+}
+
+var (
+	vmUUIDPattern  = regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	dvsUUIDPattern = regexp.MustCompile(`^(?i)([0-9a-f]{2} ){7}[0-9a-f]{2}-([0-9a-f]{2} ){7}[0-9a-f]{2}$`)
+)
+
+// dvsMoid returns the managed object ID of the distributed switch with the
+// supplied UUID.
+func dvsMoid(ctx context.Context, client *govmomi.Client, uuid string) (string, error) {
+	dvsm := types.ManagedObjectReference{Type: "DistributedVirtualSwitchManager", Value: "DVSManager"}
+	req := &types.QueryDvsByUuid{
+		This: dvsm,
+		Uuid: uuid,
+	}
+	resp, err := methods.QueryDvsByUuid(ctx, client, req)
+	if err != nil {
+		return "", err
+	}
+	if resp.Returnval == nil {
+		return "", fmt.Errorf("not found")
+	}
+	return resp.Returnval.Reference().Value, nil
+}
+
+func getMoid(client *govmomi.Client, entityType string, id string, strict bool) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+
 	switch entityType {
 	case VM:
 		vm, err := virtualmachine.FromUUID(client, id)
-		if err != nil {
-			log.Printf("unable to find VM object with uuid:%s, error %s,treating given id as managed object id", id, err)
-			return id, nil
+		if err == nil {
+			return vm.Reference().Value, nil
+		}
+		if strict {
+			return "", fmt.Errorf("error finding virtual machine with UUID %q: %s", id, err)
 		}
-		return vm.Reference().Value, nil
+		log.Printf("[DEBUG] Unable to find VM object with uuid:%s, error %s, treating given id as managed object id", id, err)
 	case DISTRIBUTEDVIRTUALSWITCH:
-		dvsm := types.ManagedObjectReference{Type: "DistributedVirtualSwitchManager", Value: "DVSManager"}
-		req := &types.QueryDvsByUuid{
-			This: dvsm,
-			Uuid: id,
+		moid, err := dvsMoid(ctx, client, id)
+		if err == nil {
+			return moid, nil
 		}
-		resp, err := methods.QueryDvsByUuid(context.TODO(), client, req)
+		if strict {
+			return "", fmt.Errorf("error finding distributed switch with UUID %q: %s", id, err)
+		}
+		log.Printf("[DEBUG] Unable to find DVS object with uuid:%s, error %v, treating given id as managed object id", id, err)
+	case HOSTSYSTEM:
+		ref, err := object.NewSearchIndex(client.Client).FindByUuid(ctx, nil, id, false, nil)
+		if err == nil && ref != nil {
+			return ref.Reference().Value, nil
+		}
+		log.Printf("[DEBUG] Unable to find host object with uuid:%s, error %v, treating given id as managed object id", id, err)
+	}
+
+	for _, t := range append([]string{entityType}, entityTypeAliases[entityType]...) {
+		exists, err := managedObjectExists(ctx, client, types.ManagedObjectReference{Type: t, Value: id})
 		if err != nil {
-			log.Printf("unable to find DVS object with uuid:%s, error %s, treating given id as managed object id", id, err)
+			return "", err
+		}
+		if exists {
 			return id, nil
 		}
-		return resp.Returnval.Reference().Value, nil
-	default:
-		return id, nil
 	}
+
+	if inventoryPathEntityTypes[entityType] {
+		ref, err := object.NewSearchIndex(client.Client).FindByInventoryPath(ctx, id)
+		if err != nil {
+			return "", fmt.Errorf("error finding %s at path %q: %s", entityType, id, err)
+		}
+		if ref != nil && isEntityType(entityType, ref.Reference().Type) {
+			return ref.Reference().Value, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find %s with ID %q", entityType, id)
+}
+
+// managedObjectExists returns true if the managed object referenced by ref
+// exists.
+func managedObjectExists(ctx context.Context, client *govmomi.Client, ref types.ManagedObjectReference) (bool, error) {
+	var entity mo.ManagedEntity
+	err := property.DefaultCollector(client.Client).RetrieveOne(ctx, ref, []string{"name"}, &entity)
+	switch {
+	case err == nil:
+		return true, nil
+	case viapi.IsManagedObjectNotFoundError(err):
+		return false, nil
+	}
+	return false, fmt.Errorf("error looking up %s %q: %s", ref.Type, ref.Value, err)
+}
+
+// isEntityType returns true if a managed object of type actual can be used as
+// an entity of type expected.
+func isEntityType(expected, actual string) bool {
+	if expected == actual {
+		return true
+	}
+	for _, t := range entityTypeAliases[expected] {
+		if t == actual {
+			return true
+		}
+	}
+	return false
 }
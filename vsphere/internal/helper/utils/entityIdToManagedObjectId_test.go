@@ -0,0 +1,271 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestGetMoid(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		client := &govmomi.Client{Client: c}
+		m := simulator.Map(ctx)
+		ds := m.Any("Datastore").(*simulator.Datastore)
+		host := m.Any("HostSystem").(*simulator.HostSystem)
+		vm := m.Any("VirtualMachine").(*simulator.VirtualMachine)
+		pg := m.Any("DistributedVirtualPortgroup").(*simulator.DistributedVirtualPortgroup)
+
+		cases := []struct {
+			name        string
+			entityType  string
+			id          string
+			expected    string
+			expectError bool
+		}{
+			{
+				name:       "virtual machine by UUID",
+				entityType: VM,
+				id:         vm.Config.Uuid,
+				expected:   vm.Self.Value,
+			},
+			{
+				name:       "virtual machine by managed object ID",
+				entityType: VM,
+				id:         vm.Self.Value,
+				expected:   vm.Self.Value,
+			},
+			{
+				name:       "datastore by managed object ID",
+				entityType: DATASTORE,
+				id:         ds.Self.Value,
+				expected:   ds.Self.Value,
+			},
+			{
+				name:       "datastore by path",
+				entityType: DATASTORE,
+				id:         "/DC0/datastore/" + ds.Name,
+				expected:   ds.Self.Value,
+			},
+			{
+				name:       "host by UUID",
+				entityType: HOSTSYSTEM,
+				id:         host.Summary.Hardware.Uuid,
+				expected:   host.Self.Value,
+			},
+			{
+				name:       "network by path to a distributed portgroup",
+				entityType: NETWORK,
+				id:         "/DC0/network/" + pg.Name,
+				expected:   pg.Self.Value,
+			},
+			{
+				name:       "folder by path",
+				entityType: FOLDER,
+				id:         "/DC0/vm",
+				expected:   m.Get(m.Any("Datacenter").(*simulator.Datacenter).VmFolder).(*simulator.Folder).Self.Value,
+			},
+			{
+				name:        "path to an entity of another type",
+				entityType:  DATASTORE,
+				id:          "/DC0/vm",
+				expectError: true,
+			},
+			{
+				name:        "missing datastore",
+				entityType:  DATASTORE,
+				id:          "datastore-missing",
+				expectError: true,
+			},
+			{
+				name:        "missing entity of a type without resolver",
+				entityType:  "Datacenter",
+				id:          "datacenter-missing",
+				expectError: true,
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				actual, err := GetMoid(client, tc.entityType, tc.id)
+				if tc.expectError {
+					if err == nil {
+						t.Fatalf("expected error, got %q", actual)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tc.expected != actual {
+					t.Fatalf("expected %q, got %q", tc.expected, actual)
+				}
+			})
+		}
+	})
+}
+
+func TestGetMoidStrict(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		client := &govmomi.Client{Client: c}
+		m := simulator.Map(ctx)
+		vm := m.Any("VirtualMachine").(*simulator.VirtualMachine)
+		dvs := m.Any("DistributedVirtualSwitch").(*simulator.DistributedVirtualSwitch)
+		dvsm := m.Get(*c.ServiceContent.DvSwitchManager).(*simulator.DistributedVirtualSwitchManager)
+		m.Put(&queryingDVSManager{DistributedVirtualSwitchManager: dvsm})
+
+		cases := []struct {
+			name        string
+			entityType  string
+			id          string
+			expected    string
+			expectError bool
+		}{
+			{
+				name:       "virtual machine by UUID",
+				entityType: VM,
+				id:         vm.Config.Uuid,
+				expected:   vm.Self.Value,
+			},
+			{
+				name:        "virtual machine by managed object ID",
+				entityType:  VM,
+				id:          vm.Self.Value,
+				expectError: true,
+			},
+			{
+				name:        "missing virtual machine",
+				entityType:  VM,
+				id:          "00000000-0000-0000-0000-000000000000",
+				expectError: true,
+			},
+			{
+				name:       "distributed switch by UUID",
+				entityType: DISTRIBUTEDVIRTUALSWITCH,
+				id:         dvs.Uuid,
+				expected:   dvs.Self.Value,
+			},
+			{
+				name:        "missing distributed switch",
+				entityType:  DISTRIBUTEDVIRTUALSWITCH,
+				id:          "50 00 00 00 00 00 00 00-00 00 00 00 00 00 00 00",
+				expectError: true,
+			},
+			{
+				name:        "distributed switch by managed object ID",
+				entityType:  DISTRIBUTEDVIRTUALSWITCH,
+				id:          dvs.Self.Value,
+				expectError: true,
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				actual, err := GetMoidStrict(client, tc.entityType, tc.id)
+				if tc.expectError {
+					if err == nil {
+						t.Fatalf("expected error, got %q", actual)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tc.expected != actual {
+					t.Fatalf("expected %q, got %q", tc.expected, actual)
+				}
+			})
+		}
+
+		// The lenient lookup treats an existing managed object ID as such.
+		for _, id := range []string{vm.Self.Value, dvs.Self.Value} {
+			entityType := VM
+			if id == dvs.Self.Value {
+				entityType = DISTRIBUTEDVIRTUALSWITCH
+			}
+			actual, err := GetMoid(client, entityType, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != id {
+				t.Fatalf("expected %q, got %q", id, actual)
+			}
+		}
+	})
+}
+
+// queryingDVSManager adds QueryDvsByUuid, which is not implemented by
+// simulator.DistributedVirtualSwitchManager.
+type queryingDVSManager struct {
+	*simulator.DistributedVirtualSwitchManager
+}
+
+func (m *queryingDVSManager) QueryDvsByUuid(ctx *simulator.Context, req *types.QueryDvsByUuid) soap.HasFault {
+	body := &methods.QueryDvsByUuidBody{}
+	for _, obj := range ctx.Map.All("DistributedVirtualSwitch") {
+		if dvs := obj.(*simulator.DistributedVirtualSwitch); dvs.Uuid == req.Uuid {
+			ref := dvs.Self
+			body.Res = &types.QueryDvsByUuidResponse{Returnval: &ref}
+			return body
+		}
+	}
+	body.Fault_ = simulator.Fault("", &types.NotFound{})
+	return body
+}
+
+func TestIsEntityUUID(t *testing.T) {
+	cases := []struct {
+		name       string
+		entityType string
+		id         string
+		expected   bool
+	}{
+		{
+			name:       "virtual machine UUID",
+			entityType: VM,
+			id:         "42a1b2c3-d4e5-f6a7-b8c9-d0e1f2a3b4c5",
+			expected:   true,
+		},
+		{
+			name:       "virtual machine managed object ID",
+			entityType: VM,
+			id:         "vm-42",
+			expected:   false,
+		},
+		{
+			name:       "distributed switch UUID",
+			entityType: DISTRIBUTEDVIRTUALSWITCH,
+			id:         "50 3c 2f 7b 7c 05 59 0d-ec 1a 5e 68 cc 75 39 18",
+			expected:   true,
+		},
+		{
+			name:       "distributed switch managed object ID",
+			entityType: DISTRIBUTEDVIRTUALSWITCH,
+			id:         "dvs-21",
+			expected:   false,
+		},
+		{
+			name:       "other entity type",
+			entityType: DATASTORE,
+			id:         "42a1b2c3-d4e5-f6a7-b8c9-d0e1f2a3b4c5",
+			expected:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsEntityUUID(tc.entityType, tc.id); actual != tc.expected {
+				t.Fatalf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
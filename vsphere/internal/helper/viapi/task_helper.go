@@ -0,0 +1,240 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package viapi
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// TaskRetryPolicy controls how waiting for a task is retried when the wait
+// fails with a transient error, such as when vCenter Server is temporarily
+// unavailable or the session has expired. Only the wait is retried, the task
+// itself keeps running on the server.
+type TaskRetryPolicy struct {
+	// Retries is the number of times a wait is retried. 0 disables retries.
+	Retries int
+
+	// Delay is the time to wait before each retry.
+	Delay time.Duration
+
+	// Login logs in again after the session has expired. The wait is not
+	// retried after an expired session if Login is nil.
+	Login func(ctx context.Context) error
+}
+
+// DefaultTaskRetryPolicy is the policy used for clients without a policy set
+// by SetTaskRetryPolicy.
+var DefaultTaskRetryPolicy = TaskRetryPolicy{
+	Retries: 3,
+	Delay:   5 * time.Second,
+}
+
+// taskRetryRoundTripper carries the TaskRetryPolicy of a client in its
+// RoundTripper, so that the policy is released together with the client.
+type taskRetryRoundTripper struct {
+	soap.RoundTripper
+
+	policy TaskRetryPolicy
+}
+
+// SetTaskRetryPolicy sets the policy used when waiting for the tasks of client
+// c. The provider sets the number of retries and the login function of each
+// configured client. The policy is kept in the RoundTripper of c, so it must
+// be set after any other wrapping of the RoundTripper.
+func SetTaskRetryPolicy(c *vim25.Client, p TaskRetryPolicy) {
+	if rt, ok := c.RoundTripper.(*taskRetryRoundTripper); ok {
+		rt.policy = p
+		return
+	}
+	c.RoundTripper = &taskRetryRoundTripper{RoundTripper: c.RoundTripper, policy: p}
+}
+
+// TaskRetryPolicyFor returns the policy of client c, or DefaultTaskRetryPolicy
+// if none has been set.
+func TaskRetryPolicyFor(c *vim25.Client) TaskRetryPolicy {
+	if rt, ok := c.RoundTripper.(*taskRetryRoundTripper); ok {
+		return rt.policy
+	}
+	return DefaultTaskRetryPolicy
+}
+
+// WaitForTask waits for task to complete, retrying on transient errors with
+// the policy of the client of the task.
+func WaitForTask(ctx context.Context, task *object.Task) error {
+	_, err := TaskRetryPolicyFor(task.Client()).WaitForResult(ctx, task)
+	return err
+}
+
+// WaitForTaskResult waits for task to complete and returns its result,
+// retrying on transient errors with the policy of the client of the task.
+func WaitForTaskResult(ctx context.Context, task *object.Task) (*types.TaskInfo, error) {
+	return TaskRetryPolicyFor(task.Client()).WaitForResult(ctx, task)
+}
+
+// WaitForResult waits for task to complete and returns its result. The wait
+// is retried up to p.Retries times if it fails with a transient error. An
+// error of the task itself is not transient and is returned without retrying.
+func (p TaskRetryPolicy) WaitForResult(ctx context.Context, task *object.Task) (*types.TaskInfo, error) {
+	var info *types.TaskInfo
+	err := p.Retry(ctx, func() error {
+		var err error
+		info, err = task.WaitForResultEx(ctx, nil)
+		return err
+	})
+	return info, err
+}
+
+// Retry runs op, which must be safe to run again, such as a call that does
+// not change anything or that sets the same configuration again. If op fails
+// with a transient error, it is run again up to p.Retries times. After an
+// expired session, p.Login is called before op is run again.
+func (p TaskRetryPolicy) Retry(ctx context.Context, op func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= p.Retries {
+			return err
+		}
+		switch {
+		case IsNotAuthenticatedError(err):
+			if p.Login == nil {
+				return err
+			}
+			log.Printf("[DEBUG] Session expired, logging in again")
+			if lerr := p.Login(ctx); lerr != nil {
+				log.Printf("[DEBUG] Could not log in again: %s", lerr)
+				return err
+			}
+		case IsTransientError(err):
+			log.Printf("[DEBUG] Transient error, retrying (%d/%d): %s", attempt+1, p.Retries, err)
+		default:
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(p.Delay):
+		}
+	}
+}
+
+// IsNotAuthenticatedError checks an error to see if it's of the
+// NotAuthenticated type, which is returned when the session has expired.
+func IsNotAuthenticatedError(err error) bool {
+	if f, ok := vimSoapFault(err); ok {
+		if _, ok := f.(types.NotAuthenticated); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTransientError checks an error to see if it is a temporary network error
+// or an HTTP 503 Service Unavailable response, which vCenter Server returns
+// while its services are restarting.
+func IsTransientError(err error) bool {
+	if vim25.IsTemporaryNetworkError(err) {
+		return true
+	}
+	var uerr *url.Error
+	if errors.As(err, &uerr) && uerr.Err != nil {
+		return strings.HasPrefix(uerr.Err.Error(), strconv.Itoa(http.StatusServiceUnavailable))
+	}
+	return false
+}
+
+// RetryOnTaskFault runs op and, if it fails with an error that retryable
+// reports as expected to clear on its own, runs it again up to retries times.
+// The delay before the first retry is delay and doubles with each further
+// retry. Any other error is returned without retrying. IsRetryableTaskFault
+// covers the faults that are transient for any operation.
+func RetryOnTaskFault(ctx context.Context, retries int, delay time.Duration, retryable func(error) bool, op func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= retries || !retryable(err) {
+			return err
+		}
+		log.Printf("[DEBUG] Retryable fault, retrying in %s (%d/%d): %s", delay, attempt+1, retries, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// IsRetryableTaskFault checks an error, either returned by a method or by a
+// task, to see if it is a fault that is expected to clear on its own. This is
+// TaskInProgress, returned while another task is running on the same object.
+// ManagedObjectNotFound is not included, as whether it is transient depends on
+// the operation.
+func IsRetryableTaskFault(err error) bool {
+	fault, ok := methodFault(err)
+	if !ok {
+		return false
+	}
+	switch fault.(type) {
+	case types.TaskInProgress, *types.TaskInProgress:
+		return true
+	}
+	return false
+}
+
+// IsManagedObjectNotFoundTaskFault checks an error, either returned by a
+// method or by a task, to see if it is a ManagedObjectNotFound fault.
+func IsManagedObjectNotFoundTaskFault(err error) bool {
+	fault, ok := methodFault(err)
+	if !ok {
+		return false
+	}
+	switch fault.(type) {
+	case types.ManagedObjectNotFound, *types.ManagedObjectNotFound:
+		return true
+	}
+	return false
+}
+
+// IsQuiesceFault checks an error, either returned by a method or by a task, to
+// see if it is a fault that is returned when the guest of a virtual machine
+// could not be quiesced for a snapshot. These are ApplicationQuiesceFault and
+// FilesystemQuiesceFault.
+func IsQuiesceFault(err error) bool {
+	fault, ok := methodFault(err)
+	if !ok {
+		return false
+	}
+	switch fault.(type) {
+	case types.ApplicationQuiesceFault, *types.ApplicationQuiesceFault,
+		types.FilesystemQuiesceFault, *types.FilesystemQuiesceFault:
+		return true
+	}
+	return false
+}
+
+// methodFault extracts the VIM fault from an error returned by a method or by
+// a task. Check the returned boolean value to see if you have a fault, which
+// will need to be further asserted into the fault that you are looking for.
+// Faults of methods are values and faults of tasks are pointers.
+func methodFault(err error) (interface{}, bool) {
+	var terr task.Error
+	if errors.As(err, &terr) && terr.LocalizedMethodFault != nil {
+		return terr.Fault(), true
+	}
+	return vimSoapFault(err)
+}
@@ -0,0 +1,448 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package viapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// flakyRoundTripper fails the first failures round trips with err.
+type flakyRoundTripper struct {
+	soap.RoundTripper
+
+	err      error
+	failures int
+}
+
+func (rt *flakyRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	if rt.failures > 0 {
+		rt.failures--
+		return rt.err
+	}
+	return rt.RoundTripper.RoundTrip(ctx, req, res)
+}
+
+func testServiceUnavailableError() error {
+	return &url.Error{Op: "POST", URL: "/sdk", Err: errors.New("503 Service Unavailable")}
+}
+
+func testNotAuthenticatedError() error {
+	f := &soap.Fault{}
+	f.Detail.Fault = types.NotAuthenticated{}
+	return soap.WrapSoapFault(f)
+}
+
+func TestTaskRetryPolicyWaitForResult(t *testing.T) {
+	cases := []struct {
+		name        string
+		err         error
+		failures    int
+		retries     int
+		login       bool
+		expectErr   bool
+		expectLogin bool
+	}{
+		{
+			name:     "transient error then success",
+			err:      testServiceUnavailableError(),
+			failures: 1,
+			retries:  3,
+		},
+		{
+			name:      "transient error without retries",
+			err:       testServiceUnavailableError(),
+			failures:  1,
+			expectErr: true,
+		},
+		{
+			name:      "transient error exceeds retries",
+			err:       testServiceUnavailableError(),
+			failures:  3,
+			retries:   2,
+			expectErr: true,
+		},
+		{
+			name:        "expired session then success",
+			err:         testNotAuthenticatedError(),
+			failures:    1,
+			retries:     3,
+			login:       true,
+			expectLogin: true,
+		},
+		{
+			name:      "expired session without login",
+			err:       testNotAuthenticatedError(),
+			failures:  1,
+			retries:   3,
+			expectErr: true,
+		},
+		{
+			name:      "permanent error",
+			err:       errors.New("permanent error"),
+			failures:  1,
+			retries:   3,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			simulator.Test(func(ctx context.Context, c *vim25.Client) {
+				vm := object.NewVirtualMachine(c, simulator.Map(ctx).Any("VirtualMachine").Reference())
+				task, err := vm.PowerOff(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				c.RoundTripper = &flakyRoundTripper{RoundTripper: c.RoundTripper, err: tc.err, failures: tc.failures}
+				loggedIn := false
+				p := TaskRetryPolicy{Retries: tc.retries}
+				if tc.login {
+					p.Login = func(context.Context) error {
+						loggedIn = true
+						return nil
+					}
+				}
+
+				_, err = p.WaitForResult(ctx, task)
+				if tc.expectErr != (err != nil) {
+					t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+				}
+				if tc.expectLogin != loggedIn {
+					t.Fatalf("expected login to be %t, got %t", tc.expectLogin, loggedIn)
+				}
+			})
+		})
+	}
+}
+
+func TestTaskRetryPolicyWaitForResultTaskError(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		vm := object.NewVirtualMachine(c, simulator.Map(ctx).Any("VirtualMachine").Reference())
+		task, err := vm.PowerOn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := TaskRetryPolicy{Retries: 3}
+		info, err := p.WaitForResult(ctx, task)
+		if err == nil {
+			t.Fatal("expected error powering on a powered on virtual machine, got none")
+		}
+		if info == nil || info.State != types.TaskInfoStateError {
+			t.Fatalf("expected task to be in error state, got %v", info)
+		}
+	})
+}
+
+func TestTaskRetryPolicyRetry(t *testing.T) {
+	cases := []struct {
+		name          string
+		errs          []error
+		retries       int
+		expectErr     bool
+		expectAttempt int
+	}{
+		{
+			name:          "transient error then success",
+			errs:          []error{testServiceUnavailableError()},
+			retries:       3,
+			expectAttempt: 2,
+		},
+		{
+			name:          "transient error exceeds retries",
+			errs:          []error{testServiceUnavailableError(), testServiceUnavailableError()},
+			retries:       1,
+			expectErr:     true,
+			expectAttempt: 2,
+		},
+		{
+			name:          "permanent error",
+			errs:          []error{errors.New("permanent error")},
+			retries:       3,
+			expectErr:     true,
+			expectAttempt: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempt := 0
+			err := TaskRetryPolicy{Retries: tc.retries}.Retry(context.Background(), func() error {
+				attempt++
+				if attempt <= len(tc.errs) {
+					return tc.errs[attempt-1]
+				}
+				return nil
+			})
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+			if tc.expectAttempt != attempt {
+				t.Fatalf("expected %d attempts, got %d", tc.expectAttempt, attempt)
+			}
+		})
+	}
+}
+
+func TestTaskRetryPolicyFor(t *testing.T) {
+	c1, c2 := new(vim25.Client), new(vim25.Client)
+	SetTaskRetryPolicy(c1, TaskRetryPolicy{Retries: 1})
+	SetTaskRetryPolicy(c2, TaskRetryPolicy{Retries: 2})
+
+	if p := TaskRetryPolicyFor(c1); p.Retries != 1 {
+		t.Fatalf("expected 1 retry for the first client, got %d", p.Retries)
+	}
+	if p := TaskRetryPolicyFor(c2); p.Retries != 2 {
+		t.Fatalf("expected 2 retries for the second client, got %d", p.Retries)
+	}
+	if p := TaskRetryPolicyFor(new(vim25.Client)); p.Retries != DefaultTaskRetryPolicy.Retries {
+		t.Fatalf("expected the default policy for an unknown client, got %d retries", p.Retries)
+	}
+
+	// Setting the policy again replaces it instead of wrapping the client again.
+	SetTaskRetryPolicy(c1, TaskRetryPolicy{Retries: 4})
+	if p := TaskRetryPolicyFor(c1); p.Retries != 4 {
+		t.Fatalf("expected 4 retries for the first client, got %d", p.Retries)
+	}
+	if _, ok := c1.RoundTripper.(*taskRetryRoundTripper).RoundTripper.(*taskRetryRoundTripper); ok {
+		t.Fatalf("expected the client to be wrapped once")
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "service unavailable",
+			err:      testServiceUnavailableError(),
+			expected: true,
+		},
+		{
+			name:     "not found",
+			err:      &url.Error{Op: "POST", URL: "/sdk", Err: errors.New("404 Not Found")},
+			expected: false,
+		},
+		{
+			name:     "not authenticated",
+			err:      testNotAuthenticatedError(),
+			expected: false,
+		},
+		{
+			name:     "other error",
+			err:      errors.New("other error"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsTransientError(tc.err); tc.expected != actual {
+				t.Fatalf("expected transient error to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func testTaskFaultError(fault types.BaseMethodFault) error {
+	return task.Error{LocalizedMethodFault: &types.LocalizedMethodFault{Fault: fault, LocalizedMessage: "task fault"}}
+}
+
+func TestIsRetryableTaskFault(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "task in progress task error",
+			err:      testTaskFaultError(&types.TaskInProgress{}),
+			expected: true,
+		},
+		{
+			name:     "managed object not found task error",
+			err:      testTaskFaultError(&types.ManagedObjectNotFound{}),
+			expected: false,
+		},
+		{
+			name:     "wrapped task error",
+			err:      fmt.Errorf("error while waiting for task: %w", testTaskFaultError(&types.TaskInProgress{})),
+			expected: true,
+		},
+		{
+			name:     "other task error",
+			err:      testTaskFaultError(&types.InvalidPowerState{}),
+			expected: false,
+		},
+		{
+			name:     "not authenticated soap fault",
+			err:      testNotAuthenticatedError(),
+			expected: false,
+		},
+		{
+			name:     "other error",
+			err:      errors.New("other error"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsRetryableTaskFault(tc.err); tc.expected != actual {
+				t.Fatalf("expected retryable fault to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRetryOnTaskFault(t *testing.T) {
+	cases := []struct {
+		name          string
+		err           error
+		failures      int
+		retries       int
+		expectErr     bool
+		expectedCalls int
+	}{
+		{
+			name:          "retryable fault then success",
+			err:           testTaskFaultError(&types.TaskInProgress{}),
+			failures:      2,
+			retries:       3,
+			expectedCalls: 3,
+		},
+		{
+			name:          "retryable fault exceeds retries",
+			err:           testTaskFaultError(&types.TaskInProgress{}),
+			failures:      3,
+			retries:       2,
+			expectErr:     true,
+			expectedCalls: 3,
+		},
+		{
+			name:          "permanent fault",
+			err:           testTaskFaultError(&types.InvalidPowerState{}),
+			failures:      1,
+			retries:       3,
+			expectErr:     true,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := RetryOnTaskFault(context.Background(), tc.retries, time.Millisecond, IsRetryableTaskFault, func() error {
+				calls++
+				if calls <= tc.failures {
+					return tc.err
+				}
+				return nil
+			})
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+			if tc.expectedCalls != calls {
+				t.Fatalf("expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestIsManagedObjectNotFoundTaskFault(t *testing.T) {
+	notFound := &soap.Fault{}
+	notFound.Detail.Fault = types.ManagedObjectNotFound{}
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "managed object not found task error",
+			err:      testTaskFaultError(&types.ManagedObjectNotFound{}),
+			expected: true,
+		},
+		{
+			name:     "wrapped managed object not found task error",
+			err:      fmt.Errorf("error while waiting for task: %w", testTaskFaultError(&types.ManagedObjectNotFound{})),
+			expected: true,
+		},
+		{
+			name:     "managed object not found soap fault",
+			err:      soap.WrapSoapFault(notFound),
+			expected: true,
+		},
+		{
+			name:     "other task error",
+			err:      testTaskFaultError(&types.TaskInProgress{}),
+			expected: false,
+		},
+		{
+			name:     "other error",
+			err:      errors.New("other error"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsManagedObjectNotFoundTaskFault(tc.err); tc.expected != actual {
+				t.Fatalf("expected managed object not found fault to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsQuiesceFault(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "application quiesce fault",
+			err:      testTaskFaultError(&types.ApplicationQuiesceFault{}),
+			expected: true,
+		},
+		{
+			name:     "file system quiesce fault",
+			err:      testTaskFaultError(&types.FilesystemQuiesceFault{}),
+			expected: true,
+		},
+		{
+			name:     "other task error",
+			err:      testTaskFaultError(&types.TaskInProgress{}),
+			expected: false,
+		},
+		{
+			name:     "other error",
+			err:      errors.New("other error"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsQuiesceFault(tc.err); tc.expected != actual {
+				t.Fatalf("expected quiesce fault to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
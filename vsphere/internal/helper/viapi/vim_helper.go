@@ -298,3 +298,38 @@ func (v VSphereVersion) Older(other VSphereVersion) bool {
 func (v VSphereVersion) Equal(other VSphereVersion) bool {
 	return v.ProductEqual(other) && !v.Older(other) && !v.Newer(other)
 }
+
+// hardwareVersionsByRelease maps vSphere releases to the latest virtual machine
+// hardware version they support, in ascending order.
+var hardwareVersionsByRelease = []struct {
+	major, minor, patch int
+	hardwareVersion     int
+}{
+	{4, 0, 0, 7},
+	{5, 0, 0, 8},
+	{5, 1, 0, 9},
+	{5, 5, 0, 10},
+	{6, 0, 0, 11},
+	{6, 5, 0, 13},
+	{6, 7, 0, 14},
+	{7, 0, 0, 17},
+	{7, 0, 1, 18},
+	{7, 0, 2, 19},
+	{8, 0, 0, 20},
+	{8, 0, 2, 21},
+	{9, 0, 0, 22},
+}
+
+// MaxHardwareVersion returns the latest virtual machine hardware version
+// supported by the supplied vCenter Server or ESXi version. Releases newer
+// than the last known release are assumed to support the same hardware
+// version as that release.
+func MaxHardwareVersion(v VSphereVersion) int {
+	maxVersion := 4
+	for _, r := range hardwareVersionsByRelease {
+		if v.AtLeast(VSphereVersion{Product: v.Product, Major: r.major, Minor: r.minor, Patch: r.patch}) {
+			maxVersion = r.hardwareVersion
+		}
+	}
+	return maxVersion
+}
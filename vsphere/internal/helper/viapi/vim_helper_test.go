@@ -385,3 +385,65 @@ func TestAtLeast(t *testing.T) {
 	}
 
 }
+
+func TestMaxHardwareVersion(t *testing.T) {
+	cases := []struct {
+		name     string
+		version  VSphereVersion
+		expected int
+	}{
+		{
+			name:     "vCenter 6.5",
+			version:  VSphereVersion{Product: "VMware vCenter Server", Major: 6, Minor: 5, Patch: 0, Build: 4602587},
+			expected: 13,
+		},
+		{
+			name:     "vCenter 6.7",
+			version:  VSphereVersion{Product: "VMware vCenter Server", Major: 6, Minor: 7, Patch: 0, Build: 8170087},
+			expected: 14,
+		},
+		{
+			name:     "vCenter 7.0",
+			version:  VSphereVersion{Product: "VMware vCenter Server", Major: 7, Minor: 0, Patch: 0, Build: 15952498},
+			expected: 17,
+		},
+		{
+			name:     "vCenter 7.0 Update 3",
+			version:  VSphereVersion{Product: "VMware vCenter Server", Major: 7, Minor: 0, Patch: 3, Build: 18700403},
+			expected: 19,
+		},
+		{
+			name:     "vCenter 8.0 Update 1",
+			version:  VSphereVersion{Product: "VMware vCenter Server", Major: 8, Minor: 0, Patch: 1, Build: 21560480},
+			expected: 20,
+		},
+		{
+			name:     "ESXi 8.0 Update 2",
+			version:  VSphereVersion{Product: "VMware ESXi", Major: 8, Minor: 0, Patch: 2, Build: 22380479},
+			expected: 21,
+		},
+		{
+			name:     "vCenter 9.0",
+			version:  VSphereVersion{Product: "VMware vCenter Server", Major: 9, Minor: 0, Patch: 0, Build: 24755230},
+			expected: 22,
+		},
+		{
+			name:     "newer than known releases",
+			version:  VSphereVersion{Product: "VMware vCenter Server", Major: 10, Minor: 1, Patch: 0, Build: 1},
+			expected: 22,
+		},
+		{
+			name:     "older than known releases",
+			version:  VSphereVersion{Product: "VMware ESX", Major: 3, Minor: 5, Patch: 0, Build: 1},
+			expected: 4,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := MaxHardwareVersion(tc.version); tc.expected != actual {
+				t.Fatalf("expected max hardware version %d, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
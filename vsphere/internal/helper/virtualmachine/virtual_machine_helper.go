@@ -86,6 +86,25 @@ func IsUUIDNotFoundError(err error) bool {
 	return ok
 }
 
+// SnapshotNotFoundError is an error type that is returned when a snapshot
+// could not be found on a virtual machine.
+type SnapshotNotFoundError struct {
+	s string
+}
+
+// Error implements error for SnapshotNotFoundError.
+func (e *SnapshotNotFoundError) Error() string {
+	return e.s
+}
+
+// IsSnapshotNotFoundError returns true if the error is a
+// SnapshotNotFoundError.
+func IsSnapshotNotFoundError(err error) bool {
+	var notFoundError *SnapshotNotFoundError
+	ok := errors.As(err, &notFoundError)
+	return ok
+}
+
 func List(client *govmomi.Client) ([]*object.VirtualMachine, error) {
 	return vmsByPath(client, "/*")
 }
@@ -268,6 +287,18 @@ func Properties(vm *object.VirtualMachine) (*mo.VirtualMachine, error) {
 	return &props, nil
 }
 
+// IsConfigAvailable returns true if the configuration of a virtual machine in
+// the supplied connection state can be read. The configuration of orphaned,
+// inaccessible, or invalid virtual machines is missing or incomplete, while a
+// disconnected virtual machine retains the last known configuration.
+func IsConfigAvailable(state types.VirtualMachineConnectionState) bool {
+	switch state {
+	case types.VirtualMachineConnectionStateConnected, types.VirtualMachineConnectionStateDisconnected:
+		return true
+	}
+	return false
+}
+
 // ConfigOptions is a convenience method that wraps fetching the VirtualMachine ConfigOptions
 // as returned by QueryConfigOption.
 func ConfigOptions(vm *object.VirtualMachine) (*types.VirtualMachineConfigOption, error) {
@@ -345,6 +376,60 @@ func WaitForGuestIP(client *govmomi.Client, vm *object.VirtualMachine, timeout i
 	return nil
 }
 
+// IsCustomizationPending returns true if the guest customization of a virtual
+// machine has not finished yet, such as after a clone with customization that
+// is applied on the first boot.
+func IsCustomizationPending(info *types.GuestInfoCustomizationInfo) bool {
+	if info == nil {
+		return false
+	}
+	switch types.GuestInfoCustomizationStatus(info.CustomizationStatus) {
+	case types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_PENDING, types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_RUNNING:
+		return true
+	}
+	return false
+}
+
+// WaitForCustomizationComplete waits for a pending guest customization of a
+// virtual machine to finish, so that reconfigures and reboots do not race the
+// customization in the guest. It returns immediately if no customization is
+// pending, or if the timeout is zero or negative.
+func WaitForCustomizationComplete(client *govmomi.Client, vm *object.VirtualMachine, timeout time.Duration) error {
+	if timeout <= 0 {
+		log.Printf("[DEBUG] Skipping customization waiter for VM %q", vm.InventoryPath)
+		return nil
+	}
+	log.Printf("[DEBUG] Waiting for pending customization on VM %q (timeout = %s)", vm.InventoryPath, timeout)
+
+	p := client.PropertyCollector()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := property.Wait(ctx, p, vm.Reference(), []string{"guest.customizationInfo"}, func(pc []types.PropertyChange) bool {
+		for _, c := range pc {
+			if c.Op != types.PropertyChangeOpAssign {
+				continue
+			}
+			switch v := c.Val.(type) {
+			case types.GuestInfoCustomizationInfo:
+				return !IsCustomizationPending(&v)
+			case *types.GuestInfoCustomizationInfo:
+				return !IsCustomizationPending(v)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return errors.New("timeout waiting for pending customization to complete")
+		}
+		return err
+	}
+
+	log.Printf("[DEBUG] No customization is pending on VM %q", vm.InventoryPath)
+	return nil
+}
+
 // WaitForGuestNet waits for a virtual machine to have routable network
 // access. This is denoted as a gateway, and at least one IP address that can
 // reach that gateway. This function supports both IPv4 and IPv6, and returns
@@ -364,12 +449,16 @@ func WaitForGuestNet(client *govmomi.Client, vm *object.VirtualMachine, routable
 		timeout,
 	)
 	var v4gw, v6gw net.IP
+	var nics []types.GuestNicInfo
 
 	p := client.PropertyCollector()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*time.Duration(timeout))
 	defer cancel()
 
 	err := property.Wait(ctx, p, vm.Reference(), []string{"guest.net", "guest.ipStack"}, func(pc []types.PropertyChange) bool {
+		// The gateways and addresses can arrive in any order, and in separate
+		// updates, so both are tracked and the addresses are checked once all
+		// changes in the update have been applied.
 		for _, c := range pc {
 			if c.Op != types.PropertyChangeOpAssign {
 				continue
@@ -390,35 +479,11 @@ func WaitForGuestNet(client *govmomi.Client, vm *object.VirtualMachine, routable
 					}
 				}
 			case types.ArrayOfGuestNicInfo:
-				for _, n := range v.GuestNicInfo {
-					if n.IpConfig != nil {
-						for _, addr := range n.IpConfig.IpAddress {
-							ip := net.ParseIP(addr.IpAddress)
-							if skipIPAddrForWaiter(ip, ignoredGuestIPs) {
-								continue
-							}
-							if !routable {
-								// We are done. The rest of this block concerns itself with
-								// checking for a routable address, but the waiter has been
-								// flagged to not wait for one.
-								return true
-							}
-							var mask net.IPMask
-							if ip.To4() != nil {
-								mask = net.CIDRMask(int(addr.PrefixLength), 32)
-							} else {
-								mask = net.CIDRMask(int(addr.PrefixLength), 128)
-							}
-							if ip.Mask(mask).Equal(v4gw.Mask(mask)) || ip.Mask(mask).Equal(v6gw.Mask(mask)) {
-								return true
-							}
-						}
-					}
-				}
+				nics = v.GuestNicInfo
 			}
 		}
 
-		return false
+		return guestNetReady(nics, v4gw, v6gw, routable, ignoredGuestIPs)
 	})
 
 	if err != nil {
@@ -433,6 +498,40 @@ func WaitForGuestNet(client *govmomi.Client, vm *object.VirtualMachine, routable
 	return nil
 }
 
+// guestNetReady returns true if nics has an address that satisfies the guest
+// network waiter. Loopback, link-local, multicast, and ignored addresses never
+// count. When routable is false, any other address is enough. Otherwise, the
+// address must be on the same network as the IPv4 or IPv6 default gateway.
+func guestNetReady(nics []types.GuestNicInfo, v4gw, v6gw net.IP, routable bool, ignoredGuestIPs []interface{}) bool {
+	for _, n := range nics {
+		if n.IpConfig == nil {
+			continue
+		}
+		for _, addr := range n.IpConfig.IpAddress {
+			ip := net.ParseIP(addr.IpAddress)
+			if ip == nil || skipIPAddrForWaiter(ip, ignoredGuestIPs) {
+				continue
+			}
+			if !routable {
+				return true
+			}
+			var mask net.IPMask
+			var gw net.IP
+			if ip.To4() != nil {
+				mask = net.CIDRMask(int(addr.PrefixLength), 32)
+				gw = v4gw
+			} else {
+				mask = net.CIDRMask(int(addr.PrefixLength), 128)
+				gw = v6gw
+			}
+			if gw != nil && ip.Mask(mask).Equal(gw.Mask(mask)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func skipIPAddrForWaiter(ip net.IP, ignoredGuestIPs []interface{}) bool {
 	switch {
 	case ip.IsLinkLocalMulticast():
@@ -838,7 +937,46 @@ func Reconfigure(vm *object.VirtualMachine, spec types.VirtualMachineConfigSpec,
 	}
 	tctx, tcancel := context.WithTimeout(context.Background(), timeout)
 	defer tcancel()
-	return task.WaitEx(tctx)
+	return viapi.WaitForTask(tctx, task)
+}
+
+// pendingCustomizationKey is the advanced setting of a virtual machine that
+// refers to the package of a pending guest customization.
+const pendingCustomizationKey = "tools.deployPkg.fileName"
+
+// HasPendingCustomization returns true if the virtual machine has a guest
+// customization package that has not been applied yet.
+func HasPendingCustomization(props *mo.VirtualMachine) bool {
+	return props.Config != nil && props.Config.Tools != nil && props.Config.Tools.PendingCustomization != ""
+}
+
+// ClearPendingCustomization removes the pending guest customization of a
+// virtual machine, such as one left behind by a failed customization, so that
+// it is not applied on the next boot.
+func ClearPendingCustomization(vm *object.VirtualMachine, timeout time.Duration) error {
+	log.Printf("[DEBUG] Clearing pending customization on virtual machine %q", vm.InventoryPath)
+	spec := types.VirtualMachineConfigSpec{
+		ExtraConfig: []types.BaseOptionValue{
+			&types.OptionValue{Key: pendingCustomizationKey, Value: ""},
+		},
+	}
+	return Reconfigure(vm, spec, timeout)
+}
+
+// UpgradeTools wraps the UpgradeTools task and the subsequent waiting for the
+// task to complete. The virtual machine must be powered on, with VMware Tools
+// installed and running.
+func UpgradeTools(vm *object.VirtualMachine, timeout time.Duration) error {
+	log.Printf("[DEBUG] Upgrading VMware Tools on virtual machine %q", vm.InventoryPath)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	task, err := vm.UpgradeTools(ctx, "")
+	if err != nil {
+		return err
+	}
+	tctx, tcancel := context.WithTimeout(context.Background(), timeout)
+	defer tcancel()
+	return viapi.WaitForTask(tctx, task)
 }
 
 // Relocate wraps the Relocate task and the subsequent waiting for the task to
@@ -875,6 +1013,130 @@ func Destroy(vm *object.VirtualMachine) error {
 	return task.WaitEx(tctx)
 }
 
+// ConsolidateDisksIfNeeded checks if the disks of a virtual machine need to be
+// consolidated, such as after a snapshot removal that did not fully complete,
+// and consolidates them if so. It returns true if a consolidation was run.
+func ConsolidateDisksIfNeeded(vm *object.VirtualMachine) (bool, error) {
+	props, err := Properties(vm)
+	if err != nil {
+		return false, err
+	}
+	if !structure.BoolNilFalse(props.Runtime.ConsolidationNeeded) {
+		log.Printf("[DEBUG] Virtual machine %q does not need disk consolidation", vm.InventoryPath)
+		return false, nil
+	}
+
+	log.Printf("[DEBUG] Consolidating disks for virtual machine %q", vm.InventoryPath)
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	req := types.ConsolidateVMDisks_Task{This: vm.Reference()}
+	res, err := methods.ConsolidateVMDisks_Task(ctx, vm.Client(), &req)
+	if err != nil {
+		return false, err
+	}
+	task := object.NewTask(vm.Client(), res.Returnval)
+	tctx, tcancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer tcancel()
+	if err := viapi.WaitForTask(tctx, task); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FindSnapshotTree searches a snapshot tree, such as the root snapshot list of
+// a virtual machine, for the snapshot with the supplied managed object ID, and
+// returns its node. nil is returned if the snapshot is not in the tree.
+func FindSnapshotTree(trees []types.VirtualMachineSnapshotTree, id string) *types.VirtualMachineSnapshotTree {
+	for i := range trees {
+		if trees[i].Snapshot.Value == id {
+			return &trees[i]
+		}
+		if tree := FindSnapshotTree(trees[i].ChildSnapshotList, id); tree != nil {
+			return tree
+		}
+	}
+	return nil
+}
+
+// findSnapshotRefs searches a snapshot tree, such as the root snapshot list of
+// a virtual machine, for the snapshots with the supplied managed object ID,
+// name, or path of names below parent, such as root/child, and returns their
+// references.
+func findSnapshotRefs(trees []types.VirtualMachineSnapshotTree, parent string, snapshot string) []types.ManagedObjectReference {
+	var refs []types.ManagedObjectReference
+	for i := range trees {
+		name := trees[i].Name
+		if parent != "" {
+			name = parent + "/" + name
+		}
+		if trees[i].Snapshot.Value == snapshot || trees[i].Name == snapshot || name == snapshot {
+			refs = append(refs, trees[i].Snapshot)
+		}
+		refs = append(refs, findSnapshotRefs(trees[i].ChildSnapshotList, name, snapshot)...)
+	}
+	return refs
+}
+
+// SnapshotDepth returns the depth of the snapshot with the supplied managed
+// object ID in a snapshot tree, such as the root snapshot list of a virtual
+// machine. A root snapshot has a depth of 1. 0 is returned if the snapshot is
+// not in the tree.
+func SnapshotDepth(trees []types.VirtualMachineSnapshotTree, id string) int {
+	for i := range trees {
+		if trees[i].Snapshot.Value == id {
+			return 1
+		}
+		if depth := SnapshotDepth(trees[i].ChildSnapshotList, id); depth > 0 {
+			return depth + 1
+		}
+	}
+	return 0
+}
+
+// RevertToSnapshot reverts a virtual machine to the snapshot with the supplied
+// managed object ID or name. If suppressPowerOn is true, the virtual machine
+// is not powered on after the revert, even if it was powered on when the
+// snapshot was taken. A SnapshotNotFoundError is returned if the snapshot no
+// longer exists on the virtual machine.
+func RevertToSnapshot(vm *object.VirtualMachine, snapshot string, suppressPowerOn bool, timeout time.Duration) error {
+	log.Printf("[DEBUG] Reverting virtual machine %q to snapshot %q", vm.InventoryPath, snapshot)
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	var props mo.VirtualMachine
+	if err := vm.Properties(ctx, vm.Reference(), []string{"snapshot"}, &props); err != nil {
+		return err
+	}
+	var trees []types.VirtualMachineSnapshotTree
+	if props.Snapshot != nil {
+		trees = props.Snapshot.RootSnapshotList
+	}
+	notFound := &SnapshotNotFoundError{s: fmt.Sprintf("snapshot %q not found on virtual machine %q", snapshot, vm.InventoryPath)}
+	refs := findSnapshotRefs(trees, "", snapshot)
+	switch {
+	case len(refs) == 0:
+		return notFound
+	case len(refs) > 1:
+		return fmt.Errorf("snapshot %q resolves to %d snapshots on virtual machine %q", snapshot, len(refs), vm.InventoryPath)
+	}
+
+	req := types.RevertToSnapshot_Task{
+		This:            refs[0],
+		SuppressPowerOn: &suppressPowerOn,
+	}
+	res, err := methods.RevertToSnapshot_Task(ctx, vm.Client(), &req)
+	if err != nil {
+		// The snapshot was removed after it was looked up.
+		if viapi.IsManagedObjectNotFoundError(err) {
+			return notFound
+		}
+		return err
+	}
+	task := object.NewTask(vm.Client(), res.Returnval)
+	tctx, tcancel := context.WithTimeout(context.Background(), timeout)
+	defer tcancel()
+	return viapi.WaitForTask(tctx, task)
+}
+
 // MOIDForUUIDResult is a struct that holds a virtual machine UUID -> MOID
 // association, designed to be used as a helper for mass returning the results
 // of translating multiple UUIDs to managed object IDs for various virtual
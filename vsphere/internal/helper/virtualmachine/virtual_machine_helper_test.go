@@ -0,0 +1,509 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualmachine
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// consolidatingVM adds disk consolidation, which is not implemented by
+// simulator.VirtualMachine.
+type consolidatingVM struct {
+	*simulator.VirtualMachine
+
+	consolidations int
+}
+
+func (vm *consolidatingVM) ConsolidateVMDisksTask(ctx *simulator.Context, req *types.ConsolidateVMDisks_Task) soap.HasFault {
+	task := simulator.CreateTask(req.This, "consolidateVMDisks", func(*simulator.Task) (types.AnyType, types.BaseMethodFault) {
+		vm.consolidations++
+		needed := false
+		vm.Runtime.ConsolidationNeeded = &needed
+		return nil, nil
+	})
+
+	return &methods.ConsolidateVMDisks_TaskBody{
+		Res: &types.ConsolidateVMDisks_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+func TestConsolidateDisksIfNeeded(t *testing.T) {
+	cases := []struct {
+		name                string
+		consolidationNeeded *bool
+		expected            bool
+	}{
+		{
+			name:                "consolidation needed",
+			consolidationNeeded: types.NewBool(true),
+			expected:            true,
+		},
+		{
+			name:                "consolidation not needed",
+			consolidationNeeded: types.NewBool(false),
+			expected:            false,
+		},
+		{
+			name:                "consolidation state unknown",
+			consolidationNeeded: nil,
+			expected:            false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := simulator.VPX()
+			err := model.Run(func(_ context.Context, c *vim25.Client) error {
+				obj := &consolidatingVM{VirtualMachine: model.Map().Any("VirtualMachine").(*simulator.VirtualMachine)}
+				obj.Runtime.ConsolidationNeeded = tc.consolidationNeeded
+				model.Map().Put(obj)
+
+				vm := object.NewVirtualMachine(c, obj.Reference())
+				actual, err := ConsolidateDisksIfNeeded(vm)
+				if err != nil {
+					return err
+				}
+				if tc.expected != actual {
+					t.Fatalf("expected consolidation to be %t, got %t", tc.expected, actual)
+				}
+				if tc.expected != (obj.consolidations == 1) {
+					t.Fatalf("expected consolidation task to run %t, ran %d times", tc.expected, obj.consolidations)
+				}
+
+				props, err := Properties(vm)
+				if err != nil {
+					return err
+				}
+				if props.Runtime.ConsolidationNeeded != nil && *props.Runtime.ConsolidationNeeded {
+					t.Fatalf("expected consolidation to no longer be needed")
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRevertToSnapshot(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		vm := object.NewVirtualMachine(c, simulator.Map(ctx).Any("VirtualMachine").Reference())
+
+		if err := RevertToSnapshot(vm, "root", true, time.Minute); !IsSnapshotNotFoundError(err) {
+			t.Fatalf("expected snapshot not found error, got %v", err)
+		}
+
+		for _, name := range []string{"root", "child"} {
+			task, err := vm.CreateSnapshot(ctx, name, "", false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := task.WaitEx(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		root, err := vm.FindSnapshot(ctx, "root")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := RevertToSnapshot(vm, root.Value, true, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		props, err := Properties(vm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if props.Snapshot.CurrentSnapshot == nil || props.Snapshot.CurrentSnapshot.Value != root.Value {
+			t.Fatalf("expected current snapshot to be %s, got %v", root.Value, props.Snapshot.CurrentSnapshot)
+		}
+
+		if err := RevertToSnapshot(vm, "missing", true, time.Minute); !IsSnapshotNotFoundError(err) {
+			t.Fatalf("expected snapshot not found error, got %v", err)
+		}
+	})
+}
+
+func TestFindSnapshotRefs(t *testing.T) {
+	trees := []types.VirtualMachineSnapshotTree{
+		{
+			Name:     "root",
+			Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-1"},
+			ChildSnapshotList: []types.VirtualMachineSnapshotTree{
+				{
+					Name:     "child",
+					Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-2"},
+				},
+				{
+					Name:     "child",
+					Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-3"},
+				},
+			},
+		},
+	}
+	cases := []struct {
+		name     string
+		snapshot string
+		expected []string
+	}{
+		{
+			name:     "managed object ID",
+			snapshot: "snapshot-3",
+			expected: []string{"snapshot-3"},
+		},
+		{
+			name:     "name",
+			snapshot: "root",
+			expected: []string{"snapshot-1"},
+		},
+		{
+			name:     "ambiguous name",
+			snapshot: "root/child",
+			expected: []string{"snapshot-2", "snapshot-3"},
+		},
+		{
+			name:     "missing",
+			snapshot: "missing",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual []string
+			for _, ref := range findSnapshotRefs(trees, "", tc.snapshot) {
+				actual = append(actual, ref.Value)
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFindSnapshotTree(t *testing.T) {
+	trees := []types.VirtualMachineSnapshotTree{
+		{
+			Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-1"},
+			Name:     "root",
+			ChildSnapshotList: []types.VirtualMachineSnapshotTree{
+				{
+					Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-2"},
+					Name:     "child",
+					ChildSnapshotList: []types.VirtualMachineSnapshotTree{
+						{
+							Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-3"},
+							Name:     "grandchild",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		id       string
+		expected string
+	}{
+		{
+			name:     "root",
+			id:       "snapshot-1",
+			expected: "root",
+		},
+		{
+			name:     "nested",
+			id:       "snapshot-3",
+			expected: "grandchild",
+		},
+		{
+			name:     "missing",
+			id:       "snapshot-4",
+			expected: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree := FindSnapshotTree(trees, tc.id)
+			var actual string
+			if tree != nil {
+				actual = tree.Name
+			}
+			if tc.expected != actual {
+				t.Fatalf("expected snapshot %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSnapshotDepth(t *testing.T) {
+	trees := []types.VirtualMachineSnapshotTree{
+		{
+			Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-1"},
+			ChildSnapshotList: []types.VirtualMachineSnapshotTree{
+				{
+					Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-2"},
+					ChildSnapshotList: []types.VirtualMachineSnapshotTree{
+						{
+							Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-3"},
+						},
+					},
+				},
+				{
+					Snapshot: types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: "snapshot-4"},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		id       string
+		expected int
+	}{
+		{id: "snapshot-1", expected: 1},
+		{id: "snapshot-3", expected: 3},
+		{id: "snapshot-4", expected: 2},
+		{id: "snapshot-5", expected: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.id, func(t *testing.T) {
+			if actual := SnapshotDepth(trees, tc.id); tc.expected != actual {
+				t.Fatalf("expected depth %d, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsConfigAvailable(t *testing.T) {
+	cases := []struct {
+		state    types.VirtualMachineConnectionState
+		expected bool
+	}{
+		{
+			state:    types.VirtualMachineConnectionStateConnected,
+			expected: true,
+		},
+		{
+			state:    types.VirtualMachineConnectionStateDisconnected,
+			expected: true,
+		},
+		{
+			state:    types.VirtualMachineConnectionStateOrphaned,
+			expected: false,
+		},
+		{
+			state:    types.VirtualMachineConnectionStateInaccessible,
+			expected: false,
+		},
+		{
+			state:    types.VirtualMachineConnectionStateInvalid,
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.state), func(t *testing.T) {
+			if actual := IsConfigAvailable(tc.state); tc.expected != actual {
+				t.Fatalf("expected config availability to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWaitForCustomizationComplete(t *testing.T) {
+	cases := []struct {
+		name        string
+		status      types.GuestInfoCustomizationStatus
+		complete    bool
+		expectError bool
+	}{
+		{
+			name:   "no customization",
+			status: "",
+		},
+		{
+			name:     "pending customization completes",
+			status:   types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_PENDING,
+			complete: true,
+		},
+		{
+			name:        "pending customization times out",
+			status:      types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_RUNNING,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			simulator.Test(func(ctx context.Context, c *vim25.Client) {
+				obj := simulator.Map(ctx).Any("VirtualMachine").(*simulator.VirtualMachine)
+				if tc.status != "" {
+					simulator.Map(ctx).Update(ctx.(*simulator.Context), obj, []types.PropertyChange{
+						{Name: "guest.customizationInfo", Val: &types.GuestInfoCustomizationInfo{CustomizationStatus: string(tc.status)}},
+					})
+				}
+				if tc.complete {
+					go func() {
+						time.Sleep(500 * time.Millisecond)
+						simulator.Map(ctx).Update(ctx.(*simulator.Context), obj, []types.PropertyChange{
+							{Name: "guest.customizationInfo", Val: &types.GuestInfoCustomizationInfo{CustomizationStatus: string(types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_SUCCEEDED)}},
+						})
+					}()
+				}
+
+				vm := object.NewVirtualMachine(c, obj.Reference())
+				err := WaitForCustomizationComplete(&govmomi.Client{Client: c}, vm, 2*time.Second)
+				if tc.expectError != (err != nil) {
+					t.Fatalf("expected error to be %t, got %v", tc.expectError, err)
+				}
+			})
+		})
+	}
+}
+
+func TestIsCustomizationPending(t *testing.T) {
+	cases := []struct {
+		info     *types.GuestInfoCustomizationInfo
+		expected bool
+	}{
+		{info: nil, expected: false},
+		{info: &types.GuestInfoCustomizationInfo{CustomizationStatus: string(types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_IDLE)}, expected: false},
+		{info: &types.GuestInfoCustomizationInfo{CustomizationStatus: string(types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_PENDING)}, expected: true},
+		{info: &types.GuestInfoCustomizationInfo{CustomizationStatus: string(types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_RUNNING)}, expected: true},
+		{info: &types.GuestInfoCustomizationInfo{CustomizationStatus: string(types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_SUCCEEDED)}, expected: false},
+		{info: &types.GuestInfoCustomizationInfo{CustomizationStatus: string(types.GuestInfoCustomizationStatusTOOLSDEPLOYPKG_FAILED)}, expected: false},
+	}
+
+	for _, tc := range cases {
+		if actual := IsCustomizationPending(tc.info); tc.expected != actual {
+			t.Fatalf("expected pending customization for %#v to be %t, got %t", tc.info, tc.expected, actual)
+		}
+	}
+}
+
+func TestClearPendingCustomization(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		obj := simulator.Map(ctx).Any("VirtualMachine").(*simulator.VirtualMachine)
+		vm := object.NewVirtualMachine(c, obj.Reference())
+
+		spec := types.VirtualMachineConfigSpec{
+			ExtraConfig: []types.BaseOptionValue{
+				&types.OptionValue{Key: pendingCustomizationKey, Value: "imcf-abc123"},
+			},
+		}
+		if err := Reconfigure(vm, spec, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		obj.Config.Tools.PendingCustomization = "[LocalDS_0] vm/imcf-abc123"
+
+		props, err := Properties(vm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !HasPendingCustomization(props) {
+			t.Fatalf("expected pending customization")
+		}
+
+		if err := ClearPendingCustomization(vm, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		props, err = Properties(vm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range props.Config.ExtraConfig {
+			if ov := v.GetOptionValue(); ov.Key == pendingCustomizationKey {
+				t.Fatalf("expected %s to be removed, got %v", pendingCustomizationKey, ov.Value)
+			}
+		}
+	})
+}
+
+func TestGuestNetReady(t *testing.T) {
+	nics := func(addrs ...types.NetIpConfigInfoIpAddress) []types.GuestNicInfo {
+		return []types.GuestNicInfo{
+			{IpConfig: &types.NetIpConfigInfo{IpAddress: addrs}},
+		}
+	}
+	linkLocal := types.NetIpConfigInfoIpAddress{IpAddress: "fe80::250:56ff:fe8a:1", PrefixLength: 64}
+	v4 := types.NetIpConfigInfoIpAddress{IpAddress: "192.168.10.20", PrefixLength: 24}
+
+	cases := []struct {
+		name     string
+		nics     []types.GuestNicInfo
+		v4gw     string
+		routable bool
+		ignored  []interface{}
+		expected bool
+	}{
+		{
+			name: "no addresses",
+		},
+		{
+			name:     "link-local only, not routable",
+			nics:     nics(linkLocal),
+			expected: false,
+		},
+		{
+			name:     "address, not routable",
+			nics:     nics(linkLocal, v4),
+			expected: true,
+		},
+		{
+			name:     "address ignored",
+			nics:     nics(v4),
+			ignored:  []interface{}{"192.168.10.0/24"},
+			expected: false,
+		},
+		{
+			name:     "routable without gateway",
+			nics:     nics(v4),
+			routable: true,
+			expected: false,
+		},
+		{
+			name:     "routable with gateway on another network",
+			nics:     nics(v4),
+			v4gw:     "192.168.20.1",
+			routable: true,
+			expected: false,
+		},
+		{
+			name:     "routable with gateway",
+			nics:     nics(linkLocal, v4),
+			v4gw:     "192.168.10.1",
+			routable: true,
+			expected: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var v4gw net.IP
+			if tc.v4gw != "" {
+				v4gw = net.ParseIP(tc.v4gw)
+			}
+			if actual := guestNetReady(tc.nics, v4gw, nil, tc.routable, tc.ignored); actual != tc.expected {
+				t.Fatalf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -41,6 +41,16 @@ func CdromSubresourceSchema() map[string]*schema.Schema {
 			Optional:    true,
 			Description: "Indicates whether the device should be mapped to a remote client device",
 		},
+		"detach_iso": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Description: "Attach the ISO file specified by datastore_id and path only while the virtual machine is created, and switch the device to a remote client device on the next apply.",
+		},
+		"iso_attached": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Indicates whether an ISO file is attached to the device.",
+		},
 	}
 	structure.MergeSchema(s, subresourceSchema())
 	return s
@@ -363,7 +373,8 @@ func CdromPostCloneOperation(d *schema.ResourceData, c *govmomi.Client, l object
 			nm.(map[string]interface{})[k] = v
 		}
 		r := NewCdromSubresource(c, d, nm.(map[string]interface{}), sm, i)
-		if !reflect.DeepEqual(sm, nm) {
+		r.deferIsoDetach()
+		if !reflect.DeepEqual(sm, r.Data()) {
 			// Update
 			cspec, err := r.Update(l)
 			if err != nil {
@@ -413,6 +424,8 @@ func (r *CdromSubresource) ValidateDiff() error {
 		return fmt.Errorf("cannot have both client_device parameter and ISO file parameters (datastore_id, path) set")
 	case !clientDevice && (dsID == "" || path == ""):
 		return fmt.Errorf("either client_device or datastore_id and path must be set")
+	case r.detachIso() && clientDevice:
+		return fmt.Errorf("detach_iso requires datastore_id and path to be set instead of client_device")
 	}
 	log.Printf("[DEBUG] %s: Config validation complete", r)
 	return nil
@@ -425,6 +438,7 @@ func (r *CdromSubresource) Create(l object.VirtualDeviceList) ([]types.BaseVirtu
 	if err != nil {
 		return nil, err
 	}
+	r.deferIsoDetach()
 	var spec []types.BaseVirtualDeviceConfigSpec
 	var ctlr types.BaseVirtualController
 	ctlr, err = r.ControllerForCreateUpdate(l, SubresourceControllerTypeIDE, 0)
@@ -470,6 +484,14 @@ func (r *CdromSubresource) Read(l object.VirtualDeviceList) error {
 	// Only read backing info if it's available.
 	switch backing := device.Backing.(type) {
 	case *types.VirtualCdromRemoteAtapiBackingInfo:
+		r.Set("iso_attached", false)
+		if r.detachIso() {
+			// The ISO has been detached as requested. datastore_id and path
+			// describe the ISO the virtual machine was created with and are
+			// left as they are, iso_attached reports the detached device.
+			log.Printf("[DEBUG] %s: ISO has been detached, device is a remote client device", r)
+			break
+		}
 		r.Set("client_device", true)
 	case *types.VirtualCdromIsoBackingInfo:
 		dp := &object.DatastorePath{}
@@ -482,6 +504,7 @@ func (r *CdromSubresource) Read(l object.VirtualDeviceList) error {
 			r.Set("datastore_id", backing.Datastore.Value)
 		}
 		r.Set("path", dp.Path)
+		r.Set("iso_attached", true)
 	default:
 		// This is an unsupported entry, so we clear all attributes in the
 		// subresource (except for the device address and key, of course).  In
@@ -493,6 +516,7 @@ func (r *CdromSubresource) Read(l object.VirtualDeviceList) error {
 		r.Set("datastore_id", "")
 		r.Set("path", "")
 		r.Set("client_device", false)
+		r.Set("iso_attached", false)
 	}
 	// Save the device key and address data
 	ctlr, err := findControllerForDevice(l, d)
@@ -522,11 +546,18 @@ func (r *CdromSubresource) Update(l object.VirtualDeviceList) ([]types.BaseVirtu
 		return nil, fmt.Errorf("device at %q is not a virtual CDROM device", l.Name(d))
 	}
 
-	// Map the CDROM to the correct device
+	// Map the CDROM to the correct device. Swapping the ISO file is applied
+	// in place, but switching between an ISO file and a remote client device
+	// requires a restart. Detaching an ISO marked with detach_iso is exempt, as
+	// it is done automatically after the virtual machine has been created.
+	oldBacking := device.Backing
 	err = r.mapCdrom(device, l)
 	if err != nil {
 		return nil, err
 	}
+	if cdromBackingTypeChanged(oldBacking, device.Backing) && !r.detachIso() {
+		r.SetRestart("client_device")
+	}
 	spec, err := object.VirtualDeviceList{device}.ConfigSpec(types.VirtualDeviceConfigSpecOperationEdit)
 	if err != nil {
 		return nil, err
@@ -562,6 +593,15 @@ func (r *CdromSubresource) mapCdrom(device *types.VirtualCdrom, l object.Virtual
 	path := r.Get("path").(string)
 	clientDevice := r.Get("client_device").(bool)
 	switch {
+	case r.detachIso():
+		// The ISO was only needed to create the virtual machine. Switch the
+		// device over to a remote client device.
+		log.Printf("[DEBUG] %s: Detaching ISO and switching to a remote client device", r)
+		device.Backing = &types.VirtualCdromRemoteAtapiBackingInfo{
+			VirtualDeviceRemoteDeviceBackingInfo: types.VirtualDeviceRemoteDeviceBackingInfo{},
+		}
+		r.Set("iso_attached", false)
+		return nil
 	case dsID != "" && path != "":
 		// If the datastore ID and path are both set, the CDROM will be mapped to a file on a datastore.
 		ds, err := datastore.FromID(r.client, dsID)
@@ -593,6 +633,33 @@ func (r *CdromSubresource) mapCdrom(device *types.VirtualCdrom, l object.Virtual
 	return fmt.Errorf("%s: no CDROM types specified", r)
 }
 
+// cdromBackingTypeChanged returns true if the backing of a CDROM device
+// changes between an ISO file and a remote client device.
+func cdromBackingTypeChanged(old, newBacking types.BaseVirtualDeviceBackingInfo) bool {
+	if old == nil || newBacking == nil {
+		return false
+	}
+	return reflect.TypeOf(old) != reflect.TypeOf(newBacking)
+}
+
+// detachIso returns true if the ISO attached to this device should be
+// detached once the virtual machine has been created.
+func (r *CdromSubresource) detachIso() bool {
+	v, _ := r.Get("detach_iso").(bool)
+	return v
+}
+
+// deferIsoDetach keeps an ISO marked with detach_iso attached while the device
+// is created. detach_iso is saved as false to record that the ISO has not been
+// detached yet, so that the next apply produces a diff that detaches it.
+func (r *CdromSubresource) deferIsoDetach() {
+	if !r.detachIso() {
+		return
+	}
+	log.Printf("[DEBUG] %s: Keeping ISO attached until the next apply", r)
+	r.Set("detach_iso", false)
+}
+
 // VerifyVAppTransport validates that all the required components are included in
 // the virtual machine configuration if vApp properties are set.
 func VerifyVAppTransport(d *schema.ResourceDiff) error {
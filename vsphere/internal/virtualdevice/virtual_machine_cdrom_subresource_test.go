@@ -0,0 +1,253 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func testCdromDetachIsoDeviceList() object.VirtualDeviceList {
+	unit := int32(0)
+	return object.VirtualDeviceList{
+		&types.VirtualIDEController{
+			VirtualController: types.VirtualController{
+				VirtualDevice: types.VirtualDevice{Key: 200},
+				BusNumber:     0,
+				Device:        []int32{3000},
+			},
+		},
+		&types.VirtualCdrom{
+			VirtualDevice: types.VirtualDevice{
+				Key:           3000,
+				ControllerKey: 200,
+				UnitNumber:    &unit,
+				Backing: &types.VirtualCdromIsoBackingInfo{
+					VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+						FileName: "[datastore1] iso/install.iso",
+						Datastore: &types.ManagedObjectReference{
+							Type:  "Datastore",
+							Value: "datastore-1",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testCdromDetachIsoConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"key":            3000,
+		"device_address": "ide:0:0",
+		"datastore_id":   "datastore-1",
+		"path":           "iso/install.iso",
+		"client_device":  false,
+		"detach_iso":     true,
+	}
+}
+
+func TestCdromDetachIso(t *testing.T) {
+	l := testCdromDetachIsoDeviceList()
+
+	// Create: the ISO stays attached and detach_iso is saved as not applied
+	// yet, so that the next apply detaches it.
+	r := NewCdromSubresource(nil, nil, testCdromDetachIsoConfig(), nil, 0)
+	r.deferIsoDetach()
+	if r.Get("detach_iso").(bool) {
+		t.Fatalf("expected detach_iso to be saved as false while the ISO is attached")
+	}
+
+	// Read after create: the attached ISO is reported as is.
+	if err := r.Read(l); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Get("iso_attached").(bool) {
+		t.Fatalf("expected iso_attached to be true while the ISO is attached")
+	}
+	if r.Get("detach_iso").(bool) {
+		t.Fatalf("expected read to leave detach_iso as saved")
+	}
+	if r.Get("path").(string) != "iso/install.iso" {
+		t.Fatalf("expected path to be %q, got %q", "iso/install.iso", r.Get("path").(string))
+	}
+
+	// Next apply: detach_iso changes to true and the ISO is swapped for a
+	// remote client device.
+	r = NewCdromSubresource(nil, nil, testCdromDetachIsoConfig(), r.Data(), 0)
+	if !r.HasChange("detach_iso") {
+		t.Fatalf("expected a change to detach_iso")
+	}
+	spec, err := r.Update(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec) != 1 {
+		t.Fatalf("expected 1 device change, got %d", len(spec))
+	}
+	backing := spec[0].GetVirtualDeviceConfigSpec().Device.GetVirtualDevice().Backing
+	if _, ok := backing.(*types.VirtualCdromRemoteAtapiBackingInfo); !ok {
+		t.Fatalf("expected remote client device backing, got %T", backing)
+	}
+	l = applyDeviceChange(l, spec)
+
+	// Read after detach: the detached device is reported in iso_attached and
+	// the configured attributes match the configuration, so there is no diff.
+	r = NewCdromSubresource(nil, nil, testCdromDetachIsoConfig(), nil, 0)
+	if err := r.Read(l); err != nil {
+		t.Fatal(err)
+	}
+	expected := testCdromDetachIsoConfig()
+	expected["iso_attached"] = false
+	if !reflect.DeepEqual(expected, r.Data()) {
+		t.Fatalf("expected %#v, got %#v", expected, r.Data())
+	}
+
+	// A repeated apply leaves the device as a remote client device.
+	r = NewCdromSubresource(nil, nil, testCdromDetachIsoConfig(), nil, 0)
+	spec, err = r.Update(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backing = spec[0].GetVirtualDeviceConfigSpec().Device.GetVirtualDevice().Backing
+	if _, ok := backing.(*types.VirtualCdromRemoteAtapiBackingInfo); !ok {
+		t.Fatalf("expected remote client device backing, got %T", backing)
+	}
+}
+
+func TestCdromDetachIsoValidateDiff(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     map[string]interface{}
+		expected bool
+	}{
+		{
+			name:     "ISO with detach",
+			data:     testCdromDetachIsoConfig(),
+			expected: true,
+		},
+		{
+			name: "client device with detach",
+			data: map[string]interface{}{
+				"datastore_id":  "",
+				"path":          "",
+				"client_device": true,
+				"detach_iso":    true,
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewCdromSubresource(nil, nil, tc.data, nil, 0)
+			err := r.ValidateDiff()
+			if tc.expected != (err == nil) {
+				t.Fatalf("expected success to be %t, got error: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestCdromBackingTypeChanged(t *testing.T) {
+	iso := &types.VirtualCdromIsoBackingInfo{
+		VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+			FileName: "[datastore1] iso/install.iso",
+		},
+	}
+	otherIso := &types.VirtualCdromIsoBackingInfo{
+		VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+			FileName: "[datastore1] iso/tools.iso",
+		},
+	}
+	client := &types.VirtualCdromRemoteAtapiBackingInfo{}
+
+	cases := []struct {
+		name     string
+		old      types.BaseVirtualDeviceBackingInfo
+		new      types.BaseVirtualDeviceBackingInfo
+		expected bool
+	}{
+		{
+			name:     "iso swap",
+			old:      iso,
+			new:      otherIso,
+			expected: false,
+		},
+		{
+			name:     "iso to client device",
+			old:      iso,
+			new:      client,
+			expected: true,
+		},
+		{
+			name:     "client device to iso",
+			old:      client,
+			new:      iso,
+			expected: true,
+		},
+		{
+			name:     "no backing",
+			old:      nil,
+			new:      iso,
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := cdromBackingTypeChanged(tc.old, tc.new); tc.expected != actual {
+				t.Fatalf("expected backing type change to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCdromUpdateClientDeviceRestart(t *testing.T) {
+	cases := []struct {
+		name      string
+		detachIso bool
+		expected  bool
+	}{
+		{
+			name:     "switch to client device",
+			expected: true,
+		},
+		{
+			name:      "detach iso",
+			detachIso: true,
+			expected:  false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{
+				"reboot_required": {Type: schema.TypeBool, Optional: true},
+			}, map[string]interface{}{})
+			old := testCdromDetachIsoConfig()
+			old["detach_iso"] = false
+			config := testCdromDetachIsoConfig()
+			config["detach_iso"] = tc.detachIso
+			if !tc.detachIso {
+				config["datastore_id"] = ""
+				config["path"] = ""
+				config["client_device"] = true
+			}
+
+			r := NewCdromSubresource(nil, d, config, old, 0)
+			if _, err := r.Update(testCdromDetachIsoDeviceList()); err != nil {
+				t.Fatal(err)
+			}
+			if actual := d.Get("reboot_required").(bool); tc.expected != actual {
+				t.Fatalf("expected reboot_required to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
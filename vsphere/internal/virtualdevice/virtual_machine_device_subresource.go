@@ -10,6 +10,7 @@ import (
 	"log"
 	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -986,6 +987,47 @@ func AppendDeviceChangeSpec(
 	return spec
 }
 
+// OrderDeviceChangeSpec returns the device change operations in spec in the
+// order they are sent to vSphere in a single reconfigure:
+//
+//   - Removals come first, so that the resources of removed devices, such as
+//     controller slots and unit numbers, are freed for other devices.
+//   - Other changes follow in the order they were generated, so that new
+//     controllers still come before the devices that are attached to them.
+//   - Security devices, such as the virtual TPM, come last. They depend on
+//     the firmware and secure boot settings of the virtual machine, which are
+//     sent in the same reconfigure.
+//
+// The order within each group is kept.
+func OrderDeviceChangeSpec(spec []types.BaseVirtualDeviceConfigSpec) []types.BaseVirtualDeviceConfigSpec {
+	result := make([]types.BaseVirtualDeviceConfigSpec, len(spec))
+	copy(result, spec)
+	sort.SliceStable(result, func(i, j int) bool {
+		return deviceChangeOrder(result[i]) < deviceChangeOrder(result[j])
+	})
+	return result
+}
+
+// deviceChangeOrder returns the group of a device change operation for
+// OrderDeviceChangeSpec.
+func deviceChangeOrder(spec types.BaseVirtualDeviceConfigSpec) int {
+	s := spec.GetVirtualDeviceConfigSpec()
+	switch {
+	case s.Operation == types.VirtualDeviceConfigSpecOperationRemove:
+		return 0
+	case isSecurityDevice(s.Device):
+		return 2
+	}
+	return 1
+}
+
+// isSecurityDevice returns true if device is a security device that depends on
+// the firmware settings of the virtual machine.
+func isSecurityDevice(device types.BaseVirtualDevice) bool {
+	_, ok := device.(*types.VirtualTPM)
+	return ok
+}
+
 // getHostPciDevice returns a HostPciDevice from a host based on the DeviceId.
 func (c *pciApplyConfig) getHostPciDevice(id string) (*types.HostPciDevice, error) {
 	host, err := hostsystem.FromID(c.Client, c.ResourceData.Get("host_system_id").(string))
@@ -0,0 +1,171 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// testDeviceSubresourceSchema returns a resource schema with a list of the
+// device subresource at key, limited to maxItems if not 0, and the
+// reboot_required attribute set by the subresource.
+func testDeviceSubresourceSchema(key string, subresource map[string]*schema.Schema, maxItems int) map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		key: {
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: maxItems,
+			Elem:     &schema.Resource{Schema: subresource},
+		},
+		"reboot_required": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+	}
+}
+
+// testDeviceResourceData returns the ResourceData of a resource with the
+// device subresource list at key set to config.
+func testDeviceResourceData(t *testing.T, key string, subresource map[string]*schema.Schema, maxItems int, config []interface{}) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, testDeviceSubresourceSchema(key, subresource, maxItems), map[string]interface{}{key: config})
+}
+
+// testSingleDevice describes a device subresource that manages at most one
+// device, such as the watchdog timer, for the shared apply and refresh tests.
+type testSingleDevice struct {
+	// key is the attribute of the subresource list.
+	key string
+	// subresource is the schema of a subresource block.
+	subresource map[string]*schema.Schema
+	// attribute is the attribute of the block that holds the setting of the
+	// device.
+	attribute string
+	// setting returns the setting of the device in l, or false if there is no
+	// device.
+	setting func(l object.VirtualDeviceList) (interface{}, bool)
+	apply   func(d *schema.ResourceData, l object.VirtualDeviceList) (object.VirtualDeviceList, []types.BaseVirtualDeviceConfigSpec, error)
+	refresh func(d *schema.ResourceData, l object.VirtualDeviceList) error
+}
+
+// testSingleDeviceApplyCase is a case of the apply operation of a
+// testSingleDevice.
+type testSingleDeviceApplyCase struct {
+	name       string
+	config     []interface{}
+	devices    object.VirtualDeviceList
+	expectedOp types.VirtualDeviceConfigSpecOperation
+	// expected is the setting of the device after the apply, or nil if no
+	// device is expected.
+	expected interface{}
+}
+
+func testSingleDeviceApplyOperation(t *testing.T, r testSingleDevice, cases []testSingleDeviceApplyCase) {
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := testDeviceResourceData(t, r.key, r.subresource, 1, tc.config)
+			l, specs, err := r.apply(d, tc.devices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectedOp == "" {
+				if len(specs) != 0 {
+					t.Fatalf("expected no operations, got %s", DeviceChangeString(specs))
+				}
+			} else {
+				if len(specs) != 1 {
+					t.Fatalf("expected one %s operation, got %s", tc.expectedOp, DeviceChangeString(specs))
+				}
+				if op := specs[0].GetVirtualDeviceConfigSpec().Operation; op != tc.expectedOp {
+					t.Fatalf("expected operation %s, got %s", tc.expectedOp, op)
+				}
+			}
+			if expected := len(specs) > 0; d.Get("reboot_required").(bool) != expected {
+				t.Fatalf("expected reboot_required to be %t", expected)
+			}
+			actual, ok := r.setting(l)
+			switch {
+			case tc.expected == nil && ok:
+				t.Fatalf("expected no %s device, got one with %s %v", r.key, r.attribute, actual)
+			case tc.expected != nil && !ok:
+				t.Fatalf("expected a %s device with %s %v, got none", r.key, r.attribute, tc.expected)
+			case tc.expected != nil && actual != tc.expected:
+				t.Fatalf("expected %s %v, got %v", r.attribute, tc.expected, actual)
+			}
+		})
+	}
+}
+
+// testSingleDeviceRefreshOperation checks that the refresh operation of r
+// reads devices, which must hold a device with the supplied setting, and
+// reads no block without a device.
+func testSingleDeviceRefreshOperation(t *testing.T, r testSingleDevice, devices object.VirtualDeviceList, expected interface{}) {
+	d := testDeviceResourceData(t, r.key, r.subresource, 1, nil)
+	if err := r.refresh(d, devices); err != nil {
+		t.Fatal(err)
+	}
+	actual := d.Get(r.key).([]interface{})
+	if len(actual) != 1 {
+		t.Fatalf("expected one %s block, got %d", r.key, len(actual))
+	}
+	if v := actual[0].(map[string]interface{})[r.attribute]; v != expected {
+		t.Fatalf("expected %s to be %v, got %v", r.attribute, expected, v)
+	}
+
+	if err := r.refresh(d, object.VirtualDeviceList{}); err != nil {
+		t.Fatal(err)
+	}
+	if actual := d.Get(r.key).([]interface{}); len(actual) != 0 {
+		t.Fatalf("expected no %s block, got %d", r.key, len(actual))
+	}
+}
+
+func TestOrderDeviceChangeSpec(t *testing.T) {
+	device := func(op types.VirtualDeviceConfigSpecOperation, device types.BaseVirtualDevice) types.BaseVirtualDeviceConfigSpec {
+		return &types.VirtualDeviceConfigSpec{
+			Operation: op,
+			Device:    device,
+		}
+	}
+	add := types.VirtualDeviceConfigSpecOperationAdd
+	edit := types.VirtualDeviceConfigSpecOperationEdit
+	remove := types.VirtualDeviceConfigSpecOperationRemove
+
+	ctlr := &types.VirtualNVDIMMController{VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{Key: -1}}}
+	nvdimm := &types.VirtualNVDIMM{VirtualDevice: types.VirtualDevice{Key: -2, ControllerKey: -1}}
+	tpm := &types.VirtualTPM{VirtualDevice: types.VirtualDevice{Key: -3}}
+	nic := &types.VirtualVmxnet3{}
+	disk := &types.VirtualDisk{VirtualDevice: types.VirtualDevice{Key: 2000}}
+	cdrom := &types.VirtualCdrom{VirtualDevice: types.VirtualDevice{Key: 3000}}
+
+	spec := []types.BaseVirtualDeviceConfigSpec{
+		device(edit, disk),
+		device(add, tpm),
+		device(remove, nic),
+		device(add, ctlr),
+		device(add, nvdimm),
+		device(remove, cdrom),
+	}
+	expected := []types.BaseVirtualDeviceConfigSpec{
+		device(remove, nic),
+		device(remove, cdrom),
+		device(edit, disk),
+		device(add, ctlr),
+		device(add, nvdimm),
+		device(add, tpm),
+	}
+
+	actual := OrderDeviceChangeSpec(spec)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %s, got %s", DeviceChangeString(expected), DeviceChangeString(actual))
+	}
+	if spec[0].GetVirtualDeviceConfigSpec().Device != disk {
+		t.Fatalf("expected the supplied spec to be left unchanged")
+	}
+}
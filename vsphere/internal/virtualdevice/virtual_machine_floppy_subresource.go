@@ -0,0 +1,246 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/datastore"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
+)
+
+// FloppySubresourceSchema represents the schema for the floppy sub-resource.
+func FloppySubresourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		// VirtualFloppyImageBackingInfo
+		"datastore_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The datastore ID the floppy image is located on.",
+		},
+		"path": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The path to the floppy image file on the datastore.",
+		},
+		// VirtualFloppyRemoteDeviceBackingInfo
+		"client_device": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Description: "Indicates whether the device should be mapped to a remote client device.",
+		},
+	}
+}
+
+// FloppyApplyOperation checks for changes in the floppy drives of a virtual
+// machine and creates config specs to apply to the virtual machine.
+//
+// Floppy drives are matched to the entries of floppy in the order of their
+// device keys. Drives are added or removed when the number of entries
+// changes, which flags a reboot, as floppy drives cannot be added or removed
+// while the virtual machine is powered on. Inserting or ejecting a floppy
+// image is applied in place. Floppy drives are only managed when floppy is
+// configured, so the drives of a virtual machine or template without floppy
+// entries are left alone.
+func FloppyApplyOperation(d *schema.ResourceData, c *govmomi.Client, l object.VirtualDeviceList) (object.VirtualDeviceList, []types.BaseVirtualDeviceConfigSpec, error) {
+	log.Printf("[DEBUG] FloppyApplyOperation: Beginning apply operation")
+	config := d.Get("floppy").([]interface{})
+	if len(config) == 0 {
+		log.Printf("[DEBUG] FloppyApplyOperation: No floppy drives configured, leaving existing drives as they are")
+		return l, nil, nil
+	}
+	devices := selectFloppies(l)
+
+	var specs []types.BaseVirtualDeviceConfigSpec
+	for i, v := range config {
+		m := expandFloppyConfig(v)
+		if err := validateFloppyBacking(m); err != nil {
+			return nil, nil, fmt.Errorf("floppy.%d: %s", i, err)
+		}
+		var device *types.VirtualFloppy
+		op := types.VirtualDeviceConfigSpecOperationEdit
+		if i < len(devices) {
+			device = devices[i]
+			if reflect.DeepEqual(flattenFloppy(device), m) {
+				continue
+			}
+		} else {
+			var err error
+			if device, err = l.CreateFloppy(); err != nil {
+				return nil, nil, fmt.Errorf("floppy.%d: %s", i, err)
+			}
+			op = types.VirtualDeviceConfigSpecOperationAdd
+			_ = d.Set("reboot_required", true)
+		}
+		if err := mapFloppy(c, l, device, m); err != nil {
+			return nil, nil, fmt.Errorf("floppy.%d: %s", i, err)
+		}
+		log.Printf("[DEBUG] FloppyApplyOperation: %s floppy drive with key %d", op, device.Key)
+		spec := []types.BaseVirtualDeviceConfigSpec{
+			&types.VirtualDeviceConfigSpec{
+				Operation: op,
+				Device:    device,
+			},
+		}
+		l = applyDeviceChange(l, spec)
+		specs = append(specs, spec...)
+	}
+	for i := len(config); i < len(devices); i++ {
+		log.Printf("[DEBUG] FloppyApplyOperation: Removing floppy drive with key %d", devices[i].Key)
+		spec := []types.BaseVirtualDeviceConfigSpec{
+			&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationRemove,
+				Device:    devices[i],
+			},
+		}
+		l = applyDeviceChange(l, spec)
+		specs = append(specs, spec...)
+		_ = d.Set("reboot_required", true)
+	}
+
+	log.Printf("[DEBUG] FloppyApplyOperation: Apply complete, returning updated spec: %s", DeviceChangeString(specs))
+	return l, specs, nil
+}
+
+// FloppyDiffOperation checks that at most one backing is configured in each
+// floppy entry. Entries that depend on values that are not known yet are
+// checked when they are applied.
+func FloppyDiffOperation(d *schema.ResourceDiff) error {
+	log.Printf("[DEBUG] FloppyDiffOperation: Beginning diff validation")
+	for i, v := range d.Get("floppy").([]interface{}) {
+		keys := []string{"datastore_id", "path", "client_device"}
+		if !structure.ValuesAvailable(fmt.Sprintf("floppy.%d.", i), keys, d) {
+			log.Printf("[DEBUG] FloppyDiffOperation: Skipping floppy.%d, values not known yet", i)
+			continue
+		}
+		if err := validateFloppyBacking(expandFloppyConfig(v)); err != nil {
+			return fmt.Errorf("floppy.%d: %s", i, err)
+		}
+	}
+	log.Printf("[DEBUG] FloppyDiffOperation: Diff validation complete")
+	return nil
+}
+
+// FloppyRefreshOperation reads the floppy drives of a virtual machine into
+// floppy, in the order of their device keys.
+func FloppyRefreshOperation(d *schema.ResourceData, l object.VirtualDeviceList) error {
+	log.Printf("[DEBUG] FloppyRefreshOperation: Beginning refresh")
+	devices := selectFloppies(l)
+	floppies := make([]interface{}, len(devices))
+	for i, device := range devices {
+		floppies[i] = flattenFloppy(device)
+	}
+	log.Printf("[DEBUG] FloppyRefreshOperation: Refresh complete, %d floppy drives found", len(floppies))
+	return d.Set("floppy", floppies)
+}
+
+// selectFloppies returns the floppy drives in l, sorted by device key.
+func selectFloppies(l object.VirtualDeviceList) []*types.VirtualFloppy {
+	var devices []*types.VirtualFloppy
+	for _, device := range l.SelectByType((*types.VirtualFloppy)(nil)) {
+		devices = append(devices, device.(*types.VirtualFloppy))
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Key < devices[j].Key })
+	return devices
+}
+
+// expandFloppyConfig returns a floppy entry with all attributes set. An entry
+// without any attributes set is read as nil and describes an empty drive.
+func expandFloppyConfig(v interface{}) map[string]interface{} {
+	m := map[string]interface{}{
+		"datastore_id":  "",
+		"path":          "",
+		"client_device": false,
+	}
+	if config, ok := v.(map[string]interface{}); ok {
+		for k := range m {
+			if config[k] != nil {
+				m[k] = config[k]
+			}
+		}
+	}
+	return m
+}
+
+// validateFloppyBacking checks that a floppy entry has at most one backing
+// configured.
+func validateFloppyBacking(m map[string]interface{}) error {
+	path := m["path"].(string)
+	switch {
+	case path != "" && m["client_device"].(bool):
+		return fmt.Errorf("only one of path or client_device can be set")
+	case path != "" && m["datastore_id"].(string) == "":
+		return fmt.Errorf("datastore_id must be set with path")
+	}
+	return nil
+}
+
+// mapFloppy sets the backing of a floppy drive to a floppy image on a
+// datastore or a remote client device. A drive without either is left empty
+// and disconnected.
+func mapFloppy(c *govmomi.Client, l object.VirtualDeviceList, device *types.VirtualFloppy, m map[string]interface{}) error {
+	connected := true
+	switch {
+	case m["path"].(string) != "":
+		ds, err := datastore.FromID(c, m["datastore_id"].(string))
+		if err != nil {
+			return fmt.Errorf("cannot find datastore: %s", err)
+		}
+		dsProps, err := datastore.Properties(ds)
+		if err != nil {
+			return fmt.Errorf("could not get properties for datastore: %s", err)
+		}
+		dsPath := &object.DatastorePath{
+			Datastore: dsProps.Name,
+			Path:      m["path"].(string),
+		}
+		l.InsertImg(device, dsPath.String())
+		dsRef := ds.Reference()
+		device.Backing.(*types.VirtualFloppyImageBackingInfo).Datastore = &dsRef
+	case m["client_device"].(bool):
+		device.Backing = &types.VirtualFloppyRemoteDeviceBackingInfo{}
+	default:
+		l.EjectImg(device)
+		connected = false
+	}
+	device.Connectable = &types.VirtualDeviceConnectInfo{
+		AllowGuestControl: true,
+		Connected:         connected,
+		StartConnected:    connected,
+	}
+	return nil
+}
+
+// flattenFloppy returns a floppy drive in the form of a floppy entry. Drives
+// that are disconnected, or backed by a floppy drive of the host, are read as
+// empty.
+func flattenFloppy(device *types.VirtualFloppy) map[string]interface{} {
+	m := expandFloppyConfig(nil)
+	if device.Connectable != nil && !device.Connectable.StartConnected {
+		return m
+	}
+	switch backing := device.Backing.(type) {
+	case *types.VirtualFloppyImageBackingInfo:
+		dp := &object.DatastorePath{}
+		if dp.FromString(backing.FileName) {
+			m["path"] = dp.Path
+		}
+		if backing.Datastore != nil {
+			m["datastore_id"] = backing.Datastore.Value
+		}
+	case *types.VirtualFloppyRemoteDeviceBackingInfo:
+		m["client_device"] = true
+	default:
+		log.Printf("[DEBUG] flattenFloppy: Floppy backing %T is read as an empty drive", backing)
+	}
+	return m
+}
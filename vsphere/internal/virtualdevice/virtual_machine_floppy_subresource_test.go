@@ -0,0 +1,160 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func testFloppyResourceData(t *testing.T, config []interface{}) *schema.ResourceData {
+	return testDeviceResourceData(t, "floppy", FloppySubresourceSchema(), 2, config)
+}
+
+func testFloppyDeviceList(floppies ...*types.VirtualFloppy) object.VirtualDeviceList {
+	l := object.VirtualDeviceList{
+		&types.VirtualSIOController{
+			VirtualController: types.VirtualController{
+				VirtualDevice: types.VirtualDevice{Key: 400},
+			},
+		},
+	}
+	for i, floppy := range floppies {
+		floppy.Key = int32(8000 + i)
+		floppy.ControllerKey = 400
+		l = append(l, floppy)
+	}
+	return l
+}
+
+func testFloppy(backing types.BaseVirtualDeviceBackingInfo, connected bool) *types.VirtualFloppy {
+	return &types.VirtualFloppy{
+		VirtualDevice: types.VirtualDevice{
+			Backing: backing,
+			Connectable: &types.VirtualDeviceConnectInfo{
+				Connected:      connected,
+				StartConnected: connected,
+			},
+		},
+	}
+}
+
+func TestFloppyApplyOperation(t *testing.T) {
+	cases := []struct {
+		name           string
+		config         []interface{}
+		devices        object.VirtualDeviceList
+		expectedOp     types.VirtualDeviceConfigSpecOperation
+		expectedReboot bool
+		expectedLen    int
+	}{
+		{
+			name:           "add empty drive",
+			config:         []interface{}{map[string]interface{}{}},
+			devices:        testFloppyDeviceList(),
+			expectedOp:     types.VirtualDeviceConfigSpecOperationAdd,
+			expectedReboot: true,
+			expectedLen:    1,
+		},
+		{
+			name:        "connect client device",
+			config:      []interface{}{map[string]interface{}{"client_device": true}},
+			devices:     testFloppyDeviceList(testFloppy(&types.VirtualFloppyDeviceBackingInfo{}, false)),
+			expectedOp:  types.VirtualDeviceConfigSpecOperationEdit,
+			expectedLen: 1,
+		},
+		{
+			name:   "eject image",
+			config: []interface{}{map[string]interface{}{}},
+			devices: testFloppyDeviceList(testFloppy(&types.VirtualFloppyImageBackingInfo{
+				VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{FileName: "[datastore1] boot.flp"},
+			}, true)),
+			expectedOp:  types.VirtualDeviceConfigSpecOperationEdit,
+			expectedLen: 1,
+		},
+		{
+			name:        "unchanged",
+			config:      []interface{}{map[string]interface{}{"client_device": true}},
+			devices:     testFloppyDeviceList(testFloppy(&types.VirtualFloppyRemoteDeviceBackingInfo{}, true)),
+			expectedLen: 1,
+		},
+		{
+			name:   "remove surplus",
+			config: []interface{}{map[string]interface{}{"client_device": true}},
+			devices: testFloppyDeviceList(
+				testFloppy(&types.VirtualFloppyRemoteDeviceBackingInfo{}, true),
+				testFloppy(&types.VirtualFloppyRemoteDeviceBackingInfo{}, true),
+			),
+			expectedOp:     types.VirtualDeviceConfigSpecOperationRemove,
+			expectedReboot: true,
+			expectedLen:    1,
+		},
+		{
+			name:        "not configured",
+			devices:     testFloppyDeviceList(testFloppy(&types.VirtualFloppyRemoteDeviceBackingInfo{}, true)),
+			expectedLen: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := testFloppyResourceData(t, tc.config)
+			l, specs, err := FloppyApplyOperation(d, nil, tc.devices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectedOp == "" {
+				if len(specs) != 0 {
+					t.Fatalf("expected no operations, got %s", DeviceChangeString(specs))
+				}
+			} else {
+				if len(specs) != 1 {
+					t.Fatalf("expected one %s operation, got %s", tc.expectedOp, DeviceChangeString(specs))
+				}
+				if op := specs[0].GetVirtualDeviceConfigSpec().Operation; op != tc.expectedOp {
+					t.Fatalf("expected operation %s, got %s", tc.expectedOp, op)
+				}
+			}
+			if d.Get("reboot_required").(bool) != tc.expectedReboot {
+				t.Fatalf("expected reboot_required to be %t", tc.expectedReboot)
+			}
+			if actual := len(selectFloppies(l)); actual != tc.expectedLen {
+				t.Fatalf("expected %d floppy drives in the device list, got %d", tc.expectedLen, actual)
+			}
+		})
+	}
+}
+
+func TestFloppyRefreshOperation(t *testing.T) {
+	d := testFloppyResourceData(t, nil)
+	devices := testFloppyDeviceList(
+		testFloppy(&types.VirtualFloppyImageBackingInfo{
+			VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+				FileName:  "[datastore1] images/boot.flp",
+				Datastore: &types.ManagedObjectReference{Type: "Datastore", Value: "datastore-1"},
+			},
+		}, true),
+		testFloppy(&types.VirtualFloppyImageBackingInfo{
+			VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{FileName: "[datastore1] images/old.flp"},
+		}, false),
+	)
+	if err := FloppyRefreshOperation(d, devices); err != nil {
+		t.Fatal(err)
+	}
+	actual := d.Get("floppy").([]interface{})
+	if len(actual) != 2 {
+		t.Fatalf("expected two floppy drives, got %d", len(actual))
+	}
+	image := actual[0].(map[string]interface{})
+	if image["datastore_id"] != "datastore-1" || image["path"] != "images/boot.flp" {
+		t.Fatalf("unexpected floppy drive with image: %#v", image)
+	}
+	if empty := actual[1].(map[string]interface{}); empty["path"] != "" || empty["client_device"] != false {
+		t.Fatalf("expected a disconnected floppy drive to be read as empty, got %#v", empty)
+	}
+}
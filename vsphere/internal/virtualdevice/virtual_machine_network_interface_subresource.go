@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/mitchellh/copystructure"
@@ -122,6 +123,18 @@ func NetworkInterfaceSubresourceSchema() map[string]*schema.Schema {
 			ForceNew:    true,
 			Description: "Mapping of network interface to OVF network.",
 		},
+		"wake_on_lan": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Enable wake-on-LAN for this network interface, allowing network activity to wake the virtual machine from standby.",
+		},
+		"start_connected": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Connect this network interface when the virtual machine powers on.",
+		},
 	}
 	structure.MergeSchema(s, subresourceSchema())
 	return s
@@ -831,6 +844,7 @@ func (r *NetworkInterfaceSubresource) Create(l object.VirtualDeviceList) ([]type
 		card.AddressType = string(types.VirtualEthernetCardMacTypeManual)
 		card.MacAddress = r.Get("mac_address").(string)
 	}
+	expandNetworkInterfaceBootOptions(card, r.configuredBool("wake_on_lan"), r.configuredBool("start_connected"))
 
 	if r.Get("adapter_type") != networkInterfaceSubresourceTypeSriov {
 		bandwidthLimit := structure.Int64Ptr(-1)
@@ -940,6 +954,9 @@ func (r *NetworkInterfaceSubresource) Read(l object.VirtualDeviceList) error {
 	r.Set("network_id", netID)
 	r.Set("use_static_mac", card.AddressType == string(types.VirtualEthernetCardMacTypeManual))
 	r.Set("mac_address", card.MacAddress)
+	wakeOnLan, startConnected := flattenNetworkInterfaceBootOptions(card)
+	r.Set("wake_on_lan", wakeOnLan)
+	r.Set("start_connected", startConnected)
 
 	if r.Get("adapter_type") != networkInterfaceSubresourceTypeSriov {
 		if card.ResourceAllocation != nil {
@@ -1069,6 +1086,7 @@ func (r *NetworkInterfaceSubresource) Update(l object.VirtualDeviceList) ([]type
 		}
 		card.MacAddress = ""
 	}
+	expandNetworkInterfaceBootOptions(card, r.configuredBool("wake_on_lan"), r.configuredBool("start_connected"))
 
 	if r.Get("adapter_type") != networkInterfaceSubresourceTypeSriov {
 		bandwidthLimit := structure.Int64Ptr(-1)
@@ -1114,6 +1132,55 @@ func (r *NetworkInterfaceSubresource) Update(l object.VirtualDeviceList) ([]type
 	return spec, nil
 }
 
+// configuredBool returns the boolean setting key of the network interface, or
+// nil if it is not set in the configuration, in which case the setting of the
+// card is left as is.
+func (r *NetworkInterfaceSubresource) configuredBool(key string) *bool {
+	if rc, ok := r.rdd.(interface{ GetRawConfig() cty.Value }); ok && !networkInterfaceRawConfigIsSet(rc.GetRawConfig(), r.Index, key) {
+		return nil
+	}
+	return structure.BoolPtr(r.Get(key).(bool))
+}
+
+// networkInterfaceRawConfigIsSet returns true if key is set in the raw
+// configuration of the network interface at index.
+func networkInterfaceRawConfigIsSet(raw cty.Value, index int, key string) bool {
+	if !raw.IsKnown() || raw.IsNull() {
+		return false
+	}
+	nics := raw.GetAttr(subresourceTypeNetworkInterface)
+	if !nics.IsKnown() || nics.IsNull() || nics.LengthInt() <= index {
+		return false
+	}
+	nic := nics.Index(cty.NumberIntVal(int64(index)))
+	if !nic.IsKnown() || nic.IsNull() {
+		return false
+	}
+	return !nic.GetAttr(key).IsNull()
+}
+
+// expandNetworkInterfaceBootOptions sets wake-on-LAN and whether the card is
+// connected when the virtual machine powers on. A nil wakeOnLan or
+// startConnected leaves the respective setting of the card unchanged. The
+// current connection state of the card is not changed.
+func expandNetworkInterfaceBootOptions(card *types.VirtualEthernetCard, wakeOnLan *bool, startConnected *bool) {
+	if wakeOnLan != nil {
+		card.WakeOnLanEnabled = wakeOnLan
+	}
+	if startConnected != nil && card.Connectable != nil {
+		card.Connectable.StartConnected = *startConnected
+	}
+}
+
+// flattenNetworkInterfaceBootOptions returns the wake-on-LAN and
+// connect-at-power-on settings of the card. A card without connection info is
+// reported as starting connected, which is the default.
+func flattenNetworkInterfaceBootOptions(card *types.VirtualEthernetCard) (bool, bool) {
+	wakeOnLan := card.WakeOnLanEnabled != nil && *card.WakeOnLanEnabled
+	startConnected := card.Connectable == nil || card.Connectable.StartConnected
+	return wakeOnLan, startConnected
+}
+
 // Add SRIOV physical function setting the device to a VirtualSriovEthernetCard
 // and by adding VirtualSriovEthernetCardSriovBackingInfo
 func (r *NetworkInterfaceSubresource) addPhysicalFunction(device types.BaseVirtualDevice) (types.BaseVirtualDevice, error) {
@@ -1210,6 +1277,11 @@ func (r *NetworkInterfaceSubresource) ValidateDiff() error {
 		return err
 	}
 
+	// SR-IOV passthrough adapters cannot wake the virtual machine
+	if r.Get("adapter_type").(string) == networkInterfaceSubresourceTypeSriov && r.Get("wake_on_lan").(bool) {
+		return fmt.Errorf("wake_on_lan is not supported on SR-IOV network interfaces")
+	}
+
 	log.Printf("[DEBUG] %s: Diff validation complete", r)
 	return nil
 }
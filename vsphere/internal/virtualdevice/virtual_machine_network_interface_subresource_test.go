@@ -0,0 +1,233 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
+)
+
+func testNetworkInterfaceDeviceList() object.VirtualDeviceList {
+	unit := int32(7)
+	return object.VirtualDeviceList{
+		&types.VirtualPCIController{
+			VirtualController: types.VirtualController{
+				VirtualDevice: types.VirtualDevice{Key: 100},
+				BusNumber:     0,
+				Device:        []int32{4000},
+			},
+		},
+		&types.VirtualVmxnet3{
+			VirtualVmxnet: types.VirtualVmxnet{
+				VirtualEthernetCard: types.VirtualEthernetCard{
+					VirtualDevice: types.VirtualDevice{
+						Key:           4000,
+						ControllerKey: 100,
+						UnitNumber:    &unit,
+						Backing: &types.VirtualEthernetCardNetworkBackingInfo{
+							Network: &types.ManagedObjectReference{
+								Type:  "Network",
+								Value: "network-1",
+							},
+						},
+						Connectable: &types.VirtualDeviceConnectInfo{
+							StartConnected: true,
+							Connected:      true,
+						},
+					},
+					AddressType: string(types.VirtualEthernetCardMacTypeManual),
+					MacAddress:  "00:50:56:00:00:01",
+				},
+			},
+		},
+	}
+}
+
+func testNetworkInterfaceConfig(wakeOnLan, startConnected bool) map[string]interface{} {
+	return map[string]interface{}{
+		"key":                   4000,
+		"device_address":        "pci:0:7",
+		"network_id":            "network-1",
+		"adapter_type":          networkInterfaceSubresourceTypeVmxnet3,
+		"use_static_mac":        true,
+		"mac_address":           "00:50:56:00:00:01",
+		"bandwidth_limit":       defaultBandwidthLimit,
+		"bandwidth_reservation": defaultBandwidthReservation,
+		"bandwidth_share_level": defaultBandwidthShareLevel,
+		"bandwidth_share_count": 50,
+		"wake_on_lan":           wakeOnLan,
+		"start_connected":       startConnected,
+	}
+}
+
+func TestNetworkInterfaceBootOptions(t *testing.T) {
+	cases := []struct {
+		name           string
+		wakeOnLan      bool
+		startConnected bool
+	}{
+		{
+			name:           "defaults",
+			wakeOnLan:      false,
+			startConnected: true,
+		},
+		{
+			name:           "wake on lan",
+			wakeOnLan:      true,
+			startConnected: true,
+		},
+		{
+			name:           "not connected at power on",
+			wakeOnLan:      false,
+			startConnected: false,
+		},
+		{
+			name:           "wake on lan and not connected at power on",
+			wakeOnLan:      true,
+			startConnected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := testNetworkInterfaceDeviceList()
+			r := NewNetworkInterfaceSubresource(nil, nil, testNetworkInterfaceConfig(tc.wakeOnLan, tc.startConnected), nil, 0)
+			spec, err := r.Update(l)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(spec) != 1 {
+				t.Fatalf("expected 1 device change, got %d", len(spec))
+			}
+			card := spec[0].GetVirtualDeviceConfigSpec().Device.(types.BaseVirtualEthernetCard).GetVirtualEthernetCard()
+			if card.WakeOnLanEnabled == nil || *card.WakeOnLanEnabled != tc.wakeOnLan {
+				t.Fatalf("expected wake-on-LAN to be %t, got %v", tc.wakeOnLan, card.WakeOnLanEnabled)
+			}
+			if card.Connectable.StartConnected != tc.startConnected {
+				t.Fatalf("expected start connected to be %t, got %t", tc.startConnected, card.Connectable.StartConnected)
+			}
+			if !card.Connectable.Connected {
+				t.Fatalf("expected network interface to stay connected")
+			}
+			l = applyDeviceChange(l, spec)
+
+			r = NewNetworkInterfaceSubresource(nil, nil, testNetworkInterfaceConfig(!tc.wakeOnLan, !tc.startConnected), nil, 0)
+			if err := r.Read(l); err != nil {
+				t.Fatal(err)
+			}
+			if actual := r.Get("wake_on_lan").(bool); actual != tc.wakeOnLan {
+				t.Fatalf("expected wake_on_lan to be %t, got %t", tc.wakeOnLan, actual)
+			}
+			if actual := r.Get("start_connected").(bool); actual != tc.startConnected {
+				t.Fatalf("expected start_connected to be %t, got %t", tc.startConnected, actual)
+			}
+		})
+	}
+}
+
+func TestExpandNetworkInterfaceBootOptionsNotConfigured(t *testing.T) {
+	card := &types.VirtualEthernetCard{
+		VirtualDevice: types.VirtualDevice{
+			Connectable: &types.VirtualDeviceConnectInfo{
+				StartConnected: false,
+				Connected:      true,
+			},
+		},
+		WakeOnLanEnabled: structure.BoolPtr(true),
+	}
+	expandNetworkInterfaceBootOptions(card, nil, nil)
+	if card.WakeOnLanEnabled == nil || !*card.WakeOnLanEnabled {
+		t.Fatalf("expected wake-on-LAN to be left enabled, got %v", card.WakeOnLanEnabled)
+	}
+	if card.Connectable.StartConnected {
+		t.Fatalf("expected start connected to be left disabled")
+	}
+}
+
+func TestNetworkInterfaceValidateDiffWakeOnLan(t *testing.T) {
+	cases := []struct {
+		name        string
+		adapterType string
+		expectError bool
+	}{
+		{
+			name:        "vmxnet3",
+			adapterType: networkInterfaceSubresourceTypeVmxnet3,
+			expectError: false,
+		},
+		{
+			name:        "sriov",
+			adapterType: networkInterfaceSubresourceTypeSriov,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := testNetworkInterfaceConfig(true, true)
+			data["adapter_type"] = tc.adapterType
+			if tc.adapterType == networkInterfaceSubresourceTypeSriov {
+				data["physical_function"] = "0000:d8:00.0"
+			} else {
+				data["physical_function"] = ""
+			}
+			r := NewNetworkInterfaceSubresource(nil, nil, data, nil, 0)
+			err := r.ValidateDiff()
+			if tc.expectError != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestNetworkInterfaceRawConfigIsSet(t *testing.T) {
+	nic := func(wakeOnLan cty.Value) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			subresourceTypeNetworkInterface: cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{"wake_on_lan": wakeOnLan}),
+			}),
+		})
+	}
+	cases := []struct {
+		name     string
+		raw      cty.Value
+		index    int
+		expected bool
+	}{
+		{
+			name:     "set",
+			raw:      nic(cty.False),
+			expected: true,
+		},
+		{
+			name:     "unset",
+			raw:      nic(cty.NullVal(cty.Bool)),
+			expected: false,
+		},
+		{
+			name:     "out of range",
+			raw:      nic(cty.True),
+			index:    1,
+			expected: false,
+		},
+		{
+			name:     "no configuration",
+			raw:      cty.NullVal(cty.DynamicPseudoType),
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := networkInterfaceRawConfigIsSet(tc.raw, tc.index, "wake_on_lan"); actual != tc.expected {
+				t.Fatalf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
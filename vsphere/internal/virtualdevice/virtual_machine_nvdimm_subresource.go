@@ -0,0 +1,212 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// NvdimmSubresourceSchema represents the schema for the nvdimm sub-resource.
+func NvdimmSubresourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"size": {
+			Type:         schema.TypeInt,
+			Required:     true,
+			Description:  "The size of the NVDIMM device, in MB. The size can be increased, but not decreased.",
+			ValidateFunc: validation.IntAtLeast(1),
+		},
+		"storage_policy_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The ID of the PMem storage policy to assign to the NVDIMM device.",
+		},
+	}
+}
+
+// NvdimmApplyOperation checks for changes in the NVDIMM devices of a virtual
+// machine and creates config specs to apply to the virtual machine.
+//
+// NVDIMM devices are lined up with the nvdimm blocks in the order of their
+// device keys. Devices that grow are resized, devices past the end of the
+// configuration are removed, and new devices are added along with an NVDIMM
+// controller if the virtual machine does not have one yet. Devices whose
+// storage policy changes are edited with the new policy. Any change other than
+// the storage policy flags a reboot, as NVDIMM devices cannot be hot-added on
+// all versions. NVDIMM devices are only managed when nvdimm is configured, so
+// the devices of a virtual machine or template without nvdimm blocks, and the
+// data on them, are left alone.
+func NvdimmApplyOperation(d *schema.ResourceData, l object.VirtualDeviceList) (object.VirtualDeviceList, []types.BaseVirtualDeviceConfigSpec, error) {
+	log.Printf("[DEBUG] NvdimmApplyOperation: Beginning apply operation")
+	o, n := d.GetChange("nvdimm")
+	old := o.([]interface{})
+	config := n.([]interface{})
+	if len(config) == 0 {
+		log.Printf("[DEBUG] NvdimmApplyOperation: No NVDIMM devices configured, leaving existing devices as they are")
+		return l, nil, nil
+	}
+	devices := selectNvdimms(l)
+
+	var specs []types.BaseVirtualDeviceConfigSpec
+	var reboot bool
+	apply := func(spec types.BaseVirtualDeviceConfigSpec) {
+		specs = append(specs, spec)
+		l = applyDeviceChange(l, []types.BaseVirtualDeviceConfigSpec{spec})
+	}
+
+	for i, device := range devices {
+		if i >= len(config) {
+			log.Printf("[DEBUG] NvdimmApplyOperation: Removing NVDIMM device with key %d", device.Key)
+			apply(&types.VirtualDeviceConfigSpec{
+				Operation:     types.VirtualDeviceConfigSpecOperationRemove,
+				FileOperation: types.VirtualDeviceConfigSpecFileOperationDestroy,
+				Device:        device,
+			})
+			reboot = true
+			continue
+		}
+		m := config[i].(map[string]interface{})
+		size := int64(m["size"].(int))
+		current := nvdimmCapacity(device)
+		if size < current {
+			return nil, nil, fmt.Errorf("nvdimm.%d: cannot shrink NVDIMM device from %d MB to %d MB", i, current, size)
+		}
+		var oldPolicy string
+		if i < len(old) && old[i] != nil {
+			oldPolicy, _ = old[i].(map[string]interface{})["storage_policy_id"].(string)
+		}
+		policyChanged := m["storage_policy_id"].(string) != oldPolicy
+		if size == current && !policyChanged {
+			continue
+		}
+		profile := expandNvdimmProfile(m)
+		if policyChanged && profile == nil {
+			profile = []types.BaseVirtualMachineProfileSpec{&types.VirtualMachineEmptyProfileSpec{}}
+		}
+		if size > current {
+			log.Printf("[DEBUG] NvdimmApplyOperation: Resizing NVDIMM device with key %d to %d MB", device.Key, size)
+			device.CapacityInMB = size
+			reboot = true
+		} else {
+			log.Printf("[DEBUG] NvdimmApplyOperation: Changing storage policy of NVDIMM device with key %d", device.Key)
+		}
+		apply(&types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationEdit,
+			Device:    device,
+			Profile:   profile,
+		})
+	}
+
+	if len(config) > len(devices) {
+		reboot = true
+		var ctlr types.BaseVirtualController
+		if c := l.SelectByType((*types.VirtualNVDIMMController)(nil)); len(c) > 0 {
+			ctlr = c[0].(types.BaseVirtualController)
+		} else {
+			log.Printf("[DEBUG] NvdimmApplyOperation: Adding NVDIMM controller")
+			ctlr = &types.VirtualNVDIMMController{
+				VirtualController: types.VirtualController{
+					VirtualDevice: types.VirtualDevice{
+						Key: l.NewKey(),
+					},
+				},
+			}
+			apply(&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+				Device:    ctlr.(types.BaseVirtualDevice),
+			})
+		}
+		for _, v := range config[len(devices):] {
+			m := v.(map[string]interface{})
+			device := &types.VirtualNVDIMM{
+				VirtualDevice: types.VirtualDevice{
+					Key: l.NewKey(),
+					Backing: &types.VirtualNVDIMMBackingInfo{
+						VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+							FileName: "",
+						},
+					},
+				},
+				CapacityInMB: int64(m["size"].(int)),
+			}
+			l.AssignController(device, ctlr)
+			log.Printf("[DEBUG] NvdimmApplyOperation: Adding NVDIMM device of %d MB", device.CapacityInMB)
+			apply(&types.VirtualDeviceConfigSpec{
+				Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+				FileOperation: types.VirtualDeviceConfigSpecFileOperationCreate,
+				Device:        device,
+				Profile:       expandNvdimmProfile(m),
+			})
+		}
+	}
+
+	if reboot {
+		_ = d.Set("reboot_required", true)
+	}
+	log.Printf("[DEBUG] NvdimmApplyOperation: Apply complete, returning updated spec: %s", DeviceChangeString(specs))
+	return l, specs, nil
+}
+
+// NvdimmRefreshOperation reads the NVDIMM devices of a virtual machine into
+// the nvdimm blocks. The storage policy is not reported by the device and is
+// kept from the existing state.
+func NvdimmRefreshOperation(d *schema.ResourceData, l object.VirtualDeviceList) error {
+	log.Printf("[DEBUG] NvdimmRefreshOperation: Beginning refresh")
+	old := d.Get("nvdimm").([]interface{})
+	var config []interface{}
+	for i, device := range selectNvdimms(l) {
+		m := map[string]interface{}{
+			"size":              int(nvdimmCapacity(device)),
+			"storage_policy_id": "",
+		}
+		if i < len(old) && old[i] != nil {
+			m["storage_policy_id"] = old[i].(map[string]interface{})["storage_policy_id"]
+		}
+		config = append(config, m)
+	}
+	log.Printf("[DEBUG] NvdimmRefreshOperation: Refresh complete, %d NVDIMM devices found", len(config))
+	return d.Set("nvdimm", config)
+}
+
+// selectNvdimms returns the NVDIMM devices in l, sorted by device key.
+func selectNvdimms(l object.VirtualDeviceList) []*types.VirtualNVDIMM {
+	var devices []*types.VirtualNVDIMM
+	for _, device := range l.SelectByType((*types.VirtualNVDIMM)(nil)) {
+		devices = append(devices, device.(*types.VirtualNVDIMM))
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Key < devices[j].Key
+	})
+	return devices
+}
+
+// nvdimmCapacity returns the configured size of an NVDIMM device in MB. The
+// backing size is used when the configured size is not reported.
+func nvdimmCapacity(device *types.VirtualNVDIMM) int64 {
+	if device.ConfiguredCapacityInMB > 0 {
+		return device.ConfiguredCapacityInMB
+	}
+	return device.CapacityInMB
+}
+
+// expandNvdimmProfile returns the profile spec for the storage policy of an
+// nvdimm block, or nil if no storage policy is set.
+func expandNvdimmProfile(m map[string]interface{}) []types.BaseVirtualMachineProfileSpec {
+	id, _ := m["storage_policy_id"].(string)
+	if id == "" {
+		return nil
+	}
+	return []types.BaseVirtualMachineProfileSpec{
+		&types.VirtualMachineDefinedProfileSpec{
+			ProfileId: id,
+		},
+	}
+}
@@ -0,0 +1,269 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func testNvdimmResourceData(t *testing.T, sizes ...int) *schema.ResourceData {
+	var nvdimms []interface{}
+	for _, size := range sizes {
+		nvdimms = append(nvdimms, map[string]interface{}{
+			"size":              size,
+			"storage_policy_id": "c268da1b-b343-49f7-a468-b1deeb7078e0",
+		})
+	}
+	return testDeviceResourceData(t, "nvdimm", NvdimmSubresourceSchema(), 0, nvdimms)
+}
+
+func testNvdimmSchema() map[string]*schema.Schema {
+	return testDeviceSubresourceSchema("nvdimm", NvdimmSubresourceSchema(), 0)
+}
+
+// testNvdimmResourceDataChange returns the ResourceData of an update of a
+// single nvdimm block from oldPolicy to newPolicy.
+func testNvdimmResourceDataChange(t *testing.T, size int, oldPolicy, newPolicy string) *schema.ResourceData {
+	sm := schema.InternalMap(testNvdimmSchema())
+	nvdimm := func(policy string) map[string]interface{} {
+		return map[string]interface{}{
+			"nvdimm": []interface{}{
+				map[string]interface{}{
+					"size":              size,
+					"storage_policy_id": policy,
+				},
+			},
+		}
+	}
+	state := schema.TestResourceDataRaw(t, sm, nvdimm(oldPolicy))
+	state.SetId("vm-1")
+	diff, err := sm.Diff(context.Background(), state.State(), terraform.NewResourceConfigRaw(nvdimm(newPolicy)), nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := sm.Data(state.State(), diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func testNvdimmDeviceList(sizes ...int64) object.VirtualDeviceList {
+	l := object.VirtualDeviceList{
+		&types.VirtualNVDIMMController{
+			VirtualController: types.VirtualController{
+				VirtualDevice: types.VirtualDevice{Key: 27000},
+			},
+		},
+	}
+	for i, size := range sizes {
+		unit := int32(i)
+		l = append(l, &types.VirtualNVDIMM{
+			VirtualDevice: types.VirtualDevice{
+				Key:           32000 + int32(i),
+				ControllerKey: 27000,
+				UnitNumber:    &unit,
+			},
+			CapacityInMB: size,
+		})
+	}
+	return l
+}
+
+func TestNvdimmApplyOperation(t *testing.T) {
+	cases := []struct {
+		name        string
+		config      []int
+		devices     object.VirtualDeviceList
+		expectedOps []string
+		reboot      bool
+		expectError bool
+	}{
+		{
+			name:        "add with controller",
+			config:      []int{1024},
+			devices:     object.VirtualDeviceList{},
+			expectedOps: []string{"add:VirtualNVDIMMController", "add:VirtualNVDIMM"},
+			reboot:      true,
+		},
+		{
+			name:        "add to existing controller",
+			config:      []int{1024, 2048},
+			devices:     testNvdimmDeviceList(1024),
+			expectedOps: []string{"edit:VirtualNVDIMM", "add:VirtualNVDIMM"},
+			reboot:      true,
+		},
+		{
+			name:        "grow",
+			config:      []int{2048},
+			devices:     testNvdimmDeviceList(1024),
+			expectedOps: []string{"edit:VirtualNVDIMM"},
+			reboot:      true,
+		},
+		{
+			name:        "shrink",
+			config:      []int{512},
+			devices:     testNvdimmDeviceList(1024),
+			expectError: true,
+		},
+		{
+			name:        "remove",
+			config:      []int{1024},
+			devices:     testNvdimmDeviceList(1024, 2048),
+			expectedOps: []string{"edit:VirtualNVDIMM", "remove:VirtualNVDIMM"},
+			reboot:      true,
+		},
+		{
+			name:        "storage policy of existing device",
+			config:      []int{1024},
+			devices:     testNvdimmDeviceList(1024),
+			expectedOps: []string{"edit:VirtualNVDIMM"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := testNvdimmResourceData(t, tc.config...)
+			l, specs, err := NvdimmApplyOperation(d, tc.devices)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ops []string
+			for _, s := range specs {
+				spec := s.GetVirtualDeviceConfigSpec()
+				ops = append(ops, string(spec.Operation)+":"+reflect.TypeOf(spec.Device).Elem().Name())
+			}
+			if len(ops) != len(tc.expectedOps) {
+				t.Fatalf("expected operations %v, got %v", tc.expectedOps, ops)
+			}
+			for i := range ops {
+				if ops[i] != tc.expectedOps[i] {
+					t.Fatalf("expected operations %v, got %v", tc.expectedOps, ops)
+				}
+			}
+			if d.Get("reboot_required").(bool) != tc.reboot {
+				t.Fatalf("expected reboot_required to be %t", tc.reboot)
+			}
+			if actual := len(selectNvdimms(l)); actual != len(tc.config) {
+				t.Fatalf("expected %d NVDIMM devices in the device list, got %d", len(tc.config), actual)
+			}
+			for _, device := range selectNvdimms(l) {
+				if device.UnitNumber == nil {
+					t.Fatalf("expected NVDIMM device with key %d to have a unit number", device.Key)
+				}
+			}
+		})
+	}
+}
+
+func TestNvdimmApplyOperationNotConfigured(t *testing.T) {
+	d := testNvdimmResourceData(t)
+	l, specs, err := NvdimmApplyOperation(d, testNvdimmDeviceList(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 0 {
+		t.Fatalf("expected no operations, got %s", DeviceChangeString(specs))
+	}
+	if d.Get("reboot_required").(bool) {
+		t.Fatalf("expected reboot_required to be false")
+	}
+	if actual := len(selectNvdimms(l)); actual != 1 {
+		t.Fatalf("expected the NVDIMM device to be kept, got %d devices", actual)
+	}
+}
+
+func TestNvdimmApplyOperationStoragePolicy(t *testing.T) {
+	cases := []struct {
+		name      string
+		oldPolicy string
+		newPolicy string
+		expected  types.BaseVirtualMachineProfileSpec
+	}{
+		{
+			name:      "unchanged",
+			oldPolicy: "c268da1b-b343-49f7-a468-b1deeb7078e0",
+			newPolicy: "c268da1b-b343-49f7-a468-b1deeb7078e0",
+		},
+		{
+			name:      "changed",
+			oldPolicy: "c268da1b-b343-49f7-a468-b1deeb7078e0",
+			newPolicy: "4d5f673c-536f-11e6-beb8-9e71128cae77",
+			expected:  &types.VirtualMachineDefinedProfileSpec{ProfileId: "4d5f673c-536f-11e6-beb8-9e71128cae77"},
+		},
+		{
+			name:      "removed",
+			oldPolicy: "c268da1b-b343-49f7-a468-b1deeb7078e0",
+			newPolicy: "",
+			expected:  &types.VirtualMachineEmptyProfileSpec{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := testNvdimmResourceDataChange(t, 1024, tc.oldPolicy, tc.newPolicy)
+			_, specs, err := NvdimmApplyOperation(d, testNvdimmDeviceList(1024))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Get("reboot_required").(bool) {
+				t.Fatalf("expected a storage policy change not to require a reboot")
+			}
+			if tc.expected == nil {
+				if len(specs) != 0 {
+					t.Fatalf("expected no device changes, got %s", DeviceChangeString(specs))
+				}
+				return
+			}
+			if len(specs) != 1 {
+				t.Fatalf("expected 1 device change, got %s", DeviceChangeString(specs))
+			}
+			spec := specs[0].GetVirtualDeviceConfigSpec()
+			if spec.Operation != types.VirtualDeviceConfigSpecOperationEdit {
+				t.Fatalf("expected an edit, got %s", spec.Operation)
+			}
+			if len(spec.Profile) != 1 || !reflect.DeepEqual(spec.Profile[0], tc.expected) {
+				t.Fatalf("expected profile %#v, got %#v", tc.expected, spec.Profile)
+			}
+		})
+	}
+}
+
+func TestNvdimmRefreshOperation(t *testing.T) {
+	d := testNvdimmResourceData(t, 1024)
+	l := testNvdimmDeviceList(2048, 4096)
+	l[2].(*types.VirtualNVDIMM).ConfiguredCapacityInMB = 8192
+
+	if err := NvdimmRefreshOperation(d, l); err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{2048, 8192}
+	actual := d.Get("nvdimm").([]interface{})
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d NVDIMM devices, got %d", len(expected), len(actual))
+	}
+	for i, size := range expected {
+		m := actual[i].(map[string]interface{})
+		if m["size"].(int) != size {
+			t.Fatalf("expected nvdimm.%d size to be %d, got %d", i, size, m["size"].(int))
+		}
+	}
+	if policy := actual[0].(map[string]interface{})["storage_policy_id"].(string); policy == "" {
+		t.Fatalf("expected the storage policy of nvdimm.0 to be kept")
+	}
+}
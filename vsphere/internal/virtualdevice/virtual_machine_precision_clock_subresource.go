@@ -0,0 +1,138 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var precisionClockAllowedProtocols = []string{
+	string(types.HostDateTimeInfoProtocolPtp),
+	string(types.HostDateTimeInfoProtocolNtp),
+}
+
+// PrecisionClockSubresourceSchema represents the schema for the
+// precision_clock sub-resource.
+func PrecisionClockSubresourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"protocol": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      string(types.HostDateTimeInfoProtocolPtp),
+			Description:  "The time synchronization protocol of the host that the precision clock presents to the guest. One of ptp or ntp.",
+			ValidateFunc: validation.StringInSlice(precisionClockAllowedProtocols, false),
+		},
+	}
+}
+
+// PrecisionClockApplyOperation checks for changes in the precision clock of a
+// virtual machine and creates config specs to apply to the virtual machine.
+//
+// A virtual machine has at most one precision clock. The device is added when
+// a precision_clock block is configured and edited when the protocol changes.
+// Any change flags a reboot, as the precision clock cannot be changed while
+// the virtual machine is powered on. The precision clock is only managed when
+// precision_clock is configured, so the precision clock of a virtual machine
+// or template without a precision_clock block is left alone.
+func PrecisionClockApplyOperation(d *schema.ResourceData, l object.VirtualDeviceList) (object.VirtualDeviceList, []types.BaseVirtualDeviceConfigSpec, error) {
+	log.Printf("[DEBUG] PrecisionClockApplyOperation: Beginning apply operation")
+	config := d.Get("precision_clock").([]interface{})
+	if len(config) == 0 {
+		log.Printf("[DEBUG] PrecisionClockApplyOperation: No precision clock configured, leaving existing device as it is")
+		return l, nil, nil
+	}
+	device := selectPrecisionClock(l)
+
+	var spec *types.VirtualDeviceConfigSpec
+	switch {
+	case device == nil:
+		protocol := precisionClockConfigProtocol(config)
+		device = &types.VirtualPrecisionClock{
+			VirtualDevice: types.VirtualDevice{
+				Key: l.NewKey(),
+				Backing: &types.VirtualPrecisionClockSystemClockBackingInfo{
+					Protocol: protocol,
+				},
+			},
+		}
+		log.Printf("[DEBUG] PrecisionClockApplyOperation: Adding precision clock (protocol: %s)", protocol)
+		spec = &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+			Device:    device,
+		}
+	case precisionClockProtocol(device) != precisionClockConfigProtocol(config):
+		protocol := precisionClockConfigProtocol(config)
+		device.Backing = &types.VirtualPrecisionClockSystemClockBackingInfo{
+			Protocol: protocol,
+		}
+		log.Printf("[DEBUG] PrecisionClockApplyOperation: Setting protocol of precision clock with key %d to %s", device.Key, protocol)
+		spec = &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationEdit,
+			Device:    device,
+		}
+	}
+
+	var specs []types.BaseVirtualDeviceConfigSpec
+	if spec != nil {
+		specs = append(specs, spec)
+		l = applyDeviceChange(l, specs)
+		_ = d.Set("reboot_required", true)
+	}
+	log.Printf("[DEBUG] PrecisionClockApplyOperation: Apply complete, returning updated spec: %s", DeviceChangeString(specs))
+	return l, specs, nil
+}
+
+// PrecisionClockRefreshOperation reads the precision clock of a virtual
+// machine into the precision_clock block.
+func PrecisionClockRefreshOperation(d *schema.ResourceData, l object.VirtualDeviceList) error {
+	log.Printf("[DEBUG] PrecisionClockRefreshOperation: Beginning refresh")
+	device := selectPrecisionClock(l)
+	if device == nil {
+		log.Printf("[DEBUG] PrecisionClockRefreshOperation: No precision clock found")
+		return d.Set("precision_clock", nil)
+	}
+	log.Printf("[DEBUG] PrecisionClockRefreshOperation: Refresh complete, precision clock found with key %d", device.Key)
+	return d.Set("precision_clock", []interface{}{
+		map[string]interface{}{
+			"protocol": precisionClockProtocol(device),
+		},
+	})
+}
+
+// selectPrecisionClock returns the precision clock in l, or nil if there is
+// none.
+func selectPrecisionClock(l object.VirtualDeviceList) *types.VirtualPrecisionClock {
+	devices := l.SelectByType((*types.VirtualPrecisionClock)(nil))
+	if len(devices) == 0 {
+		return nil
+	}
+	return devices[0].(*types.VirtualPrecisionClock)
+}
+
+// precisionClockProtocol returns the protocol of a precision clock. A device
+// without a system clock backing is reported as using ptp, the default of
+// vSphere.
+func precisionClockProtocol(device *types.VirtualPrecisionClock) string {
+	if backing, ok := device.Backing.(*types.VirtualPrecisionClockSystemClockBackingInfo); ok && backing.Protocol != "" {
+		return backing.Protocol
+	}
+	return string(types.HostDateTimeInfoProtocolPtp)
+}
+
+// precisionClockConfigProtocol returns the protocol of a precision_clock
+// block. A block without any attributes set is read as nil and uses the
+// default.
+func precisionClockConfigProtocol(config []interface{}) string {
+	m, ok := config[0].(map[string]interface{})
+	if !ok {
+		return string(types.HostDateTimeInfoProtocolPtp)
+	}
+	return m["protocol"].(string)
+}
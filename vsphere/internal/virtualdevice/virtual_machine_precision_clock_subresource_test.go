@@ -0,0 +1,83 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var testPrecisionClock = testSingleDevice{
+	key:         "precision_clock",
+	subresource: PrecisionClockSubresourceSchema(),
+	attribute:   "protocol",
+	setting: func(l object.VirtualDeviceList) (interface{}, bool) {
+		device := selectPrecisionClock(l)
+		if device == nil {
+			return nil, false
+		}
+		return precisionClockProtocol(device), true
+	},
+	apply:   PrecisionClockApplyOperation,
+	refresh: PrecisionClockRefreshOperation,
+}
+
+func testPrecisionClockDeviceList(protocol string) object.VirtualDeviceList {
+	return object.VirtualDeviceList{
+		&types.VirtualPrecisionClock{
+			VirtualDevice: types.VirtualDevice{
+				Key:     19000,
+				Backing: &types.VirtualPrecisionClockSystemClockBackingInfo{Protocol: protocol},
+			},
+		},
+	}
+}
+
+func TestPrecisionClockApplyOperation(t *testing.T) {
+	testSingleDeviceApplyOperation(t, testPrecisionClock, []testSingleDeviceApplyCase{
+		{
+			name:       "add",
+			config:     []interface{}{map[string]interface{}{"protocol": "ntp"}},
+			devices:    object.VirtualDeviceList{},
+			expectedOp: types.VirtualDeviceConfigSpecOperationAdd,
+			expected:   "ntp",
+		},
+		{
+			name:       "add with defaults",
+			config:     []interface{}{map[string]interface{}{}},
+			devices:    object.VirtualDeviceList{},
+			expectedOp: types.VirtualDeviceConfigSpecOperationAdd,
+			expected:   "ptp",
+		},
+		{
+			name:       "edit",
+			config:     []interface{}{map[string]interface{}{"protocol": "ptp"}},
+			devices:    testPrecisionClockDeviceList("ntp"),
+			expectedOp: types.VirtualDeviceConfigSpecOperationEdit,
+			expected:   "ptp",
+		},
+		{
+			name:     "unchanged",
+			config:   []interface{}{map[string]interface{}{"protocol": "ptp"}},
+			devices:  testPrecisionClockDeviceList("ptp"),
+			expected: "ptp",
+		},
+		{
+			name:     "not configured with existing precision clock",
+			devices:  testPrecisionClockDeviceList("ntp"),
+			expected: "ntp",
+		},
+		{
+			name:    "not configured",
+			devices: object.VirtualDeviceList{},
+		},
+	})
+}
+
+func TestPrecisionClockRefreshOperation(t *testing.T) {
+	testSingleDeviceRefreshOperation(t, testPrecisionClock, testPrecisionClockDeviceList("ntp"), "ntp")
+}
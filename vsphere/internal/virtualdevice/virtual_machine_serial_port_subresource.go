@@ -0,0 +1,281 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/datastore"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
+)
+
+var serialPortAllowedDirections = []string{
+	string(types.VirtualDeviceURIBackingOptionDirectionServer),
+	string(types.VirtualDeviceURIBackingOptionDirectionClient),
+}
+
+// SerialPortSubresourceSchema represents the schema for the serial_port
+// sub-resource.
+func SerialPortSubresourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		// VirtualSerialPortFileBackingInfo
+		"datastore_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The datastore ID of the file that the serial port writes to.",
+		},
+		"path": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The path to the file on the datastore that the serial port writes to.",
+		},
+		// VirtualSerialPortURIBackingInfo
+		"network_uri": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The URI of the network service that the serial port connects to, such as telnet://:2300 or tcp://192.168.1.10:9000.",
+		},
+		"direction": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      string(types.VirtualDeviceURIBackingOptionDirectionServer),
+			Description:  "Whether the virtual machine listens for connections on network_uri (server), or connects to it (client).",
+			ValidateFunc: validation.StringInSlice(serialPortAllowedDirections, false),
+		},
+		// VirtualSerialPortDeviceBackingInfo
+		"device_name": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The name of the serial port device on the host, such as /dev/char/serial/uart0.",
+		},
+		"yield_on_poll": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+			Description: "Let the virtual machine yield the CPU when the guest polls the serial port, instead of using all of the CPU.",
+		},
+	}
+}
+
+// SerialPortApplyOperation checks for changes in the serial ports of a
+// virtual machine and creates config specs to apply to the virtual machine.
+//
+// Serial ports are matched to the entries of serial_port in the order of
+// their device keys. Ports are added or removed when the number of entries
+// changes, and edited when their backing changes. Any change flags a reboot,
+// as serial ports cannot be changed while the virtual machine is powered on.
+// Serial ports are only managed when serial_port is configured, so the ports
+// of a virtual machine or template without serial_port entries are left
+// alone.
+func SerialPortApplyOperation(d *schema.ResourceData, c *govmomi.Client, l object.VirtualDeviceList) (object.VirtualDeviceList, []types.BaseVirtualDeviceConfigSpec, error) {
+	log.Printf("[DEBUG] SerialPortApplyOperation: Beginning apply operation")
+	config := d.Get("serial_port").([]interface{})
+	if len(config) == 0 {
+		log.Printf("[DEBUG] SerialPortApplyOperation: No serial ports configured, leaving existing ports as they are")
+		return l, nil, nil
+	}
+	devices := selectSerialPorts(l)
+
+	var specs []types.BaseVirtualDeviceConfigSpec
+	for i, v := range config {
+		m, _ := v.(map[string]interface{})
+		if err := validateSerialPortBacking(m); err != nil {
+			return nil, nil, fmt.Errorf("serial_port.%d: %s", i, err)
+		}
+		var device *types.VirtualSerialPort
+		op := types.VirtualDeviceConfigSpecOperationEdit
+		if i < len(devices) {
+			device = devices[i]
+			if reflect.DeepEqual(flattenSerialPort(device), m) {
+				continue
+			}
+		} else {
+			var err error
+			if device, err = l.CreateSerialPort(); err != nil {
+				return nil, nil, fmt.Errorf("serial_port.%d: %s", i, err)
+			}
+			op = types.VirtualDeviceConfigSpecOperationAdd
+		}
+		backing, err := expandSerialPortBacking(c, m)
+		if err != nil {
+			return nil, nil, fmt.Errorf("serial_port.%d: %s", i, err)
+		}
+		device.Backing = backing
+		device.YieldOnPoll = m["yield_on_poll"].(bool)
+		device.Connectable = &types.VirtualDeviceConnectInfo{
+			AllowGuestControl: true,
+			Connected:         true,
+			StartConnected:    true,
+		}
+		log.Printf("[DEBUG] SerialPortApplyOperation: %s serial port with key %d", op, device.Key)
+		spec := []types.BaseVirtualDeviceConfigSpec{
+			&types.VirtualDeviceConfigSpec{
+				Operation: op,
+				Device:    device,
+			},
+		}
+		l = applyDeviceChange(l, spec)
+		specs = append(specs, spec...)
+	}
+	for i := len(config); i < len(devices); i++ {
+		log.Printf("[DEBUG] SerialPortApplyOperation: Removing serial port with key %d", devices[i].Key)
+		spec := []types.BaseVirtualDeviceConfigSpec{
+			&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationRemove,
+				Device:    devices[i],
+			},
+		}
+		l = applyDeviceChange(l, spec)
+		specs = append(specs, spec...)
+	}
+
+	if len(specs) > 0 {
+		_ = d.Set("reboot_required", true)
+	}
+	log.Printf("[DEBUG] SerialPortApplyOperation: Apply complete, returning updated spec: %s", DeviceChangeString(specs))
+	return l, specs, nil
+}
+
+// SerialPortDiffOperation checks that exactly one backing is configured in
+// each serial_port entry. Entries that depend on values that are not known
+// yet are checked when they are applied.
+func SerialPortDiffOperation(d *schema.ResourceDiff) error {
+	log.Printf("[DEBUG] SerialPortDiffOperation: Beginning diff validation")
+	for i, v := range d.Get("serial_port").([]interface{}) {
+		keys := []string{"datastore_id", "path", "network_uri", "device_name"}
+		if !structure.ValuesAvailable(fmt.Sprintf("serial_port.%d.", i), keys, d) {
+			log.Printf("[DEBUG] SerialPortDiffOperation: Skipping serial_port.%d, values not known yet", i)
+			continue
+		}
+		m, _ := v.(map[string]interface{})
+		if err := validateSerialPortBacking(m); err != nil {
+			return fmt.Errorf("serial_port.%d: %s", i, err)
+		}
+	}
+	log.Printf("[DEBUG] SerialPortDiffOperation: Diff validation complete")
+	return nil
+}
+
+// SerialPortRefreshOperation reads the serial ports of a virtual machine into
+// serial_port, in the order of their device keys.
+func SerialPortRefreshOperation(d *schema.ResourceData, l object.VirtualDeviceList) error {
+	log.Printf("[DEBUG] SerialPortRefreshOperation: Beginning refresh")
+	devices := selectSerialPorts(l)
+	ports := make([]interface{}, len(devices))
+	for i, device := range devices {
+		ports[i] = flattenSerialPort(device)
+	}
+	log.Printf("[DEBUG] SerialPortRefreshOperation: Refresh complete, %d serial ports found", len(ports))
+	return d.Set("serial_port", ports)
+}
+
+// selectSerialPorts returns the serial ports in l, sorted by device key.
+func selectSerialPorts(l object.VirtualDeviceList) []*types.VirtualSerialPort {
+	var devices []*types.VirtualSerialPort
+	for _, device := range l.SelectByType((*types.VirtualSerialPort)(nil)) {
+		devices = append(devices, device.(*types.VirtualSerialPort))
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Key < devices[j].Key })
+	return devices
+}
+
+// validateSerialPortBacking checks that exactly one backing is configured in
+// a serial_port entry.
+func validateSerialPortBacking(m map[string]interface{}) error {
+	var count int
+	for _, k := range []string{"path", "network_uri", "device_name"} {
+		if m[k] != nil && m[k].(string) != "" {
+			count++
+		}
+	}
+	switch {
+	case count != 1:
+		return fmt.Errorf("exactly one of path, network_uri, or device_name must be set")
+	case m["path"].(string) != "" && m["datastore_id"].(string) == "":
+		return fmt.Errorf("datastore_id must be set with path")
+	}
+	return nil
+}
+
+// expandSerialPortBacking returns the backing of a serial port for a
+// serial_port entry.
+func expandSerialPortBacking(c *govmomi.Client, m map[string]interface{}) (types.BaseVirtualDeviceBackingInfo, error) {
+	switch {
+	case m["path"].(string) != "":
+		ds, err := datastore.FromID(c, m["datastore_id"].(string))
+		if err != nil {
+			return nil, fmt.Errorf("cannot find datastore: %s", err)
+		}
+		dsProps, err := datastore.Properties(ds)
+		if err != nil {
+			return nil, fmt.Errorf("could not get properties for datastore: %s", err)
+		}
+		dsPath := &object.DatastorePath{
+			Datastore: dsProps.Name,
+			Path:      m["path"].(string),
+		}
+		dsRef := ds.Reference()
+		return &types.VirtualSerialPortFileBackingInfo{
+			VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+				FileName:  dsPath.String(),
+				Datastore: &dsRef,
+			},
+		}, nil
+	case m["network_uri"].(string) != "":
+		return &types.VirtualSerialPortURIBackingInfo{
+			VirtualDeviceURIBackingInfo: types.VirtualDeviceURIBackingInfo{
+				ServiceURI: m["network_uri"].(string),
+				Direction:  m["direction"].(string),
+			},
+		}, nil
+	}
+	return &types.VirtualSerialPortDeviceBackingInfo{
+		VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{
+			DeviceName: m["device_name"].(string),
+		},
+	}, nil
+}
+
+// flattenSerialPort returns a serial port in the form of a serial_port entry.
+// Backings that are not supported, such as named pipes, leave all backing
+// attributes empty.
+func flattenSerialPort(device *types.VirtualSerialPort) map[string]interface{} {
+	m := map[string]interface{}{
+		"datastore_id":  "",
+		"path":          "",
+		"network_uri":   "",
+		"direction":     string(types.VirtualDeviceURIBackingOptionDirectionServer),
+		"device_name":   "",
+		"yield_on_poll": device.YieldOnPoll,
+	}
+	switch backing := device.Backing.(type) {
+	case *types.VirtualSerialPortFileBackingInfo:
+		dp := &object.DatastorePath{}
+		if dp.FromString(backing.FileName) {
+			m["path"] = dp.Path
+		}
+		if backing.Datastore != nil {
+			m["datastore_id"] = backing.Datastore.Value
+		}
+	case *types.VirtualSerialPortURIBackingInfo:
+		m["network_uri"] = backing.ServiceURI
+		if backing.Direction != "" {
+			m["direction"] = backing.Direction
+		}
+	case *types.VirtualSerialPortDeviceBackingInfo:
+		m["device_name"] = backing.DeviceName
+	default:
+		log.Printf("[DEBUG] flattenSerialPort: Unsupported serial port backing %T, clearing backing attributes", backing)
+	}
+	return m
+}
@@ -0,0 +1,169 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func testSerialPortResourceData(t *testing.T, config []interface{}) *schema.ResourceData {
+	return testDeviceResourceData(t, "serial_port", SerialPortSubresourceSchema(), 4, config)
+}
+
+func testSerialPortDeviceList(backings ...types.BaseVirtualDeviceBackingInfo) object.VirtualDeviceList {
+	l := object.VirtualDeviceList{
+		&types.VirtualSIOController{
+			VirtualController: types.VirtualController{
+				VirtualDevice: types.VirtualDevice{Key: 400},
+			},
+		},
+	}
+	for i, backing := range backings {
+		l = append(l, &types.VirtualSerialPort{
+			VirtualDevice: types.VirtualDevice{
+				Key:           int32(9000 + i),
+				ControllerKey: 400,
+				Backing:       backing,
+			},
+			YieldOnPoll: true,
+		})
+	}
+	return l
+}
+
+func testSerialPortURIBacking(uri, direction string) *types.VirtualSerialPortURIBackingInfo {
+	return &types.VirtualSerialPortURIBackingInfo{
+		VirtualDeviceURIBackingInfo: types.VirtualDeviceURIBackingInfo{
+			ServiceURI: uri,
+			Direction:  direction,
+		},
+	}
+}
+
+func TestSerialPortApplyOperation(t *testing.T) {
+	cases := []struct {
+		name        string
+		config      []interface{}
+		devices     object.VirtualDeviceList
+		expectedOps []types.VirtualDeviceConfigSpecOperation
+		expectedLen int
+	}{
+		{
+			name: "add network serial port",
+			config: []interface{}{
+				map[string]interface{}{"network_uri": "telnet://:2300"},
+			},
+			devices:     testSerialPortDeviceList(),
+			expectedOps: []types.VirtualDeviceConfigSpecOperation{types.VirtualDeviceConfigSpecOperationAdd},
+			expectedLen: 1,
+		},
+		{
+			name: "change direction",
+			config: []interface{}{
+				map[string]interface{}{"network_uri": "telnet://:2300", "direction": "client"},
+			},
+			devices:     testSerialPortDeviceList(testSerialPortURIBacking("telnet://:2300", "server")),
+			expectedOps: []types.VirtualDeviceConfigSpecOperation{types.VirtualDeviceConfigSpecOperationEdit},
+			expectedLen: 1,
+		},
+		{
+			name: "unchanged",
+			config: []interface{}{
+				map[string]interface{}{"network_uri": "telnet://:2300"},
+			},
+			devices:     testSerialPortDeviceList(testSerialPortURIBacking("telnet://:2300", "server")),
+			expectedLen: 1,
+		},
+		{
+			name: "add host device and remove surplus",
+			config: []interface{}{
+				map[string]interface{}{"network_uri": "telnet://:2300"},
+			},
+			devices: testSerialPortDeviceList(
+				testSerialPortURIBacking("telnet://:2300", "server"),
+				&types.VirtualSerialPortDeviceBackingInfo{
+					VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{DeviceName: "/dev/char/serial/uart0"},
+				},
+			),
+			expectedOps: []types.VirtualDeviceConfigSpecOperation{types.VirtualDeviceConfigSpecOperationRemove},
+			expectedLen: 1,
+		},
+		{
+			name:        "not configured",
+			devices:     testSerialPortDeviceList(),
+			expectedLen: 0,
+		},
+		{
+			name:        "not configured with existing serial port",
+			devices:     testSerialPortDeviceList(testSerialPortURIBacking("telnet://:2300", "server")),
+			expectedLen: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := testSerialPortResourceData(t, tc.config)
+			l, specs, err := SerialPortApplyOperation(d, nil, tc.devices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(specs) != len(tc.expectedOps) {
+				t.Fatalf("expected %d operations, got %s", len(tc.expectedOps), DeviceChangeString(specs))
+			}
+			for i, op := range tc.expectedOps {
+				if actual := specs[i].GetVirtualDeviceConfigSpec().Operation; actual != op {
+					t.Fatalf("expected operation %s, got %s", op, actual)
+				}
+			}
+			if expected := len(specs) > 0; d.Get("reboot_required").(bool) != expected {
+				t.Fatalf("expected reboot_required to be %t", expected)
+			}
+			if actual := len(selectSerialPorts(l)); actual != tc.expectedLen {
+				t.Fatalf("expected %d serial ports in the device list, got %d", tc.expectedLen, actual)
+			}
+		})
+	}
+}
+
+func TestSerialPortApplyOperationInvalidBacking(t *testing.T) {
+	d := testSerialPortResourceData(t, []interface{}{
+		map[string]interface{}{"network_uri": "telnet://:2300", "device_name": "/dev/char/serial/uart0"},
+	})
+	if _, _, err := SerialPortApplyOperation(d, nil, testSerialPortDeviceList()); err == nil {
+		t.Fatal("expected an error for a serial port with more than one backing")
+	}
+}
+
+func TestSerialPortRefreshOperation(t *testing.T) {
+	d := testSerialPortResourceData(t, nil)
+	devices := testSerialPortDeviceList(
+		testSerialPortURIBacking("tcp://192.168.1.10:9000", "client"),
+		&types.VirtualSerialPortFileBackingInfo{
+			VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+				FileName:  "[datastore1] vm/serial.log",
+				Datastore: &types.ManagedObjectReference{Type: "Datastore", Value: "datastore-1"},
+			},
+		},
+	)
+	if err := SerialPortRefreshOperation(d, devices); err != nil {
+		t.Fatal(err)
+	}
+	actual := d.Get("serial_port").([]interface{})
+	if len(actual) != 2 {
+		t.Fatalf("expected two serial ports, got %d", len(actual))
+	}
+	network := actual[0].(map[string]interface{})
+	if network["network_uri"] != "tcp://192.168.1.10:9000" || network["direction"] != "client" {
+		t.Fatalf("unexpected network serial port: %#v", network)
+	}
+	file := actual[1].(map[string]interface{})
+	if file["datastore_id"] != "datastore-1" || file["path"] != "vm/serial.log" {
+		t.Fatalf("unexpected file serial port: %#v", file)
+	}
+}
@@ -0,0 +1,115 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// WatchdogTimerSubresourceSchema represents the schema for the watchdog_timer
+// sub-resource.
+func WatchdogTimerSubresourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"run_on_boot": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Start the watchdog timer when the virtual machine boots, instead of when the guest operating system starts it.",
+		},
+	}
+}
+
+// WatchdogTimerApplyOperation checks for changes in the watchdog timer of a
+// virtual machine and creates config specs to apply to the virtual machine.
+//
+// A virtual machine has at most one watchdog timer. The device is added when
+// a watchdog_timer block is configured and edited when run_on_boot changes.
+// Any change flags a reboot, as the watchdog timer cannot be changed while the
+// virtual machine is powered on. The watchdog timer is only managed when
+// watchdog_timer is configured, so the watchdog timer of a virtual machine or
+// template without a watchdog_timer block is left alone.
+func WatchdogTimerApplyOperation(d *schema.ResourceData, l object.VirtualDeviceList) (object.VirtualDeviceList, []types.BaseVirtualDeviceConfigSpec, error) {
+	log.Printf("[DEBUG] WatchdogTimerApplyOperation: Beginning apply operation")
+	config := d.Get("watchdog_timer").([]interface{})
+	if len(config) == 0 {
+		log.Printf("[DEBUG] WatchdogTimerApplyOperation: No watchdog timer configured, leaving existing device as it is")
+		return l, nil, nil
+	}
+	device := selectWatchdogTimer(l)
+
+	var spec *types.VirtualDeviceConfigSpec
+	switch {
+	case device == nil:
+		device = &types.VirtualWDT{
+			VirtualDevice: types.VirtualDevice{
+				Key: l.NewKey(),
+			},
+			RunOnBoot: watchdogTimerRunOnBoot(config),
+		}
+		log.Printf("[DEBUG] WatchdogTimerApplyOperation: Adding watchdog timer (run on boot: %t)", device.RunOnBoot)
+		spec = &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+			Device:    device,
+		}
+	case device.RunOnBoot != watchdogTimerRunOnBoot(config):
+		device.RunOnBoot = watchdogTimerRunOnBoot(config)
+		log.Printf("[DEBUG] WatchdogTimerApplyOperation: Setting run on boot of watchdog timer with key %d to %t", device.Key, device.RunOnBoot)
+		spec = &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationEdit,
+			Device:    device,
+		}
+	}
+
+	var specs []types.BaseVirtualDeviceConfigSpec
+	if spec != nil {
+		specs = append(specs, spec)
+		l = applyDeviceChange(l, specs)
+		_ = d.Set("reboot_required", true)
+	}
+	log.Printf("[DEBUG] WatchdogTimerApplyOperation: Apply complete, returning updated spec: %s", DeviceChangeString(specs))
+	return l, specs, nil
+}
+
+// WatchdogTimerRefreshOperation reads the watchdog timer of a virtual machine
+// into the watchdog_timer block.
+func WatchdogTimerRefreshOperation(d *schema.ResourceData, l object.VirtualDeviceList) error {
+	log.Printf("[DEBUG] WatchdogTimerRefreshOperation: Beginning refresh")
+	device := selectWatchdogTimer(l)
+	if device == nil {
+		log.Printf("[DEBUG] WatchdogTimerRefreshOperation: No watchdog timer found")
+		return d.Set("watchdog_timer", nil)
+	}
+	log.Printf("[DEBUG] WatchdogTimerRefreshOperation: Refresh complete, watchdog timer found with key %d", device.Key)
+	return d.Set("watchdog_timer", []interface{}{
+		map[string]interface{}{
+			"run_on_boot": device.RunOnBoot,
+		},
+	})
+}
+
+// selectWatchdogTimer returns the watchdog timer in l, or nil if there is
+// none.
+func selectWatchdogTimer(l object.VirtualDeviceList) *types.VirtualWDT {
+	devices := l.SelectByType((*types.VirtualWDT)(nil))
+	if len(devices) == 0 {
+		return nil
+	}
+	return devices[0].(*types.VirtualWDT)
+}
+
+// watchdogTimerRunOnBoot returns the run_on_boot setting of a watchdog_timer
+// block. A block without any attributes set is read as nil and uses the
+// default.
+func watchdogTimerRunOnBoot(config []interface{}) bool {
+	m, ok := config[0].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	return m["run_on_boot"].(bool)
+}
@@ -0,0 +1,81 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualdevice
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var testWatchdogTimer = testSingleDevice{
+	key:         "watchdog_timer",
+	subresource: WatchdogTimerSubresourceSchema(),
+	attribute:   "run_on_boot",
+	setting: func(l object.VirtualDeviceList) (interface{}, bool) {
+		device := selectWatchdogTimer(l)
+		if device == nil {
+			return nil, false
+		}
+		return device.RunOnBoot, true
+	},
+	apply:   WatchdogTimerApplyOperation,
+	refresh: WatchdogTimerRefreshOperation,
+}
+
+func testWatchdogTimerDeviceList(runOnBoot bool) object.VirtualDeviceList {
+	return object.VirtualDeviceList{
+		&types.VirtualWDT{
+			VirtualDevice: types.VirtualDevice{Key: 15000},
+			RunOnBoot:     runOnBoot,
+		},
+	}
+}
+
+func TestWatchdogTimerApplyOperation(t *testing.T) {
+	testSingleDeviceApplyOperation(t, testWatchdogTimer, []testSingleDeviceApplyCase{
+		{
+			name:       "add",
+			config:     []interface{}{map[string]interface{}{"run_on_boot": true}},
+			devices:    object.VirtualDeviceList{},
+			expectedOp: types.VirtualDeviceConfigSpecOperationAdd,
+			expected:   true,
+		},
+		{
+			name:       "add with defaults",
+			config:     []interface{}{map[string]interface{}{}},
+			devices:    object.VirtualDeviceList{},
+			expectedOp: types.VirtualDeviceConfigSpecOperationAdd,
+			expected:   false,
+		},
+		{
+			name:       "edit",
+			config:     []interface{}{map[string]interface{}{"run_on_boot": true}},
+			devices:    testWatchdogTimerDeviceList(false),
+			expectedOp: types.VirtualDeviceConfigSpecOperationEdit,
+			expected:   true,
+		},
+		{
+			name:     "unchanged",
+			config:   []interface{}{map[string]interface{}{"run_on_boot": true}},
+			devices:  testWatchdogTimerDeviceList(true),
+			expected: true,
+		},
+		{
+			name:     "not configured with existing watchdog timer",
+			devices:  testWatchdogTimerDeviceList(true),
+			expected: true,
+		},
+		{
+			name:    "not configured",
+			devices: object.VirtualDeviceList{},
+		},
+	})
+}
+
+func TestWatchdogTimerRefreshOperation(t *testing.T) {
+	testSingleDeviceRefreshOperation(t, testWatchdogTimer, testWatchdogTimerDeviceList(true), true)
+}
@@ -100,6 +100,12 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("VSPHERE_API_TIMEOUT", 5),
 				Description: "API timeout in minutes (Default: 5)",
 			},
+			"api_retry_count": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("VSPHERE_API_RETRY_COUNT", 3),
+				Description: "Number of times waiting for a task is retried after a transient error, such as an unavailable vCenter Server or an expired session (Default: 3)",
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
@@ -128,6 +134,7 @@ func Provider() *schema.Provider {
 			"vsphere_guest_os_customization":                   resourceVSphereGuestOsCustomization(),
 			"vsphere_ha_vm_override":                           resourceVSphereHAVMOverride(),
 			"vsphere_host":                                     resourceVsphereHost(),
+			"vsphere_host_netstack":                            resourceVSphereHostNetStack(),
 			"vsphere_host_port_group":                          resourceVSphereHostPortGroup(),
 			"vsphere_host_virtual_switch":                      resourceVSphereHostVirtualSwitch(),
 			"vsphere_license":                                  resourceVSphereLicense(),
@@ -145,6 +152,7 @@ func Provider() *schema.Provider {
 			"vsphere_virtual_machine":                          resourceVSphereVirtualMachine(),
 			"vsphere_virtual_machine_class":                    resourceVsphereVMClass(),
 			"vsphere_virtual_machine_snapshot":                 resourceVSphereVirtualMachineSnapshot(),
+			"vsphere_virtual_machine_snapshot_revert":          resourceVSphereVirtualMachineSnapshotRevert(),
 			"vsphere_vm_storage_policy":                        resourceVMStoragePolicy(),
 			"vsphere_vmfs_datastore":                           resourceVSphereVmfsDatastore(),
 			"vsphere_vnic":                                     resourceVsphereNic(),
@@ -165,11 +173,13 @@ func Provider() *schema.Provider {
 			"vsphere_dynamic":                    dataSourceVSphereDynamic(),
 			"vsphere_folder":                     dataSourceVSphereFolder(),
 			"vsphere_guest_os_customization":     dataSourceVSphereGuestOSCustomization(),
+			"vsphere_hardware_versions":          dataSourceVSphereHardwareVersions(),
 			"vsphere_host":                       dataSourceVSphereHost(),
 			"vsphere_host_base_images":           dataSourceVSphereHostBaseImages(),
 			"vsphere_host_pci_device":            dataSourceVSphereHostPciDevice(),
 			"vsphere_host_thumbprint":            dataSourceVSphereHostThumbprint(),
 			"vsphere_host_vgpu_profile":          dataSourceVSphereHostVGpuProfile(),
+			"vsphere_host_vmkernel":              dataSourceVSphereHostVmkernel(),
 			"vsphere_license":                    dataSourceVSphereLicense(),
 			"vsphere_network":                    dataSourceVSphereNetwork(),
 			"vsphere_ovf_vm_template":            dataSourceVSphereOvfVMTemplate(),
@@ -180,7 +190,9 @@ func Provider() *schema.Provider {
 			"vsphere_tag_category":               dataSourceVSphereTagCategory(),
 			"vsphere_vapp_container":             dataSourceVSphereVAppContainer(),
 			"vsphere_virtual_machine":            dataSourceVSphereVirtualMachine(),
+			"vsphere_virtual_machine_snapshots":  dataSourceVSphereVirtualMachineSnapshots(),
 			"vsphere_vmfs_disks":                 dataSourceVSphereVmfsDisks(),
+			"vsphere_vmkernel":                   dataSourceVSphereVmkernel(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -141,6 +141,7 @@ func resourceVSphereComputeCluster() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			State: resourceVSphereComputeClusterImport,
 		},
+		CustomizeDiff: resourceVSphereComputeClusterCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -342,28 +343,32 @@ func resourceVSphereComputeCluster() *schema.Resource {
 				ValidateFunc: validation.StringInSlice(clusterDasConfigInfoVMMonitoringStateAllowedValues, false),
 			},
 			"ha_vm_failure_interval": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				Default:     30,
-				Description: "If a heartbeat from a virtual machine is not received within this configured interval, the virtual machine is marked as failed. The value is in seconds.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				Description:  "If a heartbeat from a virtual machine is not received within this configured interval, the virtual machine is marked as failed. The value is in seconds.",
+				ValidateFunc: validation.IntAtLeast(1),
 			},
 			"ha_vm_minimum_uptime": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				Default:     120,
-				Description: "The time, in seconds, that HA waits after powering on a virtual machine before monitoring for heartbeats.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      120,
+				Description:  "The time, in seconds, that HA waits after powering on a virtual machine before monitoring for heartbeats.",
+				ValidateFunc: validation.IntAtLeast(0),
 			},
 			"ha_vm_maximum_resets": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				Default:     3,
-				Description: "The maximum number of resets that HA will perform to a virtual machine when responding to a failure event.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				Description:  "The maximum number of resets that HA will perform to a virtual machine when responding to a failure event.",
+				ValidateFunc: validation.IntAtLeast(0),
 			},
 			"ha_vm_maximum_failure_window": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				Default:     -1,
-				Description: "The length of the reset window in which ha_vm_maximum_resets can operate. When this window expires, no more resets are attempted regardless of the setting configured in ha_vm_maximum_resets. -1 means no window, meaning an unlimited reset time is allotted.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      -1,
+				Description:  "The length of the reset window in which ha_vm_maximum_resets can operate. When this window expires, no more resets are attempted regardless of the setting configured in ha_vm_maximum_resets. -1 means no window, meaning an unlimited reset time is allotted.",
+				ValidateFunc: validation.Any(validation.IntInSlice([]int{-1}), validation.IntAtLeast(1)),
 			},
 			// Admission control
 			"ha_admission_control_policy": {
@@ -701,6 +706,10 @@ func resourceVSphereComputeCluster() *schema.Resource {
 	}
 }
 
+func resourceVSphereComputeClusterCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	return validateClusterVMToolsMonitoringThresholds(d)
+}
+
 func resourceVSphereComputeClusterCreate(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereComputeClusterIDString(d))
 
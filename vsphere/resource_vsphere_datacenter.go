@@ -286,7 +286,9 @@ func resourceVSphereDatacenterImport(d *schema.ResourceData, meta interface{}) (
 		return nil, errors.New("path must start with a trailing slash")
 	}
 
-	dc, err := datacenter.FromPath(client, p)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	dc, err := datacenter.FromPathContext(ctx, client, p)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@
 package vsphere
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -308,7 +309,9 @@ func resourceVSphereDatastoreClusterImport(d *schema.ResourceData, meta interfac
 		return nil, fmt.Errorf("error loading datastore cluster: %s", err)
 	}
 	client := meta.(*Client).vimClient
-	dc, err := datacenter.FromInventoryPath(client, pod.InventoryPath)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	dc, err := datacenter.FromInventoryPathContext(ctx, client, pod.InventoryPath)
 	if err != nil {
 		return nil, fmt.Errorf("error getting datacenter of datastore cluster: %s", err)
 	}
@@ -62,7 +62,13 @@ func resourceEntityPermissionsCreate(d *schema.ResourceData, meta interface{}) e
 
 	entityType := d.Get("entity_type").(string)
 	entityID := d.Get("entity_id").(string)
-	entityMoid, err := utils.GetMoid(client, entityType, entityID)
+	// An ID in UUID form cannot be a managed object ID, so report the failure
+	// of the UUID lookup instead of trying it as one.
+	getMoid := utils.GetMoid
+	if utils.IsEntityUUID(entityType, entityID) {
+		getMoid = utils.GetMoidStrict
+	}
+	entityMoid, err := getMoid(client, entityType, entityID)
 	if err != nil {
 		return err
 	}
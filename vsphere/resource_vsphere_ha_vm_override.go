@@ -5,6 +5,7 @@
 package vsphere
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -71,6 +72,7 @@ func resourceVSphereHAVMOverride() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			State: resourceVSphereHAVMOverrideImport,
 		},
+		CustomizeDiff: resourceVSphereHAVMOverrideCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"compute_cluster_id": {
@@ -151,33 +153,41 @@ func resourceVSphereHAVMOverride() *schema.Resource {
 				ValidateFunc: validation.StringInSlice(clusterDasConfigInfoVMMonitoringStateAllowedValues, false),
 			},
 			"ha_vm_failure_interval": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				Default:     30,
-				Description: "If a heartbeat from this virtual machine is not received within this configured interval, the virtual machine is marked as failed. The value is in seconds.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				Description:  "If a heartbeat from this virtual machine is not received within this configured interval, the virtual machine is marked as failed. The value is in seconds.",
+				ValidateFunc: validation.IntAtLeast(1),
 			},
 			"ha_vm_minimum_uptime": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				Default:     120,
-				Description: "The time, in seconds, that HA waits after powering on this virtual machine before monitoring for heartbeats.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      120,
+				Description:  "The time, in seconds, that HA waits after powering on this virtual machine before monitoring for heartbeats.",
+				ValidateFunc: validation.IntAtLeast(0),
 			},
 			"ha_vm_maximum_resets": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				Default:     3,
-				Description: "The maximum number of resets that HA will perform to this virtual machine when responding to a failure event.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				Description:  "The maximum number of resets that HA will perform to this virtual machine when responding to a failure event.",
+				ValidateFunc: validation.IntAtLeast(0),
 			},
 			"ha_vm_maximum_failure_window": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				Default:     -1,
-				Description: "The length of the reset window in which ha_vm_maximum_resets can operate. When this window expires, no more resets are attempted regardless of the setting configured in ha_vm_maximum_resets. -1 means no window, meaning an unlimited reset time is allotted.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      -1,
+				Description:  "The length of the reset window in which ha_vm_maximum_resets can operate. When this window expires, no more resets are attempted regardless of the setting configured in ha_vm_maximum_resets. -1 means no window, meaning an unlimited reset time is allotted.",
+				ValidateFunc: validation.Any(validation.IntInSlice([]int{-1}), validation.IntAtLeast(1)),
 			},
 		},
 	}
 }
 
+func resourceVSphereHAVMOverrideCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	return validateClusterVMToolsMonitoringThresholds(d)
+}
+
 func resourceVSphereHAVMOverrideCreate(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHAVMOverrideIDString(d))
 
@@ -380,6 +390,22 @@ func expandClusterDasVMConfigInfo(
 	return obj, nil
 }
 
+// validateClusterVMToolsMonitoringThresholds checks that the VM monitoring
+// heartbeat thresholds are consistent with each other. A reset window shorter
+// than the failure interval would never allow a reset to take place. The check
+// is skipped if either value is not known yet.
+func validateClusterVMToolsMonitoringThresholds(d *schema.ResourceDiff) error {
+	if !structure.ValuesAvailable("", []string{"ha_vm_failure_interval", "ha_vm_maximum_failure_window"}, d) {
+		return nil
+	}
+	interval := d.Get("ha_vm_failure_interval").(int)
+	window := d.Get("ha_vm_maximum_failure_window").(int)
+	if window != -1 && window < interval {
+		return fmt.Errorf("ha_vm_maximum_failure_window (%d) must be -1 or greater than or equal to ha_vm_failure_interval (%d)", window, interval)
+	}
+	return nil
+}
+
 // flattenClusterDasVmConfigInfo saves a ClusterDasVmConfigInfo into the
 // supplied ResourceData.
 func flattenClusterDasVMConfigInfo(d *schema.ResourceData, meta interface{}, obj *types.ClusterDasVmConfigInfo) error {
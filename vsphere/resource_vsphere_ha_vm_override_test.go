@@ -5,6 +5,7 @@
 package vsphere
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,8 @@ import (
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/vmware/govmomi/vim25/types"
@@ -479,3 +482,87 @@ resource "vsphere_ha_vm_override" "ha_vm_override" {
 `, testhelper.CombineConfigs(testhelper.ConfigDataRootDC1(), testhelper.ConfigDataRootHost1(), testhelper.ConfigDataRootHost2(), testhelper.ConfigResDS1(), testhelper.ConfigDataRootComputeCluster1(), testhelper.ConfigResResourcePool1(), testhelper.ConfigDataRootPortGroup1()),
 	)
 }
+
+func TestClusterVMToolsMonitoringSettingsRoundTrip(t *testing.T) {
+	raw := map[string]interface{}{
+		"compute_cluster_id":           "domain-c1",
+		"virtual_machine_id":           "42000000-0000-0000-0000-000000000000",
+		"ha_vm_monitoring":             string(types.ClusterDasConfigInfoVmMonitoringStateVmAndAppMonitoring),
+		"ha_vm_failure_interval":       60,
+		"ha_vm_minimum_uptime":         300,
+		"ha_vm_maximum_resets":         5,
+		"ha_vm_maximum_failure_window": 3600,
+	}
+	d := schema.TestResourceDataRaw(t, resourceVSphereHAVMOverride().Schema, raw)
+	expected := expandClusterVMToolsMonitoringSettings(d)
+
+	out := resourceVSphereHAVMOverride().Data(nil)
+	if err := flattenClusterVMToolsMonitoringSettings(out, expected); err != nil {
+		t.Fatalf("error flattening settings: %s", err)
+	}
+	actual := expandClusterVMToolsMonitoringSettings(out)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %s, got %s", spew.Sdump(expected), spew.Sdump(actual))
+	}
+}
+
+func TestClusterVMToolsMonitoringThresholdValidation(t *testing.T) {
+	s := resourceVSphereHAVMOverride().Schema
+	cases := []struct {
+		name    string
+		key     string
+		value   int
+		invalid bool
+	}{
+		{name: "failure interval zero", key: "ha_vm_failure_interval", value: 0, invalid: true},
+		{name: "failure interval positive", key: "ha_vm_failure_interval", value: 30},
+		{name: "minimum uptime negative", key: "ha_vm_minimum_uptime", value: -1, invalid: true},
+		{name: "minimum uptime zero", key: "ha_vm_minimum_uptime", value: 0},
+		{name: "maximum resets negative", key: "ha_vm_maximum_resets", value: -1, invalid: true},
+		{name: "failure window unlimited", key: "ha_vm_maximum_failure_window", value: -1},
+		{name: "failure window zero", key: "ha_vm_maximum_failure_window", value: 0, invalid: true},
+		{name: "failure window below unlimited", key: "ha_vm_maximum_failure_window", value: -2, invalid: true},
+		{name: "failure window positive", key: "ha_vm_maximum_failure_window", value: 3600},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, errs := s[tc.key].ValidateFunc(tc.value, tc.key)
+			if tc.invalid != (len(errs) > 0) {
+				t.Fatalf("expected invalid to be %t, got errors: %v", tc.invalid, errs)
+			}
+		})
+	}
+}
+
+func TestClusterVMToolsMonitoringThresholdCustomizeDiff(t *testing.T) {
+	resources := map[string]*schema.Resource{
+		"ha_vm_override":  resourceVSphereHAVMOverride(),
+		"compute_cluster": resourceVSphereComputeCluster(),
+	}
+	cases := []struct {
+		name     string
+		interval int
+		window   int
+		invalid  bool
+	}{
+		{name: "window shorter than interval", interval: 120, window: 60, invalid: true},
+		{name: "window equal to interval", interval: 120, window: 120},
+		{name: "unlimited window", interval: 120, window: -1},
+	}
+
+	for rname, r := range resources {
+		for _, tc := range cases {
+			t.Run(rname+"/"+tc.name, func(t *testing.T) {
+				raw := map[string]interface{}{
+					"ha_vm_failure_interval":       tc.interval,
+					"ha_vm_maximum_failure_window": tc.window,
+				}
+				_, err := schema.InternalMap(r.Schema).Diff(context.Background(), nil, sdkterraform.NewResourceConfigRaw(raw), r.CustomizeDiff, nil, true)
+				if tc.invalid != (err != nil) {
+					t.Fatalf("expected invalid to be %t, got error: %v", tc.invalid, err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
+)
+
+func resourceVSphereHostNetStack() *schema.Resource {
+	s := map[string]*schema.Schema{
+		"host_system_id": {
+			Type:        schema.TypeString,
+			Description: "The managed object ID of the host to create the TCP/IP stack instance on.",
+			Required:    true,
+			ForceNew:    true,
+		},
+	}
+	structure.MergeSchema(s, schemaHostNetStackInstance())
+
+	return &schema.Resource{
+		Create: resourceVSphereHostNetStackCreate,
+		Read:   resourceVSphereHostNetStackRead,
+		Update: resourceVSphereHostNetStackUpdate,
+		Delete: resourceVSphereHostNetStackDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostNetStackImport,
+		},
+		Schema: s,
+	}
+}
+
+func resourceVSphereHostNetStackCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	name := d.Get("name").(string)
+	hsID := d.Get("host_system_id").(string)
+	ns, err := hostNetworkSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading network system: %s", err)
+	}
+
+	obj := expandHostNetStackInstance(d)
+	if err := updateHostNetStack(ns, obj, types.ConfigSpecOperationAdd); err != nil {
+		return fmt.Errorf("error adding TCP/IP stack instance: %s", err)
+	}
+
+	saveHostNetStackID(d, hsID, name)
+	return resourceVSphereHostNetStackRead(d, meta)
+}
+
+func resourceVSphereHostNetStackRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	hsID, key, err := netStackIDsFromResourceID(d)
+	if err != nil {
+		return err
+	}
+	ns, err := hostNetworkSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host network system: %s", err)
+	}
+
+	nsi, err := hostNetStackFromKey(client, ns, key)
+	if err != nil {
+		return fmt.Errorf("error fetching TCP/IP stack instance data: %s", err)
+	}
+
+	if err := flattenHostNetStackInstance(d, nsi); err != nil {
+		return fmt.Errorf("error setting resource data: %s", err)
+	}
+
+	return nil
+}
+
+func resourceVSphereHostNetStackUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	hsID, _, err := netStackIDsFromResourceID(d)
+	if err != nil {
+		return err
+	}
+	ns, err := hostNetworkSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host network system: %s", err)
+	}
+
+	obj := expandHostNetStackInstance(d)
+	if err := updateHostNetStack(ns, obj, types.ConfigSpecOperationEdit); err != nil {
+		return fmt.Errorf("error updating TCP/IP stack instance: %s", err)
+	}
+
+	return resourceVSphereHostNetStackRead(d, meta)
+}
+
+func resourceVSphereHostNetStackDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	hsID, key, err := netStackIDsFromResourceID(d)
+	if err != nil {
+		return err
+	}
+	ns, err := hostNetworkSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host network system: %s", err)
+	}
+
+	obj := &types.HostNetStackInstance{Key: key}
+	if err := updateHostNetStack(ns, obj, types.ConfigSpecOperationRemove); err != nil {
+		return fmt.Errorf("error deleting TCP/IP stack instance: %s", err)
+	}
+
+	return nil
+}
+
+func resourceVSphereHostNetStackImport(d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+	hsID, key, err := netStackIDsFromResourceID(d)
+	if err != nil {
+		return []*schema.ResourceData{}, err
+	}
+
+	err = d.Set("host_system_id", hsID)
+	if err != nil {
+		return []*schema.ResourceData{}, err
+	}
+
+	err = d.Set("name", key)
+	if err != nil {
+		return []*schema.ResourceData{}, err
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// updateHostNetStack applies the supplied operation for a TCP/IP stack
+// instance through the host's network configuration.
+func updateHostNetStack(ns *object.HostNetworkSystem, obj *types.HostNetStackInstance, op types.ConfigSpecOperation) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	config := types.HostNetworkConfig{
+		NetStackSpec: []types.HostNetworkConfigNetStackSpec{
+			{
+				NetStackInstance: *obj,
+				Operation:        string(op),
+			},
+		},
+	}
+	_, err := ns.UpdateNetworkConfig(ctx, config, string(types.HostConfigChangeModeModify))
+	return err
+}
@@ -0,0 +1,86 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestAccResourceVSphereHostNetStack_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccResourceVSphereHostNetStackExists(false),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostNetStackConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceVSphereHostNetStackExists(true),
+					resource.TestCheckResourceAttr("vsphere_host_netstack.netstack", "congestion_control_algorithm", "cubic"),
+				),
+			},
+			{
+				ResourceName:      "vsphere_host_netstack.netstack",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					vars, err := testClientVariablesForResource(s, fmt.Sprintf("vsphere_host_netstack.%s", "netstack"))
+					if err != nil {
+						return "", err
+					}
+					return vars.resourceID, err
+				},
+				Config: testAccResourceVSphereHostNetStackConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceVSphereHostNetStackExists(true),
+				),
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostNetStackExists(expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		name := "tfNetStackTest"
+		_, err := testGetHostNetStack(s, "netstack")
+		if err != nil {
+			if err.Error() == fmt.Sprintf("could not find TCP/IP stack instance %s", name) && expected == false {
+				// Expected missing
+				return nil
+			}
+			return err
+		}
+		if expected == false {
+			return fmt.Errorf("expected TCP/IP stack instance %s to still be missing", name)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereHostNetStackConfig() string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_netstack" "netstack" {
+  name                         = "tfNetStackTest"
+  host_system_id               = data.vsphere_host.esxi_host.id
+  congestion_control_algorithm = "cubic"
+}
+`, testhelper.ConfigDataRootDC1(), os.Getenv("TF_VAR_VSPHERE_ESXI3"))
+}
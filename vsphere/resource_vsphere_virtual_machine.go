@@ -12,6 +12,7 @@ import (
 	"net"
 	"os"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -19,8 +20,11 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/govmomi/vapi/vcenter"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/computeresource"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/contentlibrary"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/customattribute"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/datastore"
@@ -158,6 +162,25 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 			Description:  "The amount of time, in minutes, to wait for shutdown when making necessary updates to the virtual machine.",
 			ValidateFunc: validation.IntBetween(1, 10),
 		},
+		"customization_pending_timeout": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      10,
+			Description:  "The amount of time, in minutes, to wait for a pending guest customization to complete before shutting down the virtual machine for updates that require a reboot. A value of 0 disables the waiter.",
+			ValidateFunc: validation.IntAtLeast(0),
+		},
+		"clear_pending_customization": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Clear a pending guest customization of the virtual machine, such as one left behind by a failed customization, before applying updates.",
+		},
+		"upgrade_tools_on_apply": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Upgrade VMware Tools on the virtual machine when they are out of date, after creating the virtual machine or applying updates. Tools are only upgraded when they are installed and running on a powered on virtual machine.",
+		},
 		"migrate_wait_timeout": {
 			Type:         schema.TypeInt,
 			Optional:     true,
@@ -272,25 +295,67 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 			Computed:    true,
 			Description: "Value internal to Terraform used to determine if a configuration set change requires a reboot.",
 		},
+		"connection_state": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The connection state of the virtual machine. One of connected, disconnected, orphaned, inaccessible, or invalid.",
+		},
 		"vmware_tools_status": {
 			Type:        schema.TypeString,
 			Computed:    true,
 			Description: "The state of VMware Tools in the guest. This will determine the proper course of action for some device operations.",
 		},
+		"customization_pending": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Whether a guest customization of the virtual machine is pending or running, such as after a clone with customization.",
+		},
+		"pending_customization": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The path of the guest customization package that is pending on the virtual machine. Empty if no customization is pending.",
+		},
 		"vmx_path": {
 			Type:        schema.TypeString,
 			Computed:    true,
 			Description: "The path of the virtual machine's configuration file in the VM's datastore.",
 		},
+		"swap_datastore_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The managed object ID of the datastore that holds the swap file of the virtual machine. Empty if the virtual machine has no swap file, such as when it is powered off.",
+		},
+		"datastore_ids": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The managed object IDs of all datastores that the virtual machine has files on.",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
 		"imported": {
 			Type:        schema.TypeBool,
 			Computed:    true,
 			Description: "A flag internal to Terraform that indicates that this resource was either imported or came from a earlier major version of this resource. Reset after the first post-import or post-upgrade apply.",
 		},
-		"power_state": {
-			Type:        schema.TypeString,
+		"power_state": schemaVirtualMachinePowerState(),
+		"overall_cpu_usage": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The basic CPU performance statistics of the virtual machine, in MHz. Zero when the virtual machine is not running.",
+		},
+		"host_memory_usage": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The host memory used by the virtual machine, in MB. Zero when the virtual machine is not running.",
+		},
+		"guest_memory_usage": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The guest memory actively used by the virtual machine, in MB. Zero when the virtual machine is not running.",
+		},
+		"uptime_seconds": {
+			Type:        schema.TypeInt,
 			Computed:    true,
-			Description: "The power state of the virtual machine.",
+			Description: "The time the virtual machine has been running, in seconds. Zero when the virtual machine is not running.",
 		},
 		"vtpm": {
 			Type:        schema.TypeList,
@@ -309,6 +374,45 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 				},
 			},
 		},
+		"nvdimm": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Computed:    true,
+			Description: "A specification for a virtual NVDIMM device on the virtual machine, backed by the persistent memory (PMem) datastore of the host. Existing NVDIMM devices are left alone if none are configured.",
+			Elem:        &schema.Resource{Schema: virtualdevice.NvdimmSubresourceSchema()},
+		},
+		"watchdog_timer": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Computed:    true,
+			MaxItems:    1,
+			Description: "A specification for a virtual watchdog timer device on the virtual machine. An existing watchdog timer is left alone if none is configured.",
+			Elem:        &schema.Resource{Schema: virtualdevice.WatchdogTimerSubresourceSchema()},
+		},
+		"precision_clock": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Computed:    true,
+			MaxItems:    1,
+			Description: "A specification for a virtual precision clock device on the virtual machine, which presents the system clock of the host to the guest. An existing precision clock is left alone if none is configured.",
+			Elem:        &schema.Resource{Schema: virtualdevice.PrecisionClockSubresourceSchema()},
+		},
+		"serial_port": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Computed:    true,
+			MaxItems:    4,
+			Description: "A specification for a virtual serial port on the virtual machine, backed by a file on a datastore, a network service, or a serial port of the host. Existing serial ports are left alone if none are configured.",
+			Elem:        &schema.Resource{Schema: virtualdevice.SerialPortSubresourceSchema()},
+		},
+		"floppy": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Computed:    true,
+			MaxItems:    2,
+			Description: "A specification for a virtual floppy drive on the virtual machine, with a floppy image on a datastore or a remote client device. Existing floppy drives are left alone if none are configured.",
+			Elem:        &schema.Resource{Schema: virtualdevice.FloppySubresourceSchema()},
+		},
 		vSphereTagAttributeKey:    tagsSchema(),
 		customattribute.ConfigKey: customattribute.ConfigSchema(),
 	}
@@ -427,11 +531,37 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 		return err
 	}
 
+	// Upgrade out of date VMware Tools, such as those in an older template,
+	// now that the guest is up.
+	if err := resourceVSphereVirtualMachineUpgradeTools(d, vm, meta.(*Client).timeout); err != nil {
+		return err
+	}
+
 	// All done!
 	log.Printf("[DEBUG] %s: Create complete", resourceVSphereVirtualMachineIDString(d))
 	return resourceVSphereVirtualMachineRead(d, meta)
 }
 
+// resourceVSphereVirtualMachineUpgradeTools upgrades VMware Tools on the
+// virtual machine if upgrade_tools_on_apply is set and they are out of date.
+func resourceVSphereVirtualMachineUpgradeTools(d *schema.ResourceData, vm *object.VirtualMachine, timeout time.Duration) error {
+	if !d.Get("upgrade_tools_on_apply").(bool) {
+		return nil
+	}
+	vprops, err := virtualmachine.Properties(vm)
+	if err != nil {
+		return fmt.Errorf("error fetching VM properties: %s", err)
+	}
+	if !toolsUpgradeNeeded(vprops) {
+		return nil
+	}
+	if err := virtualmachine.UpgradeTools(vm, timeout); err != nil {
+		return fmt.Errorf("error upgrading VMware Tools: %s", err)
+	}
+	log.Printf("[INFO] %s: Upgraded VMware Tools", resourceVSphereVirtualMachineIDString(d))
+	return nil
+}
+
 func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[DEBUG] %s: Reading state of virtual machine", resourceVSphereVirtualMachineIDString(d))
 	client := meta.(*Client).vimClient
@@ -460,9 +590,19 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 	// Reset reboot_required. This is an update only variable and should not be
 	// set across TF runs.
 	_ = d.Set("reboot_required", false)
+
+	// An orphaned or inaccessible virtual machine, such as one on a failed host,
+	// still exists but its configuration cannot be read. Keep the last known
+	// state instead of removing the resource or failing the read.
+	_ = d.Set("connection_state", string(vprops.Runtime.ConnectionState))
+	if !virtualmachine.IsConfigAvailable(vprops.Runtime.ConnectionState) {
+		log.Printf("[WARN] %s: Virtual machine is %s, skipping read of its configuration", resourceVSphereVirtualMachineIDString(d), vprops.Runtime.ConnectionState)
+		return nil
+	}
 	// Check to see if VMware Tools is running.
 	if vprops.Guest != nil {
 		_ = d.Set("vmware_tools_status", vprops.Guest.ToolsRunningStatus)
+		_ = d.Set("customization_pending", virtualmachine.IsCustomizationPending(vprops.Guest.CustomizationInfo))
 	}
 
 	// Resource pool
@@ -502,6 +642,8 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 	// get away with not having to have the datastore unnecessarily supplied to
 	// the resource when it's not used by anything else.
 	var ds *object.Datastore
+	swapDatastore := virtualMachineSwapDatastoreName(vprops)
+	var swapDatastoreID string
 	for _, dsRef := range vprops.Datastore {
 		dsx, err := datastore.FromID(client, dsRef.Value)
 		if err != nil {
@@ -514,12 +656,20 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 		if dsxProps.Summary.Name == dp.Datastore {
 			ds = dsx
 		}
+		if dsxProps.Summary.Name == swapDatastore {
+			swapDatastoreID = dsRef.Value
+		}
 	}
 	if ds == nil {
 		return fmt.Errorf("VMX datastore %s not found", dp.Datastore)
 	}
 	_ = d.Set("datastore_id", ds.Reference().Value)
 	_ = d.Set("vmx_path", dp.Path)
+	_ = d.Set("swap_datastore_id", swapDatastoreID)
+	if vprops.Config.Tools != nil {
+		_ = d.Set("pending_customization", vprops.Config.Tools.PendingCustomization)
+	}
+	flattenVirtualMachineDatastoreIDs(d, vprops.Datastore)
 
 	isImported := d.Get("imported").(bool)
 	if isImported {
@@ -554,6 +704,18 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 			return err
 		}
 		_ = d.Set("storage_policy_id", polID)
+
+		// Read the replication group of the VM configuration, but only when one
+		// is being tracked. Lookup failures are logged rather than failing the
+		// read, as not every PBM endpoint supports replication queries.
+		if d.Get("replication_group_id").(string) != "" {
+			rgID, err := spbm.ReplicationGroupIDByVirtualMachine(client, moid)
+			if err != nil {
+				log.Printf("[WARN] %s: could not read replication group: %s", resourceVSphereVirtualMachineIDString(d), err)
+			} else {
+				_ = d.Set("replication_group_id", rgID)
+			}
+		}
 	}
 
 	// Read the virtual machine PCI passthrough devices
@@ -590,6 +752,26 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 	if err := virtualdevice.CdromRefreshOperation(d, client, devices); err != nil {
 		return err
 	}
+	// NVDIMM
+	if err := virtualdevice.NvdimmRefreshOperation(d, devices); err != nil {
+		return err
+	}
+	// Watchdog timer
+	if err := virtualdevice.WatchdogTimerRefreshOperation(d, devices); err != nil {
+		return err
+	}
+	// Precision clock
+	if err := virtualdevice.PrecisionClockRefreshOperation(d, devices); err != nil {
+		return err
+	}
+	// Serial ports
+	if err := virtualdevice.SerialPortRefreshOperation(d, devices); err != nil {
+		return err
+	}
+	// Floppy drives
+	if err := virtualdevice.FloppyRefreshOperation(d, devices); err != nil {
+		return err
+	}
 
 	// Read tags if we have the ability to do so
 	if tagsClient, _ := meta.(*Client).TagsManager(); tagsClient != nil {
@@ -607,20 +789,19 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 	// provisioning. This also populates some computed values to present to the
 	// user.
 	if vprops.Guest != nil {
-		if err := buildAndSelectGuestIPs(d, *vprops.Guest); err != nil {
+		if err := buildAndSelectGuestIPs(d, *vprops.Guest, expandGuestIPSelectionOptions(d)); err != nil {
 			return fmt.Errorf("error reading virtual machine guest data: %s", err)
 		}
+		if err := flattenGuestReadiness(d, *vprops.Guest); err != nil {
+			return fmt.Errorf("error reading virtual machine guest state: %s", err)
+		}
 	}
 
 	// Get the power state for the virtual machine.
-	switch vprops.Runtime.PowerState {
-	case types.VirtualMachinePowerStatePoweredOn:
-		_ = d.Set("power_state", "on")
-	case types.VirtualMachinePowerStatePoweredOff:
-		_ = d.Set("power_state", "off")
-	case types.VirtualMachinePowerStateSuspended:
-		_ = d.Set("power_state", "suspended")
-	}
+	flattenVirtualMachinePowerState(d, vprops.Runtime.PowerState)
+
+	// Read the live usage statistics for the virtual machine.
+	flattenVirtualMachineQuickStats(d, vprops.Runtime.PowerState, vprops.Summary.QuickStats)
 
 	// Set the virtual Trusted Platform Module device for the virtual machine.
 	var isVTPMPresent bool
@@ -742,6 +923,21 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 	if err != nil {
 		return fmt.Errorf("error fetching VM properties: %s", err)
 	}
+	if vprops.Config == nil {
+		return fmt.Errorf("virtual machine is %s and its configuration cannot be updated", vprops.Runtime.ConnectionState)
+	}
+
+	// Clear a stuck pending customization first, as it can make the
+	// reconfigure fail.
+	if d.Get("clear_pending_customization").(bool) && virtualmachine.HasPendingCustomization(vprops) {
+		if err := virtualmachine.ClearPendingCustomization(vm, timeout); err != nil {
+			return fmt.Errorf("error clearing pending customization: %s", err)
+		}
+		log.Printf("[INFO] %s: Cleared pending customization %s", resourceVSphereVirtualMachineIDString(d), vprops.Config.Tools.PendingCustomization)
+		if vprops, err = virtualmachine.Properties(vm); err != nil {
+			return fmt.Errorf("error fetching VM properties: %s", err)
+		}
+	}
 
 	spec, changed, err := expandVirtualMachineConfigSpecChanged(d, client, vprops.Config)
 	if err != nil {
@@ -759,9 +955,22 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 	if tv > cv {
 		_ = d.Set("reboot_required", true)
 	}
+	if stageExtraConfig(d, &spec, vprops.Runtime.PowerState) {
+		changed = changed && virtualMachineConfigSpecChangedWithoutExtraConfig(d)
+	} else if applyStagedExtraConfig(d, &spec, vprops.Runtime.PowerState) {
+		changed = true
+	}
 	if changed || len(spec.DeviceChange) > 0 {
 		// Check to see if we need to shutdown the VM for this process.
 		if d.Get("reboot_required").(bool) && vprops.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOff {
+			// Do not race a pending guest customization, such as sysprep, with the
+			// shutdown.
+			if vprops.Guest != nil && virtualmachine.IsCustomizationPending(vprops.Guest.CustomizationInfo) {
+				timeout := time.Duration(d.Get("customization_pending_timeout").(int)) * time.Minute
+				if err := virtualmachine.WaitForCustomizationComplete(client, vm, timeout); err != nil {
+					return fmt.Errorf("error waiting for pending customization: %s", err)
+				}
+			}
 			// Attempt a graceful shutdown of this process. We wrap this in a VM helper.
 			timeout := d.Get("shutdown_wait_timeout").(int)
 			force := d.Get("force_power_off").(bool)
@@ -870,6 +1079,12 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 		}
 	}
 
+	// Upgrade out of date VMware Tools last, as the virtual machine needs to be
+	// powered on with VMware Tools running.
+	if err := resourceVSphereVirtualMachineUpgradeTools(d, vm, timeout); err != nil {
+		return err
+	}
+
 	// Now safe to turn off partial mode.
 	d.Partial(false)
 	_ = d.Set("reboot_required", false)
@@ -980,6 +1195,14 @@ func resourceVSphereVirtualMachineCustomizeDiff(_ context.Context, d *schema.Res
 		return err
 	}
 
+	// Validate serial port and floppy drive sub-resources
+	if err := virtualdevice.SerialPortDiffOperation(d); err != nil {
+		return err
+	}
+	if err := virtualdevice.FloppyDiffOperation(d); err != nil {
+		return err
+	}
+
 	// Process changes to resource pool
 	if err := resourceVSphereVirtualMachineCustomizeDiffResourcePoolOperation(d); err != nil {
 		return err
@@ -1065,6 +1288,25 @@ func resourceVSphereVirtualMachineCustomizeDiff(_ context.Context, d *schema.Res
 		}
 	}
 
+	// Validate that content based read cache keys are not also managed through
+	// extra_config.
+	if err := validateContentBasedReadCacheExtraConfig(d.Get("extra_config").(map[string]interface{}), d.Get("content_based_read_cache").([]interface{})); err != nil {
+		return err
+	}
+
+	// Warn on boot retry delays that look like they were supplied in seconds.
+	resourceVSphereVirtualMachineCustomizeDiffBootRetryDelay(d)
+
+	// Warn on 32-bit guest IDs for virtual machines that look 64-bit.
+	if msg := guestIDBitnessWarning(d.Get("guest_id").(string), d.Get("memory").(int), d.Get("num_cpus").(int)); msg != "" {
+		log.Printf("[WARN] %s: %s", resourceVSphereVirtualMachineIDString(d), msg)
+	}
+
+	// Warn that affinity prevents vMotion and DRS automation.
+	if msg := affinityWarning(d.Get("cpu_affinity").([]interface{}), d.Get("memory_affinity").([]interface{})); msg != "" {
+		log.Printf("[WARN] %s: %s", resourceVSphereVirtualMachineIDString(d), msg)
+	}
+
 	// Validate hardware version changes.
 	cv, tv := d.GetChange("hardware_version")
 	err := virtualmachine.ValidateHardwareVersion(cv.(int), tv.(int))
@@ -1072,9 +1314,104 @@ func resourceVSphereVirtualMachineCustomizeDiff(_ context.Context, d *schema.Res
 		return err
 	}
 
+	// Replication groups are managed through SPBM, which is only available on
+	// vCenter 6.5 and later.
+	if d.HasChange("replication_group_id") && d.Get("replication_group_id").(string) != "" {
+		if !spbm.IsSupported(client) {
+			return errors.New("replication_group_id requires vCenter")
+		}
+		version := viapi.ParseVersionFromClient(client)
+		if version.Older(viapi.VSphereVersion{Product: version.Product, Major: 6, Minor: 5}) {
+			return fmt.Errorf("replication_group_id requires vSphere 6.5 or later, connected to %s", version)
+		}
+	}
+
+	// Record and replay is only available on versions before 6.0.
+	if d.HasChange("record_replay_enabled") {
+		if err := validateRecordReplay(d.Get("record_replay_enabled").(bool), viapi.ParseVersionFromClient(client)); err != nil {
+			return err
+		}
+	}
+
+	// Validate the prerequisites of virtual CPU performance counters.
+	if err := resourceVSphereVirtualMachineCustomizeDiffCPUPerformanceCounters(d); err != nil {
+		return err
+	}
+
+	// Show the removal of a pending customization in the plan when it is going
+	// to be cleared.
+	if err := resourceVSphereVirtualMachineCustomizeDiffPendingCustomization(d); err != nil {
+		return err
+	}
+
+	// Show the change to tools_version in the plan when out of date VMware
+	// Tools are going to be upgraded.
+	if d.Get("upgrade_tools_on_apply").(bool) &&
+		d.Get("tools_running_status").(string) == string(types.VirtualMachineToolsRunningStatusGuestToolsRunning) &&
+		toolsVersionNeedsUpgrade(d.Get("tools_version_status").(string)) {
+		if err := d.SetNewComputed("tools_version"); err != nil {
+			return err
+		}
+	}
+
+	// Validate that the firmware is consistent with secure boot and the guest
+	// ID. Skip the check if any of the values is not known yet.
+	if structure.ValuesAvailable("", []string{"firmware", "efi_secure_boot_enabled", "guest_id"}, d) {
+		if err := validateVirtualMachineFirmware(d.Get("firmware").(string), d.Get("efi_secure_boot_enabled").(bool), d.Get("guest_id").(string)); err != nil {
+			return err
+		}
+	}
+
+	// Validate that the number of CPUs can be split into sockets, such as after
+	// decreasing num_cpus.
+	if (d.HasChange("num_cpus") || d.HasChange("num_cores_per_socket")) && structure.ValuesAvailable("", []string{"num_cpus", "num_cores_per_socket"}, d) {
+		if err := validateCPUTopology(d.Get("num_cpus").(int), d.Get("num_cores_per_socket").(int)); err != nil {
+			return err
+		}
+	}
+
+	// Validate that the memory reservation does not conflict with locking it to
+	// the memory size, and that the memory size is valid for the reservation
+	// and the hot-add limits.
+	if err := resourceVSphereVirtualMachineCustomizeDiffMemory(d, client); err != nil {
+		return err
+	}
+
+	// Validate that the hardware version is supported by the target compute
+	// resource.
+	if err := resourceVSphereVirtualMachineCustomizeDiffHardwareVersion(d, client); err != nil {
+		return err
+	}
+
+	// Validate that the hardware version supports NVDIMM devices.
+	if err := validateDeviceHardwareVersion("nvdimm", len(d.Get("nvdimm").([]interface{})), d.Get("hardware_version").(int), virtualMachineNVDIMMMinHardwareVersion); err != nil {
+		return err
+	}
+
+	// Validate that the hardware version and the connected vSphere version
+	// support a watchdog timer.
+	if err := resourceVSphereVirtualMachineCustomizeDiffWatchdogTimer(d, client); err != nil {
+		return err
+	}
+
+	// Warn if the swap file cannot be placed on the swap datastore of the host.
+	resourceVSphereVirtualMachineCustomizeDiffSwapPlacement(d, client)
+
+	// Validate that the hardware version, the connected vSphere version, and
+	// the host support a precision clock.
+	if err := resourceVSphereVirtualMachineCustomizeDiffPrecisionClock(d, client); err != nil {
+		return err
+	}
+
 	// Validate that the config has the necessary components for vApp support.
 	// Note that for clones the data is prepopulated in
-	// ValidateVirtualMachineClone.
+	// ValidateVirtualMachineClone. A configured OVF environment transport
+	// replaces the current one.
+	if transport := d.Get("vapp.0.ovf_environment_transport").([]interface{}); len(transport) > 0 && d.HasChange("vapp.0.ovf_environment_transport") {
+		if err := d.SetNew("vapp_transport", transport); err != nil {
+			return err
+		}
+	}
 	if err = virtualdevice.VerifyVAppTransport(d); err != nil {
 		return err
 	}
@@ -1096,6 +1433,231 @@ func resourceVSphereVirtualMachineCustomizeDiffResourcePoolOperation(d *schema.R
 	return nil
 }
 
+// resourceVSphereVirtualMachineCustomizeDiffMemory checks that the memory
+// size is valid for the configured reservation and, when memory is going to be
+// hot-added, for the hot-add limits of the virtual machine. This fails before
+// the virtual machine is powered off or reconfigured for the change.
+func resourceVSphereVirtualMachineCustomizeDiffMemory(d *schema.ResourceDiff, client *govmomi.Client) error {
+	if !structure.ValuesAvailable("", []string{"memory", "memory_reservation"}, d) {
+		return nil
+	}
+	memory := d.Get("memory").(int)
+	// A reservation locked to the memory size, or set by latency sensitivity,
+	// is read back into state, so only a configured reservation can conflict
+	// with the memory size or the lock.
+	reservation := configuredMemoryReservation(d)
+	if err := validateMemorySize(memory, reservation); err != nil {
+		return err
+	}
+	if err := validateMemoryReservationLockedToMax(d.Get("memory_reservation_locked_to_max").(bool), memory, reservation); err != nil {
+		return err
+	}
+
+	// Memory is hot-added to powered on virtual machines that had hot-add
+	// enabled before the change. See isHotApplicableChange.
+	o, n := d.GetChange("memory")
+	hotAdd, _ := d.GetChange("memory_hot_add_enabled")
+	if d.Id() == "" || n.(int) <= o.(int) || !hotAdd.(bool) || d.Get("power_state").(string) != "on" {
+		return nil
+	}
+	vm, err := virtualmachine.FromUUID(client, d.Id())
+	if err != nil {
+		log.Printf("[WARN] %s: Could not find virtual machine to validate the memory hot-add limits: %s", resourceVSphereVirtualMachineIDString(d), err)
+		return nil
+	}
+	vprops, err := virtualmachine.Properties(vm)
+	if err != nil || vprops.Config == nil {
+		log.Printf("[WARN] %s: Could not read the memory hot-add limits of the virtual machine: %v", resourceVSphereVirtualMachineIDString(d), err)
+		return nil
+	}
+	return validateMemoryHotAdd(o.(int), n.(int), vprops.Config.HotPlugMemoryLimit, vprops.Config.HotPlugMemoryIncrementSize)
+}
+
+// resourceVSphereVirtualMachineCustomizeDiffBootRetryDelay logs a warning
+// when boot retry is enabled with a boot_retry_delay that is suspiciously
+// small or large, which usually indicates the value was supplied in seconds
+// rather than milliseconds.
+func resourceVSphereVirtualMachineCustomizeDiffBootRetryDelay(d *schema.ResourceDiff) {
+	if !d.Get("boot_retry_enabled").(bool) {
+		return
+	}
+	if msg := bootRetryDelayWarning(d.Get("boot_retry_delay").(int)); msg != "" {
+		log.Printf("[WARN] %s: %s", resourceVSphereVirtualMachineIDString(d), msg)
+	}
+}
+
+// resourceVSphereVirtualMachineCustomizeDiffCPUPerformanceCounters checks that
+// the hardware version supports virtual CPU performance counters when they are
+// enabled, and warns about the migration limitations that come with them.
+func resourceVSphereVirtualMachineCustomizeDiffCPUPerformanceCounters(d *schema.ResourceDiff) error {
+	enabled := d.Get("cpu_performance_counters_enabled").(bool)
+	if err := validateCPUPerformanceCounters(enabled, d.Get("hardware_version").(int)); err != nil {
+		return err
+	}
+	if enabled && d.HasChange("cpu_performance_counters_enabled") {
+		log.Printf(
+			"[WARN] %s: CPU performance counters are enabled. The virtual machine can only be migrated with vMotion to hosts that support the same performance counters, and cannot use Fault Tolerance",
+			resourceVSphereVirtualMachineIDString(d),
+		)
+	}
+	return nil
+}
+
+// resourceVSphereVirtualMachineCustomizeDiffPendingCustomization plans the
+// removal of the pending customization of the virtual machine when
+// clear_pending_customization is set. This produces a diff, so that a pending
+// customization is cleared even when nothing else changes.
+func resourceVSphereVirtualMachineCustomizeDiffPendingCustomization(d *schema.ResourceDiff) error {
+	if d.Get("clear_pending_customization").(bool) && d.Get("pending_customization").(string) != "" {
+		return d.SetNew("pending_customization", "")
+	}
+	return nil
+}
+
+// resourceVSphereVirtualMachineCustomizeDiffWatchdogTimer checks that a
+// configured watchdog timer is supported by the hardware version of the
+// virtual machine and by the connected vSphere version.
+func resourceVSphereVirtualMachineCustomizeDiffWatchdogTimer(d *schema.ResourceDiff, client *govmomi.Client) error {
+	count := len(d.Get("watchdog_timer").([]interface{}))
+	if err := validateDeviceHardwareVersion("watchdog_timer", count, d.Get("hardware_version").(int), virtualMachineWatchdogTimerMinHardwareVersion); err != nil {
+		return err
+	}
+	if count > 0 && d.HasChange("watchdog_timer") {
+		version := viapi.ParseVersionFromClient(client)
+		if version.Older(viapi.VSphereVersion{Product: version.Product, Major: 7}) {
+			return fmt.Errorf("watchdog_timer requires vSphere 7.0 or later, connected to %s", version)
+		}
+	}
+	return nil
+}
+
+// resourceVSphereVirtualMachineCustomizeDiffPrecisionClock checks that a
+// configured precision clock is supported by the hardware version of the
+// virtual machine and by the connected vSphere version. When the precision
+// clock uses ptp and the host of the virtual machine is known, the system
+// clock of the host must also be disciplined by PTP. The host check is skipped
+// if the host does not report its protocol or cannot be looked up.
+func resourceVSphereVirtualMachineCustomizeDiffPrecisionClock(d *schema.ResourceDiff, client *govmomi.Client) error {
+	config := d.Get("precision_clock").([]interface{})
+	if err := validateDeviceHardwareVersion("precision_clock", len(config), d.Get("hardware_version").(int), virtualMachinePrecisionClockMinHardwareVersion); err != nil {
+		return err
+	}
+	if len(config) == 0 || (!d.HasChange("precision_clock") && !d.HasChange("host_system_id")) {
+		return nil
+	}
+	version := viapi.ParseVersionFromClient(client)
+	if version.Older(viapi.VSphereVersion{Product: version.Product, Major: 7}) {
+		return fmt.Errorf("precision_clock requires vSphere 7.0 or later, connected to %s", version)
+	}
+
+	protocol := string(types.HostDateTimeInfoProtocolPtp)
+	if m, ok := config[0].(map[string]interface{}); ok {
+		protocol = m["protocol"].(string)
+	}
+	if protocol != string(types.HostDateTimeInfoProtocolPtp) || !structure.ValuesAvailable("", []string{"host_system_id"}, d) {
+		return nil
+	}
+	hostID := d.Get("host_system_id").(string)
+	if hostID == "" {
+		return nil
+	}
+	host, err := hostsystem.FromID(client, hostID)
+	if err != nil {
+		log.Printf("[WARN] %s: Could not find host %q to validate precision_clock: %s", resourceVSphereVirtualMachineIDString(d), hostID, err)
+		return nil
+	}
+	hostProtocol, err := hostsystem.SystemClockProtocol(host)
+	if err != nil {
+		log.Printf("[WARN] %s: Could not query the system clock protocol of host %q to validate precision_clock: %s", resourceVSphereVirtualMachineIDString(d), hostID, err)
+		return nil
+	}
+	if hostProtocol != "" && hostProtocol != protocol {
+		return fmt.Errorf("precision_clock with protocol %s requires a PTP source on host %s, but its system clock uses %s", protocol, hostsystem.NameOrID(client, hostID), hostProtocol)
+	}
+	return nil
+}
+
+// resourceVSphereVirtualMachineCustomizeDiffSwapPlacement logs a warning when
+// swap_placement_policy is changed to hostLocal and the configured host has no
+// swap datastore. vSphere then places the swap file in the directory of the
+// virtual machine instead. The check is skipped if the host is not known or
+// cannot be looked up.
+func resourceVSphereVirtualMachineCustomizeDiffSwapPlacement(d *schema.ResourceDiff, client *govmomi.Client) {
+	if !d.HasChange("swap_placement_policy") && !d.HasChange("host_system_id") {
+		return
+	}
+	if d.Get("swap_placement_policy").(string) != string(types.VirtualMachineConfigInfoSwapPlacementTypeHostLocal) {
+		return
+	}
+	if !structure.ValuesAvailable("", []string{"host_system_id"}, d) || d.Get("host_system_id").(string) == "" {
+		return
+	}
+	hostID := d.Get("host_system_id").(string)
+	host, err := hostsystem.FromID(client, hostID)
+	if err != nil {
+		log.Printf("[WARN] %s: Could not find host %q to validate swap_placement_policy: %s", resourceVSphereVirtualMachineIDString(d), hostID, err)
+		return
+	}
+	props, err := hostsystem.Properties(host)
+	if err != nil {
+		log.Printf("[WARN] %s: Could not query host %q to validate swap_placement_policy: %s", resourceVSphereVirtualMachineIDString(d), hostID, err)
+		return
+	}
+	if props.Config != nil && props.Config.LocalSwapDatastore == nil {
+		log.Printf(
+			"[WARN] %s: swap_placement_policy is hostLocal, but host %q has no swap datastore. The swap file is placed in the directory of the virtual machine",
+			resourceVSphereVirtualMachineIDString(d),
+			hostsystem.NameOrID(client, hostID),
+		)
+	}
+}
+
+// resourceVSphereVirtualMachineCustomizeDiffHardwareVersion checks a changed
+// hardware_version against the latest version supported by the connected
+// vSphere version, and by the compute resource that owns the resource pool of
+// the virtual machine. The compute resource check is skipped if the resource
+// pool is not known yet or its supported versions cannot be looked up.
+func resourceVSphereVirtualMachineCustomizeDiffHardwareVersion(d *schema.ResourceDiff, client *govmomi.Client) error {
+	if !d.HasChange("hardware_version") || !structure.ValuesAvailable("", []string{"hardware_version"}, d) {
+		return nil
+	}
+	target := d.Get("hardware_version").(int)
+	if target == 0 {
+		return nil
+	}
+
+	version := viapi.ParseVersionFromClient(client)
+	if maxVersion := viapi.MaxHardwareVersion(version); target > maxVersion {
+		return fmt.Errorf("hardware_version %d is not supported by %s, the latest supported version is %d", target, version, maxVersion)
+	}
+
+	if !structure.ValuesAvailable("", []string{"resource_pool_id"}, d) {
+		return nil
+	}
+	poolID := d.Get("resource_pool_id").(string)
+	pool, err := resourcepool.FromID(client, poolID)
+	if err != nil {
+		log.Printf("[WARN] %s: Could not find resource pool %q to validate hardware_version: %s", resourceVSphereVirtualMachineIDString(d), poolID, err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	owner, err := pool.Owner(ctx)
+	if err != nil {
+		log.Printf("[WARN] %s: Could not find compute resource of resource pool %q to validate hardware_version: %s", resourceVSphereVirtualMachineIDString(d), poolID, err)
+		return nil
+	}
+	maxVersion, err := computeresource.MaxHardwareVersion(client, owner.Reference())
+	if err != nil {
+		log.Printf("[WARN] %s: Could not query supported hardware versions of %s to validate hardware_version: %s", resourceVSphereVirtualMachineIDString(d), owner.Reference().Value, err)
+		return nil
+	}
+	if maxVersion > 0 && target > maxVersion {
+		return fmt.Errorf("hardware_version %d is not supported by compute resource %s, the latest supported version is %d", target, owner.Reference().Value, maxVersion)
+	}
+	return nil
+}
+
 func datastoreClusterDiffOperation(d *schema.ResourceDiff, client *govmomi.Client) error {
 	if !structure.ValuesAvailable("", []string{"datastore_cluster_id", "datastore_id"}, d) {
 		log.Printf("[DEBUG] DatastoreClusterDiffOperation: datastore_id or datastore_cluster_id value depends on a computed value from another resource. Skipping validation.")
@@ -1221,17 +1783,35 @@ func resourceVSphereVirtualMachineImport(d *schema.ResourceData, meta interface{
 	d.SetId(props.Config.Uuid)
 	_ = d.Set("imported", true)
 
+	// Read only refreshes the replication group once it is tracked, so read the
+	// current one here. As in Read, a failed lookup is only logged.
+	if spbm.IsSupported(client) {
+		rgID, err := spbm.ReplicationGroupIDByVirtualMachine(client, vm.Reference().Value)
+		if err != nil {
+			log.Printf("[WARN] %s: could not read replication group: %s", resourceVSphereVirtualMachineIDString(d), err)
+		} else {
+			_ = d.Set("replication_group_id", rgID)
+		}
+	}
+
 	// Set some defaults. This helps possibly prevent diffs where these values
 	// have not been changed.
 	rs := resourceVSphereVirtualMachine().Schema
 	_ = d.Set("force_power_off", rs["force_power_off"].Default)
 	_ = d.Set("migrate_wait_timeout", rs["migrate_wait_timeout"].Default)
 	_ = d.Set("shutdown_wait_timeout", rs["shutdown_wait_timeout"].Default)
+	_ = d.Set("customization_pending_timeout", rs["customization_pending_timeout"].Default)
+	_ = d.Set("clear_pending_customization", rs["clear_pending_customization"].Default)
+	_ = d.Set("upgrade_tools_on_apply", rs["upgrade_tools_on_apply"].Default)
 	_ = d.Set("wait_for_guest_ip_timeout", rs["wait_for_guest_ip_timeout"].Default)
 	_ = d.Set("wait_for_guest_net_timeout", rs["wait_for_guest_net_timeout"].Default)
 	_ = d.Set("wait_for_guest_net_routable", rs["wait_for_guest_net_routable"].Default)
 	_ = d.Set("poweron_timeout", rs["poweron_timeout"].Default)
 	_ = d.Set("extra_config_reboot_required", rs["extra_config_reboot_required"].Default)
+	_ = d.Set("extra_config_apply_on_reboot", rs["extra_config_apply_on_reboot"].Default)
+	_ = d.Set("vapp_config_removal_enabled", rs["vapp_config_removal_enabled"].Default)
+	_ = d.Set("ip_version_preference", rs["ip_version_preference"].Default)
+	_ = d.Set("include_link_local_guest_ips", rs["include_link_local_guest_ips"].Default)
 
 	log.Printf("[DEBUG] %s: Import complete, resource is ready for read", resourceVSphereVirtualMachineIDString(d))
 	return []*schema.ResourceData{d}, nil
@@ -1473,6 +2053,9 @@ func createVCenterDeploy(d *schema.ResourceData, meta interface{}) (*virtualmach
 	if err != nil {
 		return nil, err
 	}
+	if err := contentlibrary.ValidateItemType(item, library.ItemTypeOVF, library.ItemTypeVMTX); err != nil {
+		return nil, err
+	}
 
 	poolID := d.Get("resource_pool_id").(string)
 	poolObj, err := resourcepool.FromID(vimClient, poolID)
@@ -1775,6 +2358,67 @@ func resourceVSphereVirtualMachinePostDeployChanges(d *schema.ResourceData, meta
 		)
 	}
 	cfgSpec.DeviceChange = virtualdevice.AppendDeviceChangeSpec(cfgSpec.DeviceChange, delta...)
+
+	// NVDIMM
+	devices, delta, err = virtualdevice.NvdimmApplyOperation(d, devices)
+	if err != nil {
+		return resourceVSphereVirtualMachineRollbackCreate(
+			d,
+			meta,
+			vm,
+			fmt.Errorf("error processing NVDIMM device changes post-clone: %s", err),
+		)
+	}
+	cfgSpec.DeviceChange = virtualdevice.AppendDeviceChangeSpec(cfgSpec.DeviceChange, delta...)
+
+	// Watchdog timer
+	devices, delta, err = virtualdevice.WatchdogTimerApplyOperation(d, devices)
+	if err != nil {
+		return resourceVSphereVirtualMachineRollbackCreate(
+			d,
+			meta,
+			vm,
+			fmt.Errorf("error processing watchdog timer changes post-clone: %s", err),
+		)
+	}
+	cfgSpec.DeviceChange = virtualdevice.AppendDeviceChangeSpec(cfgSpec.DeviceChange, delta...)
+
+	// Precision clock
+	devices, delta, err = virtualdevice.PrecisionClockApplyOperation(d, devices)
+	if err != nil {
+		return resourceVSphereVirtualMachineRollbackCreate(
+			d,
+			meta,
+			vm,
+			fmt.Errorf("error processing precision clock changes post-clone: %s", err),
+		)
+	}
+	cfgSpec.DeviceChange = virtualdevice.AppendDeviceChangeSpec(cfgSpec.DeviceChange, delta...)
+
+	// Serial ports
+	devices, delta, err = virtualdevice.SerialPortApplyOperation(d, client, devices)
+	if err != nil {
+		return resourceVSphereVirtualMachineRollbackCreate(
+			d,
+			meta,
+			vm,
+			fmt.Errorf("error processing serial port changes post-clone: %s", err),
+		)
+	}
+	cfgSpec.DeviceChange = virtualdevice.AppendDeviceChangeSpec(cfgSpec.DeviceChange, delta...)
+
+	// Floppy drives
+	devices, delta, err = virtualdevice.FloppyApplyOperation(d, client, devices)
+	if err != nil {
+		return resourceVSphereVirtualMachineRollbackCreate(
+			d,
+			meta,
+			vm,
+			fmt.Errorf("error processing floppy drive changes post-clone: %s", err),
+		)
+	}
+	cfgSpec.DeviceChange = virtualdevice.AppendDeviceChangeSpec(cfgSpec.DeviceChange, delta...)
+	cfgSpec.DeviceChange = virtualdevice.OrderDeviceChangeSpec(cfgSpec.DeviceChange)
 	log.Printf("[DEBUG] %s: Final device list: %s", resourceVSphereVirtualMachineIDString(d), virtualdevice.DeviceListString(devices))
 	log.Printf("[DEBUG] %s: Final device change cfgSpec: %s", resourceVSphereVirtualMachineIDString(d), virtualdevice.DeviceChangeString(cfgSpec.DeviceChange))
 
@@ -2086,6 +2730,37 @@ func applyVirtualDevices(d *schema.ResourceData, c *govmomi.Client, l object.Vir
 		return nil, err
 	}
 	spec = virtualdevice.AppendDeviceChangeSpec(spec, delta...)
+	// NVDIMM
+	l, delta, err = virtualdevice.NvdimmApplyOperation(d, l)
+	if err != nil {
+		return nil, err
+	}
+	spec = virtualdevice.AppendDeviceChangeSpec(spec, delta...)
+	// Watchdog timer
+	l, delta, err = virtualdevice.WatchdogTimerApplyOperation(d, l)
+	if err != nil {
+		return nil, err
+	}
+	spec = virtualdevice.AppendDeviceChangeSpec(spec, delta...)
+	// Precision clock
+	l, delta, err = virtualdevice.PrecisionClockApplyOperation(d, l)
+	if err != nil {
+		return nil, err
+	}
+	spec = virtualdevice.AppendDeviceChangeSpec(spec, delta...)
+	// Serial ports
+	l, delta, err = virtualdevice.SerialPortApplyOperation(d, c, l)
+	if err != nil {
+		return nil, err
+	}
+	spec = virtualdevice.AppendDeviceChangeSpec(spec, delta...)
+	// Floppy drives
+	l, delta, err = virtualdevice.FloppyApplyOperation(d, c, l)
+	if err != nil {
+		return nil, err
+	}
+	spec = virtualdevice.AppendDeviceChangeSpec(spec, delta...)
+	spec = virtualdevice.OrderDeviceChangeSpec(spec)
 	log.Printf("[DEBUG] %s: Final device list: %s", resourceVSphereVirtualMachineIDString(d), virtualdevice.DeviceListString(l))
 	log.Printf("[DEBUG] %s: Final device change spec: %s", resourceVSphereVirtualMachineIDString(d), virtualdevice.DeviceChangeString(spec))
 	return spec, nil
@@ -2103,6 +2778,56 @@ func getNewDisks(delta []types.BaseVirtualDeviceConfigSpec) []types.BaseVirtualD
 	return result
 }
 
+// flattenVirtualMachineDatastoreIDs saves the IDs of the datastores that the
+// virtual machine has files on, sorted by ID.
+func flattenVirtualMachineDatastoreIDs(d *schema.ResourceData, refs []types.ManagedObjectReference) {
+	sorted := make([]types.ManagedObjectReference, len(refs))
+	copy(sorted, refs)
+	sort.Sort(structure.MoRefSorter(sorted))
+	ids := make([]string, 0, len(sorted))
+	for _, ref := range sorted {
+		ids = append(ids, ref.Value)
+	}
+	_ = d.Set("datastore_ids", ids)
+}
+
+// virtualMachineSwapDatastoreName returns the name of the datastore that holds
+// the swap file of a virtual machine, or an empty string if the virtual machine
+// has no swap file. The swap file only exists while the virtual machine is
+// powered on or suspended.
+func virtualMachineSwapDatastoreName(props *mo.VirtualMachine) string {
+	var path string
+	if props.LayoutEx != nil {
+		for _, file := range props.LayoutEx.File {
+			if file.Type == string(types.VirtualMachineFileLayoutExFileTypeSwap) {
+				path = file.Name
+				break
+			}
+		}
+	}
+	if path == "" && props.Layout != nil {
+		path = props.Layout.SwapFile
+	}
+	dp := &object.DatastorePath{}
+	if path == "" || !dp.FromString(path) {
+		return ""
+	}
+	return dp.Datastore
+}
+
+// flattenVirtualMachineQuickStats saves the live usage statistics from the
+// virtual machine's quick stats. The statistics are only meaningful while the
+// virtual machine is running, so they are zeroed for any other power state.
+func flattenVirtualMachineQuickStats(d *schema.ResourceData, powerState types.VirtualMachinePowerState, stats types.VirtualMachineQuickStats) {
+	if powerState != types.VirtualMachinePowerStatePoweredOn {
+		stats = types.VirtualMachineQuickStats{}
+	}
+	_ = d.Set("overall_cpu_usage", stats.OverallCpuUsage)
+	_ = d.Set("host_memory_usage", stats.HostMemoryUsage)
+	_ = d.Set("guest_memory_usage", stats.GuestMemoryUsage)
+	_ = d.Set("uptime_seconds", stats.UptimeSeconds)
+}
+
 // resourceVSphereVirtualMachineIDString prints a friendly string for the
 // vsphere_virtual_machine resource.
 func resourceVSphereVirtualMachineIDString(d structure.ResourceIDStringer) string {
@@ -9,17 +9,34 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/viapi"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
 )
 
+// snapshotRetryDelay is the delay before the first retry of a snapshot
+// operation that failed with a retryable fault. It doubles with each further
+// retry.
+const snapshotRetryDelay = 5 * time.Second
+
 func resourceVSphereVirtualMachineSnapshot() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceVSphereVirtualMachineSnapshotCreate,
 		Read:   resourceVSphereVirtualMachineSnapshotRead,
+		Update: resourceVSphereVirtualMachineSnapshotUpdate,
 		Delete: resourceVSphereVirtualMachineSnapshotDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereVirtualMachineSnapshotImport,
+		},
+		CustomizeDiff: resourceVSphereVirtualMachineSnapshotCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"virtual_machine_uuid": {
@@ -30,12 +47,10 @@ func resourceVSphereVirtualMachineSnapshot() *schema.Resource {
 			"snapshot_name": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 			"description": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 			"memory": {
 				Type:     schema.TypeBool,
@@ -47,6 +62,45 @@ func resourceVSphereVirtualMachineSnapshot() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 			},
+			"quiesce_spec": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Description: "Options for quiescing the guest when the snapshot is taken. Requires quiesce to be true.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"timeout": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Description:  "The maximum amount of time, in minutes, to quiesce the guest. Must be between 5 and 240.",
+							ValidateFunc: validation.IntBetween(5, 240),
+						},
+						"vss_backup_type": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Description:  "The type of VSS backup operation to perform in a Windows guest, as a VSS_BACKUP_TYPE value. 0 leaves the backup type unset.",
+							ValidateFunc: validation.IntBetween(0, 6),
+						},
+						"vss_backup_context": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  "The context of the VSS backup operation to perform in a Windows guest. One of ctx_auto, ctx_backup, or ctx_file_share_backup.",
+							ValidateFunc: validation.StringInSlice(snapshotVssBackupContexts(), false),
+						},
+						"vss_bootable_system_state": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Description: "Include a bootable system state in the VSS backup of a Windows guest.",
+						},
+						"vss_partial_file_support": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Description: "Enable partial file support in the VSS backup of a Windows guest.",
+						},
+					},
+				},
+			},
 			"remove_children": {
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -57,38 +111,210 @@ func resourceVSphereVirtualMachineSnapshot() *schema.Resource {
 				Optional: true,
 				ForceNew: true,
 			},
+			"auto_consolidate": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Consolidate the virtual machine's disks after the snapshot is deleted if vSphere reports that consolidation is still needed.",
+			},
+			"consolidate_on_create": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Consolidate the virtual machine's disks before the snapshot is created if vSphere reports that consolidation is needed.",
+			},
+			"max_chain_length": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "The maximum length of the snapshot chain of the virtual machine, including the new snapshot. Creating the snapshot fails if the chain would be longer.",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "The amount of time, in minutes, to wait for the snapshot to be created or deleted. Defaults to the API timeout of the provider.",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				Description:  "The number of times to retry creating or deleting the snapshot when vSphere reports that another task is in progress on the virtual machine, or that the virtual machine is temporarily not found.",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"create_time": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The time, in RFC 3339 format, at which the snapshot was taken.",
+			},
+			"size_bytes": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The size of the snapshot files on the datastore, in bytes.",
+			},
+			"power_state_at_snapshot": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The power state of the virtual machine when the snapshot was taken.",
+			},
+			"is_current_snapshot": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether this is the current snapshot of the virtual machine.",
+			},
 		},
 	}
 }
 
+func resourceVSphereVirtualMachineSnapshotCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !structure.ValuesAvailable("", []string{"quiesce"}, d) {
+		return nil
+	}
+	return validateVirtualMachineSnapshotQuiesceSpec(d.Get("quiesce").(bool), d.Get("quiesce_spec").([]interface{}))
+}
+
 func resourceVSphereVirtualMachineSnapshotCreate(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client).vimClient
 	vm, err := virtualmachine.FromUUID(client, d.Get("virtual_machine_uuid").(string))
 	if err != nil {
 		return fmt.Errorf("error while getting the virtual machine :%s", err)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout) // This is 5 mins
-	defer cancel()
-	task, err := vm.CreateSnapshot(ctx, d.Get("snapshot_name").(string), d.Get("description").(string), d.Get("memory").(bool), d.Get("quiesce").(bool))
-	if err != nil {
-		log.Printf("[DEBUG] Error while creating for the create snapshot task: %v", err)
-		return fmt.Errorf("error while creating for the create snapshot task: %s", err)
+	if d.Get("consolidate_on_create").(bool) {
+		consolidated, err := virtualmachine.ConsolidateDisksIfNeeded(vm)
+		if err != nil {
+			return fmt.Errorf("error while consolidating disks before creating snapshot: %s", err)
+		}
+		if consolidated {
+			log.Printf("[DEBUG] Consolidated disks before creating snapshot %v", d.Get("snapshot_name").(string))
+		}
+	}
+	if v, ok := d.GetOk("max_chain_length"); ok {
+		props, err := virtualmachine.Properties(vm)
+		if err != nil {
+			return fmt.Errorf("error while getting the virtual machine properties :%s", err)
+		}
+		if err := validateVirtualMachineSnapshotChainLength(props, v.(int)); err != nil {
+			return err
+		}
 	}
-	log.Printf("[DEBUG] Task created for create snapshot: %v", task)
 
-	tctx, tcancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	quiesceSpec := expandVirtualMachineSnapshotQuiesceSpec(d)
+
+	tctx, tcancel := context.WithTimeout(context.Background(), snapshotTimeout(d))
 	defer tcancel()
-	taskInfo, err := task.WaitForResultEx(tctx, nil)
+	var taskInfo *types.TaskInfo
+	err = viapi.RetryOnTaskFault(tctx, d.Get("max_retries").(int), snapshotRetryDelay, isRetryableSnapshotCreateFault, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout) // This is 5 mins
+		defer cancel()
+		var task *object.Task
+		if quiesceSpec != nil {
+			task, err = vm.CreateSnapshotEx(ctx, d.Get("snapshot_name").(string), d.Get("description").(string), d.Get("memory").(bool), quiesceSpec)
+		} else {
+			task, err = vm.CreateSnapshot(ctx, d.Get("snapshot_name").(string), d.Get("description").(string), d.Get("memory").(bool), d.Get("quiesce").(bool))
+		}
+		if err != nil {
+			log.Printf("[DEBUG] Error while creating for the create snapshot task: %v", err)
+			return fmt.Errorf("error while creating for the create snapshot task: %w", err)
+		}
+		log.Printf("[DEBUG] Task created for create snapshot: %v", task)
+
+		taskInfo, err = viapi.WaitForTaskResult(tctx, task)
+		if err != nil {
+			log.Printf("[DEBUG] Error while waiting for the create snapshot task: %v", err)
+			if viapi.IsQuiesceFault(err) {
+				return fmt.Errorf("could not quiesce the guest of the virtual machine for the snapshot: %w", err)
+			}
+			return fmt.Errorf(" error while waiting for the create snapshot task: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("[DEBUG] Error while waiting for the create snapshot task: %v", err)
-		return fmt.Errorf(" error while waiting for the create snapshot task: %s", err)
+		return err
 	}
 	log.Printf("[DEBUG] Create snapshot completed %v", d.Get("snapshot_name").(string))
 	log.Println("[DEBUG] Managed Object Reference: " + taskInfo.Result.(types.ManagedObjectReference).Value)
 	d.SetId(taskInfo.Result.(types.ManagedObjectReference).Value)
+	return resourceVSphereVirtualMachineSnapshotRead(d, meta)
+}
+
+// isRetryableSnapshotCreateFault checks if creating a snapshot should be
+// retried after err. In addition to the faults covered by
+// viapi.IsRetryableTaskFault, ManagedObjectNotFound is retried, as vSphere can
+// return it for a short time after a virtual machine is cloned. When deleting
+// a snapshot, ManagedObjectNotFound instead means that the snapshot is gone.
+func isRetryableSnapshotCreateFault(err error) bool {
+	return viapi.IsRetryableTaskFault(err) || viapi.IsManagedObjectNotFoundTaskFault(err)
+}
+
+// validateVirtualMachineSnapshotChainLength checks that a new snapshot, which
+// is taken as a child of the current snapshot of the virtual machine, does not
+// make the snapshot chain longer than maxLength.
+func validateVirtualMachineSnapshotChainLength(props *mo.VirtualMachine, maxLength int) error {
+	var depth int
+	if props.Snapshot != nil && props.Snapshot.CurrentSnapshot != nil {
+		depth = virtualmachine.SnapshotDepth(props.Snapshot.RootSnapshotList, props.Snapshot.CurrentSnapshot.Value)
+	}
+	if depth+1 > maxLength {
+		return fmt.Errorf("creating the snapshot would make the snapshot chain %d snapshots long, which exceeds max_chain_length of %d", depth+1, maxLength)
+	}
 	return nil
 }
 
+// validateVirtualMachineSnapshotQuiesceSpec checks that quiesce_spec is only
+// set when quiesce is enabled.
+func validateVirtualMachineSnapshotQuiesceSpec(quiesce bool, quiesceSpec []interface{}) error {
+	if len(quiesceSpec) > 0 && !quiesce {
+		return fmt.Errorf("quiesce_spec requires quiesce to be true")
+	}
+	return nil
+}
+
+// expandVirtualMachineSnapshotQuiesceSpec reads the quiesce_spec block into a
+// quiesce spec for CreateSnapshotEx. A Windows quiesce spec is returned if any
+// of the VSS options are set, with the backup type omitted if vss_backup_type
+// is 0. nil is returned if the block is not set.
+func expandVirtualMachineSnapshotQuiesceSpec(d *schema.ResourceData) types.BaseVirtualMachineGuestQuiesceSpec {
+	l := d.Get("quiesce_spec").([]interface{})
+	if len(l) == 0 {
+		return nil
+	}
+	spec := types.VirtualMachineGuestQuiesceSpec{}
+	m, ok := l[0].(map[string]interface{})
+	if !ok {
+		return &spec
+	}
+	spec.Timeout = int32(m["timeout"].(int))
+
+	backupType := m["vss_backup_type"].(int)
+	backupContext := m["vss_backup_context"].(string)
+	bootable := m["vss_bootable_system_state"].(bool)
+	partial := m["vss_partial_file_support"].(bool)
+	if backupType == 0 && backupContext == "" && !bootable && !partial {
+		return &spec
+	}
+	windowsSpec := &types.VirtualMachineWindowsQuiesceSpec{
+		VirtualMachineGuestQuiesceSpec: spec,
+		VssBackupContext:               backupContext,
+		VssBootableSystemState:         types.NewBool(bootable),
+		VssPartialFileSupport:          types.NewBool(partial),
+	}
+	// 0 means that vss_backup_type is not set, so leave the backup type for
+	// vSphere to choose rather than sending VSS_BT_UNDEFINED.
+	if backupType != 0 {
+		windowsSpec.VssBackupType = int32(backupType)
+	}
+	return windowsSpec
+}
+
+// snapshotVssBackupContexts returns the valid values of vss_backup_context.
+func snapshotVssBackupContexts() []string {
+	var contexts []string
+	for _, v := range types.VirtualMachineWindowsQuiesceSpecVssBackupContext("").Values() {
+		contexts = append(contexts, string(v))
+	}
+	return contexts
+}
+
 func resourceVSphereVirtualMachineSnapshotDelete(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client).vimClient
 	vm, err := virtualmachine.FromUUID(client, d.Get("virtual_machine_uuid").(string))
@@ -116,25 +342,88 @@ func resourceVSphereVirtualMachineSnapshotDelete(d *schema.ResourceData, meta in
 	} else {
 		removeChildren = false
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout) // This is 5 mins
-	defer cancel()
-	task, err := vm.RemoveSnapshot(ctx, d.Id(), removeChildren, consolidatePtr)
-	if err != nil {
-		log.Printf("[DEBUG] Error while creating the delete snapshot task: %v", err)
-		return fmt.Errorf("error while creating the delete snapshot task: %s", err)
-	}
-	log.Printf("[DEBUG] Task created for delete snapshot: %v", task)
+	tctx, tcancel := context.WithTimeout(context.Background(), snapshotTimeout(d))
+	defer tcancel()
+	err = viapi.RetryOnTaskFault(tctx, d.Get("max_retries").(int), snapshotRetryDelay, viapi.IsRetryableTaskFault, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout) // This is 5 mins
+		defer cancel()
+		task, err := vm.RemoveSnapshot(ctx, d.Id(), removeChildren, consolidatePtr)
+		if err != nil {
+			if viapi.IsManagedObjectNotFoundTaskFault(err) {
+				// The snapshot is already gone, do not retry the delete.
+				log.Printf("[DEBUG] Snapshot %s not found, assuming it has already been deleted", d.Id())
+				return nil
+			}
+			log.Printf("[DEBUG] Error while creating the delete snapshot task: %v", err)
+			return fmt.Errorf("error while creating the delete snapshot task: %w", err)
+		}
+		log.Printf("[DEBUG] Task created for delete snapshot: %v", task)
 
-	err = task.WaitEx(ctx)
+		if err := viapi.WaitForTask(tctx, task); err != nil {
+			if viapi.IsManagedObjectNotFoundTaskFault(err) {
+				log.Printf("[DEBUG] Snapshot %s not found, assuming it has already been deleted", d.Id())
+				return nil
+			}
+			log.Printf("[DEBUG] Error while waiting for the delete snapshot task: %v", err)
+			return fmt.Errorf("error while waiting for the delete snapshot task: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("[DEBUG] Error while waiting for the delete snapshot task: %v", err)
-		return fmt.Errorf("error while waiting for the delete snapshot task: %s", err)
+		return err
 	}
 	log.Printf("[DEBUG] Delete snapshot completed %v", d.Get("snapshot_name").(string))
 
+	if d.Get("auto_consolidate").(bool) {
+		consolidated, err := virtualmachine.ConsolidateDisksIfNeeded(vm)
+		if err != nil {
+			return fmt.Errorf("error while consolidating disks after deleting snapshot: %s", err)
+		}
+		if consolidated {
+			log.Printf("[DEBUG] Consolidated disks after deleting snapshot %v", d.Get("snapshot_name").(string))
+		}
+	}
+
 	return nil
 }
 
+// snapshotTimeout returns the amount of time to wait for snapshot tasks to
+// complete, falling back to the API timeout of the provider if timeout is not
+// set.
+func snapshotTimeout(d *schema.ResourceData) time.Duration {
+	if v, ok := d.GetOk("timeout"); ok {
+		return time.Duration(v.(int)) * time.Minute
+	}
+	return defaultAPITimeout
+}
+
+func resourceVSphereVirtualMachineSnapshotUpdate(d *schema.ResourceData, meta interface{}) error {
+	// auto_consolidate, consolidate_on_create, max_chain_length, timeout, and
+	// max_retries are only used by other operations, so the name and
+	// description are the only attributes that need to be changed on the
+	// snapshot.
+	if d.HasChanges("snapshot_name", "description") {
+		client := meta.(*Client).vimClient
+		log.Printf("[DEBUG] Renaming snapshot %s to %v", d.Id(), d.Get("snapshot_name").(string))
+		ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout) // This is 5 mins
+		defer cancel()
+		req := types.RenameSnapshot{
+			This: types.ManagedObjectReference{
+				Type:  "VirtualMachineSnapshot",
+				Value: d.Id(),
+			},
+			Name:        d.Get("snapshot_name").(string),
+			Description: d.Get("description").(string),
+		}
+		if _, err := methods.RenameSnapshot(ctx, client, &req); err != nil {
+			log.Printf("[DEBUG] Error while renaming the snapshot: %v", err)
+			return fmt.Errorf("error while renaming the snapshot: %s", err)
+		}
+		log.Printf("[DEBUG] Rename snapshot completed %v", d.Get("snapshot_name").(string))
+	}
+	return resourceVSphereVirtualMachineSnapshotRead(d, meta)
+}
+
 func resourceVSphereVirtualMachineSnapshotRead(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client).vimClient
 	vm, err := virtualmachine.FromUUID(client, d.Get("virtual_machine_uuid").(string))
@@ -154,5 +443,81 @@ func resourceVSphereVirtualMachineSnapshotRead(d *schema.ResourceData, meta inte
 		return fmt.Errorf("error while finding the snapshot :%s", err)
 	}
 	log.Printf("[DEBUG] Snapshot found: %v", snapshot)
+	props, err := virtualmachine.Properties(vm)
+	if err != nil {
+		return fmt.Errorf("error while getting the virtual machine properties :%s", err)
+	}
+	return flattenVirtualMachineSnapshot(d, props)
+}
+
+// flattenVirtualMachineSnapshot finds the snapshot of the resource in the
+// snapshot tree of the virtual machine and sets its metadata in the supplied
+// ResourceData.
+func flattenVirtualMachineSnapshot(d *schema.ResourceData, props *mo.VirtualMachine) error {
+	if props.Snapshot == nil {
+		return fmt.Errorf("virtual machine has no snapshots")
+	}
+	tree := virtualmachine.FindSnapshotTree(props.Snapshot.RootSnapshotList, d.Id())
+	if tree == nil {
+		return fmt.Errorf("could not find snapshot %s in the snapshot tree", d.Id())
+	}
+	_ = d.Set("snapshot_name", tree.Name)
+	_ = d.Set("description", tree.Description)
+	_ = d.Set("create_time", tree.CreateTime.UTC().Format(time.RFC3339))
+	_ = d.Set("power_state_at_snapshot", string(tree.State))
+
+	for _, v := range flattenVirtualMachineSnapshotTree(props) {
+		snapshot := v.(map[string]interface{})
+		if snapshot["id"] == d.Id() {
+			_ = d.Set("size_bytes", snapshot["size"])
+			_ = d.Set("is_current_snapshot", snapshot["is_current"])
+		}
+	}
 	return nil
 }
+
+func resourceVSphereVirtualMachineSnapshotImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.SplitN(d.Id(), ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid ID %q: must be of the form <virtual-machine-uuid>:<snapshot-id-or-name>", d.Id())
+	}
+	uuid, snapshotID := parts[0], parts[1]
+
+	client := meta.(*Client).vimClient
+	vm, err := virtualmachine.FromUUID(client, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting the virtual machine :%s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout) // This is 5 mins
+	defer cancel()
+	snapshot, err := vm.FindSnapshot(ctx, snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("error while finding the snapshot :%s", err)
+	}
+	props, err := virtualmachine.Properties(vm)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting the virtual machine properties :%s", err)
+	}
+	if props.Snapshot == nil {
+		return nil, fmt.Errorf("virtual machine %s has no snapshots", uuid)
+	}
+	tree := virtualmachine.FindSnapshotTree(props.Snapshot.RootSnapshotList, snapshot.Value)
+	if tree == nil {
+		return nil, fmt.Errorf("could not find snapshot %s in the snapshot tree of virtual machine %s", snapshot.Value, uuid)
+	}
+
+	d.SetId(snapshot.Value)
+	_ = d.Set("virtual_machine_uuid", uuid)
+	_ = d.Set("snapshot_name", tree.Name)
+	_ = d.Set("description", tree.Description)
+	// A snapshot that includes the memory of the virtual machine is taken in
+	// the powered on state.
+	_ = d.Set("memory", tree.State == types.VirtualMachinePowerStatePoweredOn)
+	_ = d.Set("quiesce", tree.Quiesced)
+	rs := resourceVSphereVirtualMachineSnapshot().Schema
+	_ = d.Set("auto_consolidate", rs["auto_consolidate"].Default)
+	_ = d.Set("consolidate_on_create", rs["consolidate_on_create"].Default)
+	_ = d.Set("max_retries", rs["max_retries"].Default)
+	log.Printf("[DEBUG] Imported snapshot %s of virtual machine %s", snapshot.Value, uuid)
+	return []*schema.ResourceData{d}, nil
+}
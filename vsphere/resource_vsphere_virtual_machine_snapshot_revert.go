@@ -0,0 +1,117 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
+)
+
+func resourceVSphereVirtualMachineSnapshotRevert() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereVirtualMachineSnapshotRevertCreate,
+		Read:   resourceVSphereVirtualMachineSnapshotRevertRead,
+		Delete: resourceVSphereVirtualMachineSnapshotRevertDelete,
+
+		Schema: map[string]*schema.Schema{
+			"virtual_machine_uuid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The UUID of the virtual machine to revert.",
+			},
+			"snapshot_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID or name of the snapshot to revert the virtual machine to.",
+			},
+			"suppress_power_on": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     false,
+				Description: "Do not power on the virtual machine after the revert, even if it was powered on when the snapshot was taken.",
+			},
+			"timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      5,
+				Description:  "The amount of time, in minutes, to wait for the revert to complete.",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "A map of arbitrary values that, when changed, cause the virtual machine to be reverted again.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"reverted_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The time, in RFC 3339 format, at which the virtual machine was reverted.",
+			},
+		},
+	}
+}
+
+func resourceVSphereVirtualMachineSnapshotRevertCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	vm, err := virtualmachine.FromUUID(client, d.Get("virtual_machine_uuid").(string))
+	if err != nil {
+		return fmt.Errorf("error while getting the virtual machine :%s", err)
+	}
+	snapshot := d.Get("snapshot_id").(string)
+	timeout := time.Duration(d.Get("timeout").(int)) * time.Minute
+	if err := virtualmachine.RevertToSnapshot(vm, snapshot, d.Get("suppress_power_on").(bool), timeout); err != nil {
+		if virtualmachine.IsSnapshotNotFoundError(err) {
+			return fmt.Errorf("cannot revert virtual machine: %s. The snapshot may have been deleted outside of Terraform", err)
+		}
+		return fmt.Errorf("error while reverting to snapshot %q: %s", snapshot, err)
+	}
+	log.Printf("[DEBUG] Revert to snapshot %q completed", snapshot)
+
+	revertedAt := time.Now().UTC().Format(time.RFC3339)
+	d.SetId(fmt.Sprintf("%s:%s:%s", d.Get("virtual_machine_uuid").(string), snapshot, revertedAt))
+	_ = d.Set("reverted_at", revertedAt)
+	return resourceVSphereVirtualMachineSnapshotRevertRead(d, meta)
+}
+
+func resourceVSphereVirtualMachineSnapshotRevertRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	vm, err := virtualmachine.FromUUID(client, d.Get("virtual_machine_uuid").(string))
+	if err != nil {
+		if virtualmachine.IsUUIDNotFoundError(err) {
+			log.Printf("[DEBUG] Virtual machine %q no longer exists, removing revert from state", d.Get("virtual_machine_uuid").(string))
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("error while getting the virtual machine :%s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if _, err := vm.FindSnapshot(ctx, d.Get("snapshot_id").(string)); err != nil {
+		// The revert has already happened, so a snapshot deleted out-of-band does
+		// not invalidate it. Keep the resource in state so that a plan does not
+		// try to revert to a snapshot that no longer exists.
+		log.Printf("[WARN] Snapshot %q referenced by revert %q could not be found: %s", d.Get("snapshot_id").(string), d.Id(), err)
+	}
+	return nil
+}
+
+func resourceVSphereVirtualMachineSnapshotRevertDelete(d *schema.ResourceData, _ interface{}) error {
+	// A revert cannot be undone, so deleting this resource only removes it from
+	// state.
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,92 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
+)
+
+func TestAccResourceVSphereVirtualMachineSnapshotRevert_basic(t *testing.T) {
+	testAccSkipUnstable(t)
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereVirtualMachineSnapshotPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereVirtualMachineSnapshotRevertConfig("1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("vsphere_virtual_machine_snapshot_revert.revert", "reverted_at", regexp.MustCompile(".+")),
+					testAccCheckVirtualMachineCurrentSnapshot("vsphere_virtual_machine.vm", "vsphere_virtual_machine_snapshot.snapshot.0"),
+				),
+			},
+			{
+				Config: testAccResourceVSphereVirtualMachineSnapshotRevertConfig("2"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_virtual_machine_snapshot_revert.revert", "triggers.run", "2"),
+					testAccCheckVirtualMachineCurrentSnapshot("vsphere_virtual_machine.vm", "vsphere_virtual_machine_snapshot.snapshot.0"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVirtualMachineCurrentSnapshot(vmName, snapshotName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		vmRs, ok := s.RootModule().Resources[vmName]
+		if !ok {
+			return fmt.Errorf("not found: %s", vmName)
+		}
+		snapshotRs, ok := s.RootModule().Resources[snapshotName]
+		if !ok {
+			return fmt.Errorf("not found: %s", snapshotName)
+		}
+		client := testAccProvider.Meta().(*Client).vimClient
+
+		vm, err := virtualmachine.FromUUID(client, vmRs.Primary.Attributes["uuid"])
+		if err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+		props, err := virtualmachine.Properties(vm)
+		if err != nil {
+			return fmt.Errorf("cannot get properties for virtual machine: %s", err)
+		}
+		if props.Snapshot == nil || props.Snapshot.CurrentSnapshot == nil {
+			return fmt.Errorf("expected VM to have a current snapshot")
+		}
+		if props.Snapshot.CurrentSnapshot.Value != snapshotRs.Primary.ID {
+			return fmt.Errorf("expected current snapshot to be %s, got %s", snapshotRs.Primary.ID, props.Snapshot.CurrentSnapshot.Value)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereVirtualMachineSnapshotRevertConfig(trigger string) string {
+	return fmt.Sprintf(`
+%s
+
+resource "vsphere_virtual_machine_snapshot_revert" "revert" {
+  virtual_machine_uuid = vsphere_virtual_machine.vm.uuid
+  snapshot_id          = vsphere_virtual_machine_snapshot.snapshot[0].id
+  suppress_power_on    = true
+
+  triggers = {
+    run = "%s"
+  }
+}
+`,
+		testAccResourceVSphereVirtualMachineSnapshotConfig(true),
+		trigger,
+	)
+}
@@ -8,10 +8,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
 )
@@ -33,6 +39,12 @@ func TestAccResourceVSphereVirtualMachineSnapshot_basic(t *testing.T) {
 					testAccCheckVirtualMachineSnapshotExists("vsphere_virtual_machine_snapshot.snapshot", true),
 					resource.TestCheckResourceAttr(
 						"vsphere_virtual_machine_snapshot.snapshot", "snapshot_name", "terraform-test-snapshot"),
+					resource.TestCheckResourceAttrSet(
+						"vsphere_virtual_machine_snapshot.snapshot.0", "create_time"),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine_snapshot.snapshot.0", "is_current_snapshot", "true"),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine_snapshot.snapshot.0", "power_state_at_snapshot", "poweredOn"),
 				),
 			},
 			{
@@ -45,6 +57,132 @@ func TestAccResourceVSphereVirtualMachineSnapshot_basic(t *testing.T) {
 	})
 }
 
+func TestAccResourceVSphereVirtualMachineSnapshot_import(t *testing.T) {
+	testAccSkipUnstable(t)
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereVirtualMachineSnapshotPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualMachineSnapshotExists("vsphere_virtual_machine_snapshot.snapshot", false),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereVirtualMachineSnapshotConfig(true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVirtualMachineSnapshotExists("vsphere_virtual_machine_snapshot.snapshot.0", true),
+				),
+			},
+			{
+				ResourceName:      "vsphere_virtual_machine_snapshot.snapshot[0]",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"remove_children",
+					"consolidate",
+				},
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					rs, ok := s.RootModule().Resources["vsphere_virtual_machine_snapshot.snapshot.0"]
+					if !ok {
+						return "", fmt.Errorf("not found: vsphere_virtual_machine_snapshot.snapshot.0")
+					}
+					return fmt.Sprintf("%s:%s", rs.Primary.Attributes["virtual_machine_uuid"], rs.Primary.ID), nil
+				},
+				Config: testAccResourceVSphereVirtualMachineSnapshotConfig(true),
+			},
+		},
+	})
+}
+
+func TestAccResourceVSphereVirtualMachineSnapshot_rename(t *testing.T) {
+	testAccSkipUnstable(t)
+	var snapshotID string
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereVirtualMachineSnapshotPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualMachineSnapshotExists("vsphere_virtual_machine_snapshot.snapshot", false),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereVirtualMachineSnapshotConfigWithName(true, "terraform-test-snapshot", "Managed by Terraform"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVirtualMachineSnapshotExists("vsphere_virtual_machine_snapshot.snapshot.0", true),
+					testAccCheckVirtualMachineSnapshotID("vsphere_virtual_machine_snapshot.snapshot.0", &snapshotID),
+				),
+			},
+			{
+				Config: testAccResourceVSphereVirtualMachineSnapshotConfigWithName(true, "terraform-test-snapshot-renamed", "Renamed by Terraform"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVirtualMachineSnapshotExists("vsphere_virtual_machine_snapshot.snapshot.0", true),
+					testAccCheckVirtualMachineSnapshotID("vsphere_virtual_machine_snapshot.snapshot.0", &snapshotID),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine_snapshot.snapshot.0", "snapshot_name", "terraform-test-snapshot-renamed"),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine_snapshot.snapshot.0", "description", "Renamed by Terraform"),
+				),
+			},
+		},
+	})
+}
+
+func TestFlattenVirtualMachineSnapshot(t *testing.T) {
+	ref := func(id string) types.ManagedObjectReference {
+		return types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: id}
+	}
+	current := ref("snapshot-2")
+	createTime := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	props := &mo.VirtualMachine{
+		Snapshot: &types.VirtualMachineSnapshotInfo{
+			CurrentSnapshot: &current,
+			RootSnapshotList: []types.VirtualMachineSnapshotTree{
+				{
+					Snapshot: ref("snapshot-1"),
+					Name:     "root",
+					ChildSnapshotList: []types.VirtualMachineSnapshotTree{
+						{
+							Snapshot:    ref("snapshot-2"),
+							Name:        "renamed",
+							Description: "changed outside of Terraform",
+							CreateTime:  createTime,
+							State:       types.VirtualMachinePowerStatePoweredOff,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachineSnapshot().Schema, map[string]interface{}{
+		"snapshot_name": "original",
+		"description":   "Managed by Terraform",
+	})
+	d.SetId("snapshot-2")
+	if err := flattenVirtualMachineSnapshot(d, props); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"snapshot_name":           "renamed",
+		"description":             "changed outside of Terraform",
+		"create_time":             "2024-05-01T12:30:00Z",
+		"power_state_at_snapshot": "poweredOff",
+		"is_current_snapshot":     true,
+	}
+	for k, v := range expected {
+		if actual := d.Get(k); actual != v {
+			t.Fatalf("expected %s to be %v, got %v", k, v, actual)
+		}
+	}
+
+	d.SetId("snapshot-3")
+	if err := flattenVirtualMachineSnapshot(d, props); err == nil {
+		t.Fatalf("expected error for missing snapshot")
+	}
+}
+
 func testAccResourceVSphereVirtualMachineSnapshotPreCheck(t *testing.T) {
 	if os.Getenv("TF_VAR_VSPHERE_DATACENTER") == "" {
 		t.Skip("set TF_VAR_VSPHERE_DATACENTER to run vsphere_virtual_machine_snapshot acceptance tests")
@@ -112,6 +250,25 @@ func testAccCheckVirtualMachineSnapshotExists(n string, exists bool) resource.Te
 	}
 }
 
+// testAccCheckVirtualMachineSnapshotID records the ID of the snapshot in id if
+// it is empty, or checks that the ID of the snapshot has not changed otherwise.
+func testAccCheckVirtualMachineSnapshotID(n string, id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("not found: %s", n)
+		}
+		if *id == "" {
+			*id = rs.Primary.ID
+			return nil
+		}
+		if rs.Primary.ID != *id {
+			return fmt.Errorf("expected snapshot ID to be %s, got %s", *id, rs.Primary.ID)
+		}
+		return nil
+	}
+}
+
 func testAccCheckVirtualMachineHasNoSnapshots(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -142,6 +299,10 @@ func testAccCheckVirtualMachineHasNoSnapshots(n string) resource.TestCheckFunc {
 }
 
 func testAccResourceVSphereVirtualMachineSnapshotConfig(enabled bool) string {
+	return testAccResourceVSphereVirtualMachineSnapshotConfigWithName(enabled, "terraform-test-snapshot", "Managed by Terraform")
+}
+
+func testAccResourceVSphereVirtualMachineSnapshotConfigWithName(enabled bool, name, description string) string {
 	return fmt.Sprintf(`
 %s
 
@@ -211,8 +372,8 @@ resource "vsphere_virtual_machine" "vm" {
 resource "vsphere_virtual_machine_snapshot" "snapshot" {
   count                = var.snapshot_enabled == "true" ? 1 : 0 
   virtual_machine_uuid = vsphere_virtual_machine.vm.uuid
-  snapshot_name        = "terraform-test-snapshot"
-  description          = "Managed by Terraform"
+  snapshot_name        = "%s"
+  description          = "%s"
   memory               = true
   quiesce              = true
 }
@@ -223,5 +384,198 @@ resource "vsphere_virtual_machine_snapshot" "snapshot" {
 		testhelper.CombineConfigs(testhelper.ConfigDataRootDC1(), testhelper.ConfigDataRootHost1(), testhelper.ConfigDataRootHost2(), testhelper.ConfigResDS1(), testhelper.ConfigDataRootComputeCluster1(), testhelper.ConfigResResourcePool1(), testhelper.ConfigDataRootPortGroup1()),
 		os.Getenv("TF_VAR_VSPHERE_TEMPLATE"),
 		enabled,
+		name,
+		description,
 	)
 }
+
+func TestExpandVirtualMachineSnapshotQuiesceSpec(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   []interface{}
+		expected types.BaseVirtualMachineGuestQuiesceSpec
+	}{
+		{
+			name:     "not set",
+			expected: nil,
+		},
+		{
+			name:   "timeout only",
+			config: []interface{}{map[string]interface{}{"timeout": 30}},
+			expected: &types.VirtualMachineGuestQuiesceSpec{
+				Timeout: 30,
+			},
+		},
+		{
+			name: "vss options",
+			config: []interface{}{map[string]interface{}{
+				"timeout":                   10,
+				"vss_backup_type":           1,
+				"vss_backup_context":        "ctx_backup",
+				"vss_bootable_system_state": true,
+			}},
+			expected: &types.VirtualMachineWindowsQuiesceSpec{
+				VirtualMachineGuestQuiesceSpec: types.VirtualMachineGuestQuiesceSpec{
+					Timeout: 10,
+				},
+				VssBackupType:          1,
+				VssBackupContext:       "ctx_backup",
+				VssBootableSystemState: types.NewBool(true),
+				VssPartialFileSupport:  types.NewBool(false),
+			},
+		},
+		{
+			name: "vss options without backup type",
+			config: []interface{}{map[string]interface{}{
+				"timeout":                  10,
+				"vss_partial_file_support": true,
+			}},
+			expected: &types.VirtualMachineWindowsQuiesceSpec{
+				VirtualMachineGuestQuiesceSpec: types.VirtualMachineGuestQuiesceSpec{
+					Timeout: 10,
+				},
+				VssBootableSystemState: types.NewBool(false),
+				VssPartialFileSupport:  types.NewBool(true),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachineSnapshot().Schema, map[string]interface{}{
+				"quiesce":      true,
+				"quiesce_spec": tc.config,
+			})
+			actual := expandVirtualMachineSnapshotQuiesceSpec(d)
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateVirtualMachineSnapshotQuiesceSpec(t *testing.T) {
+	cases := []struct {
+		name        string
+		quiesce     bool
+		quiesceSpec []interface{}
+		expectErr   bool
+	}{
+		{
+			name:    "no quiesce spec",
+			quiesce: false,
+		},
+		{
+			name:        "quiesce spec with quiesce",
+			quiesce:     true,
+			quiesceSpec: []interface{}{map[string]interface{}{"timeout": 10}},
+		},
+		{
+			name:        "quiesce spec without quiesce",
+			quiesce:     false,
+			quiesceSpec: []interface{}{map[string]interface{}{"timeout": 10}},
+			expectErr:   true,
+		},
+		{
+			name:        "empty quiesce spec without quiesce",
+			quiesce:     false,
+			quiesceSpec: []interface{}{nil},
+			expectErr:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateVirtualMachineSnapshotQuiesceSpec(tc.quiesce, tc.quiesceSpec)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestIsRetryableSnapshotCreateFault(t *testing.T) {
+	cases := []struct {
+		name     string
+		fault    types.BaseMethodFault
+		expected bool
+	}{
+		{
+			name:     "task in progress",
+			fault:    &types.TaskInProgress{},
+			expected: true,
+		},
+		{
+			name:     "managed object not found",
+			fault:    &types.ManagedObjectNotFound{},
+			expected: true,
+		},
+		{
+			name:     "invalid power state",
+			fault:    &types.InvalidPowerState{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := task.Error{LocalizedMethodFault: &types.LocalizedMethodFault{Fault: tc.fault}}
+			if actual := isRetryableSnapshotCreateFault(err); tc.expected != actual {
+				t.Fatalf("expected retryable fault to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateVirtualMachineSnapshotChainLength(t *testing.T) {
+	ref := func(id string) *types.ManagedObjectReference {
+		return &types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: id}
+	}
+	snapshots := &types.VirtualMachineSnapshotInfo{
+		CurrentSnapshot: ref("snapshot-2"),
+		RootSnapshotList: []types.VirtualMachineSnapshotTree{
+			{
+				Snapshot: *ref("snapshot-1"),
+				ChildSnapshotList: []types.VirtualMachineSnapshotTree{
+					{
+						Snapshot: *ref("snapshot-2"),
+					},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name      string
+		snapshots *types.VirtualMachineSnapshotInfo
+		maxLength int
+		expectErr bool
+	}{
+		{
+			name:      "no snapshots",
+			maxLength: 1,
+			expectErr: false,
+		},
+		{
+			name:      "chain within limit",
+			snapshots: snapshots,
+			maxLength: 3,
+			expectErr: false,
+		},
+		{
+			name:      "chain exceeds limit",
+			snapshots: snapshots,
+			maxLength: 2,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateVirtualMachineSnapshotChainLength(&mo.VirtualMachine{Snapshot: tc.snapshots}, tc.maxLength)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@
 package vsphere
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -16,10 +17,15 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/computeresource"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/datastore"
@@ -63,6 +69,10 @@ func TestAccResourceVSphereVirtualMachine_basic(t *testing.T) {
 					"disk",
 					"imported",
 					"wait_for_guest_net_timeout",
+					"overall_cpu_usage",
+					"host_memory_usage",
+					"guest_memory_usage",
+					"uptime_seconds",
 				},
 				ImportStateIdFunc: func(s *terraform.State) (string, error) {
 					vm, err := testGetVirtualMachine(s, "vm")
@@ -1040,6 +1050,41 @@ func TestAccResourceVSphereVirtualMachine_cdromIsoBacking(t *testing.T) {
 		},
 	})
 }
+
+func TestAccResourceVSphereVirtualMachine_cdromIsoDetach(t *testing.T) {
+	testAccSkipUnstable(t)
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereVirtualMachinePreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereVirtualMachineConfigCdromIsoDetach(),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceVSphereVirtualMachineCheckIsoCdrom(),
+					resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "cdrom.0.iso_attached", "true"),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccResourceVSphereVirtualMachineConfigCdromIsoDetach(),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceVSphereVirtualMachineCheckClientCdrom(),
+					resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "cdrom.0.iso_attached", "false"),
+				),
+			},
+			{
+				Config:   testAccResourceVSphereVirtualMachineConfigCdromIsoDetach(),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
 func TestAccResourceVSphereVirtualMachine_cdromConflictingParameters(t *testing.T) {
 	testAccSkipUnstable(t)
 	resource.Test(t, resource.TestCase{
@@ -2636,6 +2681,10 @@ func TestAccResourceVSphereVirtualMachine_multipleDisksAtDifferentSCSISlotsImpor
 					"disk",
 					"imported",
 					"wait_for_guest_net_timeout",
+					"overall_cpu_usage",
+					"host_memory_usage",
+					"guest_memory_usage",
+					"uptime_seconds",
 				},
 				ImportStateIdFunc: func(s *terraform.State) (string, error) {
 					vm, err := testGetVirtualMachine(s, "vm")
@@ -2687,6 +2736,10 @@ func TestAccResourceVSphereVirtualMachine_cloneImport(t *testing.T) {
 					"cdrom",
 					"wait_for_guest_net_timeout",
 					"sata_controller_count",
+					"overall_cpu_usage",
+					"host_memory_usage",
+					"guest_memory_usage",
+					"uptime_seconds",
 				},
 				ImportStateIdFunc: func(s *terraform.State) (string, error) {
 					vm, err := testGetVirtualMachine(s, "vm")
@@ -2919,6 +2972,164 @@ func TestAccResourceVSphereVirtualMachine_nvmeController(t *testing.T) {
 	})
 }
 
+func TestFlattenVirtualMachineDatastoreIDs(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+	flattenVirtualMachineDatastoreIDs(d, []types.ManagedObjectReference{
+		{Type: "Datastore", Value: "datastore-2"},
+		{Type: "Datastore", Value: "datastore-1"},
+	})
+	expected := []interface{}{"datastore-1", "datastore-2"}
+	if actual := d.Get("datastore_ids").([]interface{}); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected datastore IDs %v, got %v", expected, actual)
+	}
+}
+
+func TestVirtualMachineSwapDatastoreName(t *testing.T) {
+	cases := []struct {
+		name     string
+		props    *mo.VirtualMachine
+		expected string
+	}{
+		{
+			name:     "no swap file",
+			props:    &mo.VirtualMachine{},
+			expected: "",
+		},
+		{
+			name: "swap file in extended layout",
+			props: &mo.VirtualMachine{
+				LayoutEx: &types.VirtualMachineFileLayoutEx{
+					File: []types.VirtualMachineFileLayoutExFileInfo{
+						{Name: "[datastore1] vm/vm.vmx", Type: "config"},
+						{Name: "[local-swap] vm-4a2b.vswp", Type: "swap"},
+					},
+				},
+			},
+			expected: "local-swap",
+		},
+		{
+			name: "swap file in layout",
+			props: &mo.VirtualMachine{
+				Layout: &types.VirtualMachineFileLayout{
+					SwapFile: "[datastore1] vm/vm.vswp",
+				},
+			},
+			expected: "datastore1",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := virtualMachineSwapDatastoreName(tc.props); tc.expected != actual {
+				t.Fatalf("expected swap datastore %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFlattenVirtualMachineQuickStats(t *testing.T) {
+	stats := types.VirtualMachineQuickStats{
+		OverallCpuUsage:  1200,
+		HostMemoryUsage:  2048,
+		GuestMemoryUsage: 512,
+		UptimeSeconds:    3600,
+	}
+	cases := []struct {
+		name       string
+		powerState types.VirtualMachinePowerState
+		expected   map[string]int
+	}{
+		{
+			name:       "powered on",
+			powerState: types.VirtualMachinePowerStatePoweredOn,
+			expected: map[string]int{
+				"overall_cpu_usage":  1200,
+				"host_memory_usage":  2048,
+				"guest_memory_usage": 512,
+				"uptime_seconds":     3600,
+			},
+		},
+		{
+			name:       "powered off",
+			powerState: types.VirtualMachinePowerStatePoweredOff,
+			expected: map[string]int{
+				"overall_cpu_usage":  0,
+				"host_memory_usage":  0,
+				"guest_memory_usage": 0,
+				"uptime_seconds":     0,
+			},
+		},
+		{
+			name:       "suspended",
+			powerState: types.VirtualMachinePowerStateSuspended,
+			expected: map[string]int{
+				"overall_cpu_usage":  0,
+				"host_memory_usage":  0,
+				"guest_memory_usage": 0,
+				"uptime_seconds":     0,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+			flattenVirtualMachineQuickStats(d, tc.powerState, stats)
+			for k, v := range tc.expected {
+				if actual := d.Get(k).(int); actual != v {
+					t.Fatalf("expected %s to be %d, got %d", k, v, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceVSphereVirtualMachineReadConfigUnavailable(t *testing.T) {
+	states := []types.VirtualMachineConnectionState{
+		types.VirtualMachineConnectionStateOrphaned,
+		types.VirtualMachineConnectionStateInaccessible,
+		types.VirtualMachineConnectionStateInvalid,
+	}
+
+	for _, state := range states {
+		t.Run(string(state), func(t *testing.T) {
+			model := simulator.VPX()
+			err := model.Run(func(_ context.Context, c *vim25.Client) error {
+				obj := model.Map().Any("VirtualMachine").(*simulator.VirtualMachine)
+				obj.Runtime.ConnectionState = state
+
+				d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+					"memory": 4096,
+				})
+				d.SetId(obj.Config.Uuid)
+				_ = d.Set("reboot_required", true)
+
+				client := &Client{vimClient: &govmomi.Client{Client: c}}
+				if err := resourceVSphereVirtualMachineRead(d, client); err != nil {
+					return err
+				}
+
+				if d.Id() != obj.Config.Uuid {
+					t.Fatalf("expected resource to be kept, got ID %q", d.Id())
+				}
+				if actual := d.Get("connection_state").(string); actual != string(state) {
+					t.Fatalf("expected connection_state to be %q, got %q", state, actual)
+				}
+				if d.Get("reboot_required").(bool) {
+					t.Fatal("expected reboot_required to be reset")
+				}
+				if actual := d.Get("memory").(int); actual != 4096 {
+					t.Fatalf("expected memory to keep the last known value 4096, got %d", actual)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
 func testAccResourceVSphereVirtualMachinePreCheck(t *testing.T) {
 	// Note that TF_VAR_VSPHERE_USE_LINKED_CLONE is also a variable and its presence
 	// speeds up tests greatly, but it's not a necessary variable, so we don't
@@ -5212,6 +5423,57 @@ resource "vsphere_virtual_machine" "vm" {
 	)
 }
 
+func testAccResourceVSphereVirtualMachineConfigCdromIsoDetach() string {
+	return fmt.Sprintf(`
+%s  // Mix and match config
+
+variable "iso_datastore" {
+  default = "%s"
+}
+
+variable "iso_path" {
+  default = "%s"
+}
+
+data "vsphere_datastore" "iso_datastore" {
+  name          = var.iso_datastore
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name             = "testacc-test"
+  resource_pool_id = vsphere_resource_pool.pool1.id
+  datastore_id     = data.vsphere_datastore.rootds1.id
+
+  num_cpus = 2
+  memory   = 2048
+  guest_id = "other3xLinuxGuest"
+
+  wait_for_guest_net_timeout = -1
+
+  network_interface {
+    network_id = data.vsphere_network.network1.id
+  }
+
+  disk {
+    label = "disk0"
+    size  = 1
+  }
+
+  cdrom {
+    datastore_id = data.vsphere_datastore.iso_datastore.id
+    path         = var.iso_path
+    detach_iso   = true
+  }
+}
+`,
+
+		testAccResourceVSphereVirtualMachineConfigBase(),
+		testAccResourceVSphereVirtualMachineIsoDatastore,
+		testAccResourceVSphereVirtualMachineIsoFile,
+	)
+}
+
 func testAccResourceVSphereVirtualMachineConfigConflictingCdromParameters() string {
 	return fmt.Sprintf(`
 
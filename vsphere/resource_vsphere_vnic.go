@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"strconv"
 	"strings"
 
@@ -17,8 +18,11 @@ import (
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/dvportgroup"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/utils"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/viapi"
 )
 
 const (
@@ -27,6 +31,26 @@ const (
 	vnicServiceTypeManagement = "management"
 )
 
+const (
+	// vnicMtuMin is the smallest MTU accepted for a vmkernel adapter. This is
+	// the minimum link MTU required by IPv6.
+	vnicMtuMin = 1280
+	// vnicMtuMax is the largest MTU accepted for a vmkernel adapter.
+	vnicMtuMax = 9000
+	// vnicMtuStandard is the standard Ethernet MTU. Anything above this
+	// requires jumbo frames to be enabled on the switch and physical uplinks.
+	vnicMtuStandard = 1500
+)
+
+// vnicVMwareOUIs lists the organizationally unique identifiers that VMware
+// uses for MAC addresses of virtual network adapters.
+var vnicVMwareOUIs = []string{
+	"00:05:69",
+	"00:0c:29",
+	"00:1c:14",
+	"00:50:56",
+}
+
 var vnicServiceTypeAllowedValues = []string{
 	vnicServiceTypeVsan,
 	vnicServiceTypeVmotion,
@@ -42,7 +66,8 @@ func resourceVsphereNic() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			State: resourceVSphereNicImport,
 		},
-		Schema: vNicSchema(),
+		CustomizeDiff: resourceVsphereNicCustomizeDiff,
+		Schema:        vNicSchema(),
 	}
 }
 
@@ -54,6 +79,22 @@ func vNicSchema() map[string]*schema.Schema {
 		Description: "ESX host the interface belongs to",
 		ForceNew:    true,
 	}
+	base["rollback_on_failure"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Remove the interface from the host if configuring it fails after it has been created.",
+	}
+	base["device"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The device name of the interface on the host, such as vmk1.",
+	}
+	base["active_uplink"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The physical NIC that currently carries the traffic of the interface, according to the teaming policy of its portgroup.",
+	}
 
 	return base
 }
@@ -72,6 +113,7 @@ func resourceVsphereNicRead(d *schema.ResourceData, meta interface{}) error {
 		return nil
 	}
 
+	_ = d.Set("device", nicID)
 	_ = d.Set("netstack", vnic.Spec.NetStackInstanceKey)
 	_ = d.Set("portgroup", vnic.Portgroup)
 	if vnic.Spec.DistributedVirtualPort != nil {
@@ -81,17 +123,18 @@ func resourceVsphereNicRead(d *schema.ResourceData, meta interface{}) error {
 	_ = d.Set("mtu", vnic.Spec.Mtu)
 	_ = d.Set("mac", vnic.Spec.Mac)
 
-	// Do we have any ipv4 config ?
-	// IpAddress will be an empty string if ipv4 is off
-	if vnic.Spec.Ip.IpAddress != "" {
-		// if DHCP is true then we should ignore whatever addresses are set here.
-		ipv4dict := make(map[string]interface{})
-		ipv4dict["dhcp"] = vnic.Spec.Ip.Dhcp
-		if !vnic.Spec.Ip.Dhcp {
-			ipv4dict["ip"] = vnic.Spec.Ip.IpAddress
-			ipv4dict["netmask"] = vnic.Spec.Ip.SubnetMask
-			if vnic.Spec.IpRouteSpec != nil {
-				ipv4dict["gw"] = vnic.Spec.IpRouteSpec.IpRouteConfig.GetHostIpRouteConfig().DefaultGateway
+	// Gateways on non-default TCP/IP stacks are kept in the route
+	// configuration of the stack, not of the adapter.
+	nsGateway, nsIPv6Gateway, err := netStackGateways(client, hostID, vnic.Spec.NetStackInstanceKey)
+	if err != nil {
+		log.Printf("[WARN] Could not read default gateways of TCP/IP stack %s: %s", vnic.Spec.NetStackInstanceKey, err)
+	}
+
+	if ipv4dict := flattenHostVirtualNicIPv4(vnic.Spec); ipv4dict != nil {
+		preserveVnicDefaultGateway(d, "ipv4", ipv4dict)
+		if gw, _ := ipv4dict["gw"].(string); gw == "" && nsGateway != "" && ipv4dict["enable_default_gateway"].(bool) {
+			if _, ok := d.GetOk("ipv4.0.gw"); ok {
+				ipv4dict["gw"] = nsGateway
 			}
 		}
 		err = d.Set("ipv4", []map[string]interface{}{ipv4dict})
@@ -103,29 +146,17 @@ func resourceVsphereNicRead(d *schema.ResourceData, meta interface{}) error {
 	// Do we have any ipv6 config ?
 	// IpV6Config will be nil if ipv6 is off
 	if vnic.Spec.Ip.IpV6Config != nil {
-		ipv6dict := map[string]interface{}{
-			"dhcp":       *vnic.Spec.Ip.IpV6Config.DhcpV6Enabled,
-			"autoconfig": *vnic.Spec.Ip.IpV6Config.AutoConfigurationEnabled,
-		}
-
-		// First we need to filter out addresses that were configured via dhcp or autoconfig
-		// or link local or any other mechanism
-		addrList := make([]string, 0)
-		for _, addr := range vnic.Spec.Ip.IpV6Config.IpV6Address {
-			if addr.Origin == "manual" {
-				addrList = append(addrList, fmt.Sprintf("%s/%d", addr.IpAddress, addr.PrefixLength))
-			}
-		}
-		if (len(addrList) == 0) && !*vnic.Spec.Ip.IpV6Config.DhcpV6Enabled && !*vnic.Spec.Ip.IpV6Config.AutoConfigurationEnabled {
+		ipv6dict := flattenHostVirtualNicIPv6(vnic.Spec)
+		if ipv6dict == nil {
 			_ = d.Set("ipv6", nil)
 		} else {
-			ipv6dict["addresses"] = addrList
-
-			if vnic.Spec.IpRouteSpec != nil {
-				ipv6dict["gw"] = vnic.Spec.IpRouteSpec.IpRouteConfig.GetHostIpRouteConfig().IpV6DefaultGateway
-			} else if _, ok := d.GetOk("ipv6.0.gw"); ok {
-				// There is a gw set in the config, but none set on the Host.
-				ipv6dict["gw"] = ""
+			preserveVnicDefaultGateway(d, "ipv6", ipv6dict)
+			if gw, _ := ipv6dict["gw"].(string); gw == "" && ipv6dict["enable_default_gateway"].(bool) {
+				if _, ok := d.GetOk("ipv6.0.gw"); ok {
+					// There is a gw set in the config, but none set on the
+					// adapter. Use the gateway of the TCP/IP stack, if any.
+					ipv6dict["gw"] = canonicalIPv6Address(nsIPv6Gateway)
+				}
 			}
 			err = d.Set("ipv6", []map[string]interface{}{ipv6dict})
 			if err != nil {
@@ -134,31 +165,18 @@ func resourceVsphereNicRead(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
-	// get enabled services
-	hostSystem, err := hostsystem.FromID(client, hostID)
-	if err != nil {
-		return err
-	}
-
-	hostVnicMgr, err := hostSystem.ConfigManager().VirtualNicManager(ctx)
+	uplink, err := vnicActiveUplink(ctx, client, hostID, vnic)
 	if err != nil {
-		return nil
+		log.Printf("[WARN] Could not determine active uplink of vmkernel adapter %s: %s", nicID, err)
 	}
+	_ = d.Set("active_uplink", uplink)
 
-	hostVnicMgrInfo, err := hostVnicMgr.Info(ctx)
+	// get enabled services
+	services, err := getVnicServicesFromHost(ctx, client, hostID)
 	if err != nil {
-		return nil
-	}
-
-	var services []string
-	for _, netConfig := range hostVnicMgrInfo.NetConfig {
-		for _, vnic := range netConfig.SelectedVnic {
-			if isNicIDContained := strings.Contains(vnic, nicID); isNicIDContained {
-				services = append(services, netConfig.NicType)
-			}
-		}
+		return err
 	}
-	if err := d.Set("services", schema.NewSet(schema.HashString, structure.SliceStringsToInterfaces(services))); err != nil {
+	if err := d.Set("services", schema.NewSet(schema.HashString, structure.SliceStringsToInterfaces(services[nicID]))); err != nil {
 		return err
 	}
 
@@ -210,6 +228,7 @@ func resourceVSphereNicImport(d *schema.ResourceData, _ interface{}) ([]*schema.
 	if err != nil {
 		return []*schema.ResourceData{}, err
 	}
+	_ = d.Set("rollback_on_failure", false)
 
 	return []*schema.ResourceData{d}, nil
 }
@@ -258,6 +277,12 @@ func BaseVMKernelSchema() map[string]*schema.Schema {
 					Optional:    true,
 					Description: "IP address of the default gateway, if DHCP is not set.",
 				},
+				"enable_default_gateway": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Set gw as the default gateway of the TCP/IP stack. If false, gw is not applied to the host.",
+				},
 			}},
 		},
 		"ipv6": {
@@ -283,7 +308,7 @@ func BaseVMKernelSchema() map[string]*schema.Schema {
 						Type: schema.TypeString,
 					},
 					DiffSuppressFunc: func(_, old, newValue string, _ *schema.ResourceData) bool {
-						return strings.EqualFold(old, newValue)
+						return canonicalIPv6CIDR(old) == canonicalIPv6CIDR(newValue)
 					},
 				},
 				"gw": {
@@ -291,27 +316,38 @@ func BaseVMKernelSchema() map[string]*schema.Schema {
 					Optional:    true,
 					Description: "IP address of the default gateway, if DHCP or autoconfig is not set.",
 					DiffSuppressFunc: func(_, old, newValue string, _ *schema.ResourceData) bool {
-						return strings.EqualFold(old, newValue)
+						return canonicalIPv6Address(old) == canonicalIPv6Address(newValue)
 					},
 				},
+				"enable_default_gateway": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Set gw as the default gateway of the TCP/IP stack. If false, gw is not applied to the host.",
+				},
 			}},
 		},
 		"mac": {
-			Type:        schema.TypeString,
-			Optional:    true,
-			Computed:    true,
-			Description: "MAC address of the interface.",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			Description:  "MAC address of the interface.",
+			ValidateFunc: validateVnicMAC,
+			DiffSuppressFunc: func(_, old, newValue string, _ *schema.ResourceData) bool {
+				return canonicalMAC(old) == canonicalMAC(newValue)
+			},
 		},
 		"mtu": {
-			Type:        schema.TypeInt,
-			Optional:    true,
-			Computed:    true,
-			Description: "MTU of the interface.",
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Computed:     true,
+			Description:  "MTU of the interface. Must be between 1280 and 9000; 1280 is the minimum MTU for IPv6.",
+			ValidateFunc: validation.IntBetween(vnicMtuMin, vnicMtuMax),
 		},
 		"netstack": {
 			Type:        schema.TypeString,
 			Optional:    true,
-			Description: "TCP/IP stack setting for this interface. Possible values are 'defaultTcpipStack', 'vmotion', 'provisioning'",
+			Description: "TCP/IP stack setting for this interface. Possible values are 'defaultTcpipStack', 'vmotion', 'provisioning', or the name of a custom TCP/IP stack instance on the host",
 			Default:     "defaultTcpipStack",
 			ForceNew:    true,
 		},
@@ -333,6 +369,9 @@ func updateVNic(d *schema.ResourceData, meta interface{}) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if err := precheckVnicMtu(d); err != nil {
+		return "", err
+	}
 
 	client := meta.(*Client).vimClient
 	hostID, nicID := splitHostIDNicID(d)
@@ -348,11 +387,22 @@ func updateVNic(d *schema.ResourceData, meta interface{}) (string, error) {
 		return "", err
 	}
 
-	err = hns.UpdateVirtualNic(ctx, nicID, *nic)
+	warnVnicMtuExceedsSwitch(client, hns, nic)
+	warnVnicSubnetOverlap(client, hostID, nicID, nic)
+
+	// Updating a vnic sets the whole spec, so it can be retried on transient
+	// errors.
+	err = viapi.TaskRetryPolicyFor(client.Client).Retry(ctx, func() error {
+		return hns.UpdateVirtualNic(ctx, nicID, *nic)
+	})
 	if err != nil {
 		return "", err
 	}
 
+	if err := updateNetStackGateway(client, hns, nic); err != nil {
+		return "", err
+	}
+
 	err = updateVnicService(d, hostID, nicID, meta)
 	if err != nil {
 		return "", err
@@ -377,15 +427,20 @@ func updateVnicService(d *schema.ResourceData, hostID string, nicID string, meta
 		return nil
 	}
 
+	policy := viapi.TaskRetryPolicyFor(client.Client)
 	for _, value := range deleteList {
-		err = method.DeselectVnic(ctx, value.(string), nicID)
+		err = policy.Retry(ctx, func() error {
+			return method.DeselectVnic(ctx, value.(string), nicID)
+		})
 		if err != nil {
 			return err
 		}
 	}
 
 	for _, value := range addList {
-		err = method.SelectVnic(ctx, value.(string), nicID)
+		err = policy.Retry(ctx, func() error {
+			return method.SelectVnic(ctx, value.(string), nicID)
+		})
 		if err != nil {
 			return err
 		}
@@ -401,11 +456,270 @@ func precheckEnableServices(d *schema.ResourceData) error {
 	return nil
 }
 
+// precheckVnicMtu rejects an MTU below the IPv6 minimum when IPv6 is
+// configured. The schema validation only covers values set in configuration,
+// so this also catches a smaller MTU carried over from the host, such as on an
+// imported adapter.
+func precheckVnicMtu(d *schema.ResourceData) error {
+	mtu := d.Get("mtu").(int)
+	if _, ok := d.GetOk("ipv6.0"); ok && mtu != 0 && mtu < vnicMtuMin {
+		return fmt.Errorf("mtu must be at least %d when ipv6 is configured, got %d", vnicMtuMin, mtu)
+	}
+	return nil
+}
+
+// precheckNetStackExists checks that a custom TCP/IP stack instance exists on
+// the host before a vmkernel adapter is added to it. The system stacks are
+// always present and are not checked.
+func precheckNetStackExists(client *govmomi.Client, hns *object.HostNetworkSystem, key string) error {
+	switch key {
+	case "", "defaultTcpipStack", "vmotion", "provisioning":
+		return nil
+	}
+	if _, err := hostNetStackFromKey(client, hns, key); err != nil {
+		return fmt.Errorf("netstack %q is not available on the host: %s", key, err)
+	}
+	return nil
+}
+
+// isSystemDefaultNetStack returns true if the key refers to the host's default
+// TCP/IP stack.
+func isSystemDefaultNetStack(key string) bool {
+	return key == "" || key == "defaultTcpipStack"
+}
+
+// updateNetStackGateway applies the default gateways of a vmkernel adapter on
+// a non-default TCP/IP stack to the route configuration of that stack. Routes
+// on these stacks are kept per stack rather than per adapter, so setting them
+// on the adapter alone does not take effect.
+func updateNetStackGateway(client *govmomi.Client, hns *object.HostNetworkSystem, nic *types.HostVirtualNicSpec) error {
+	if isSystemDefaultNetStack(nic.NetStackInstanceKey) || nic.IpRouteSpec == nil || nic.IpRouteSpec.IpRouteConfig == nil {
+		return nil
+	}
+	rc := nic.IpRouteSpec.IpRouteConfig.GetHostIpRouteConfig()
+	if rc.DefaultGateway == "" && rc.IpV6DefaultGateway == "" {
+		return nil
+	}
+
+	nsi, err := hostNetStackFromKey(client, hns, nic.NetStackInstanceKey)
+	if err != nil {
+		return err
+	}
+	current := &types.HostIpRouteConfig{}
+	if nsi.IpRouteConfig != nil {
+		current = nsi.IpRouteConfig.GetHostIpRouteConfig()
+	}
+	changed := false
+	if rc.DefaultGateway != "" && rc.DefaultGateway != current.DefaultGateway {
+		current.DefaultGateway = rc.DefaultGateway
+		changed = true
+	}
+	if rc.IpV6DefaultGateway != "" && canonicalIPv6Address(rc.IpV6DefaultGateway) != canonicalIPv6Address(current.IpV6DefaultGateway) {
+		current.IpV6DefaultGateway = rc.IpV6DefaultGateway
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Setting default gateway on TCP/IP stack %s", nic.NetStackInstanceKey)
+	nsi.IpRouteConfig = current
+	if err := updateHostNetStack(hns, nsi, types.ConfigSpecOperationEdit); err != nil {
+		return fmt.Errorf("error setting default gateway on TCP/IP stack %s: %s", nic.NetStackInstanceKey, err)
+	}
+	return nil
+}
+
+// netStackGateways returns the IPv4 and IPv6 default gateways configured on
+// a non-default TCP/IP stack. Empty values are returned for the default stack.
+func netStackGateways(client *govmomi.Client, hostID, key string) (string, string, error) {
+	if isSystemDefaultNetStack(key) {
+		return "", "", nil
+	}
+	hns, err := getHostNetworkSystem(client, hostID)
+	if err != nil {
+		return "", "", err
+	}
+	nsi, err := hostNetStackFromKey(client, hns, key)
+	if err != nil {
+		return "", "", err
+	}
+	if nsi.IpRouteConfig == nil {
+		return "", "", nil
+	}
+	rc := nsi.IpRouteConfig.GetHostIpRouteConfig()
+	return rc.DefaultGateway, rc.IpV6DefaultGateway, nil
+}
+
+// warnVnicMtuExceedsSwitch logs a warning when a jumbo frame MTU is requested
+// for a vmkernel adapter but the switch it is connected to is configured with
+// a smaller MTU. Failures to look up the switch are logged and ignored, since
+// the check is advisory only.
+func warnVnicMtuExceedsSwitch(client *govmomi.Client, hns *object.HostNetworkSystem, nic *types.HostVirtualNicSpec) {
+	if nic.Mtu <= vnicMtuStandard {
+		return
+	}
+	switchMtu, err := vnicSwitchMtu(client, hns, nic)
+	if err != nil {
+		log.Printf("[DEBUG] Could not determine switch MTU for vmkernel adapter: %s", err)
+		return
+	}
+	if switchMtu < nic.Mtu {
+		log.Printf(
+			"[WARN] vmkernel adapter MTU %d is larger than the MTU %d of the switch it is connected to. "+
+				"Jumbo frames must also be enabled on the switch and its physical uplinks.",
+			nic.Mtu,
+			switchMtu,
+		)
+	}
+}
+
+// vnicSwitchMtu returns the MTU of the standard or distributed switch that a
+// vmkernel adapter spec is connected to.
+func vnicSwitchMtu(client *govmomi.Client, hns *object.HostNetworkSystem, nic *types.HostVirtualNicSpec) (int32, error) {
+	if nic.Portgroup != "" {
+		pg, err := hostPortGroupFromName(client, hns, nic.Portgroup)
+		if err != nil {
+			return 0, err
+		}
+		sw, err := hostVSwitchFromName(client, hns, pg.Spec.VswitchName)
+		if err != nil {
+			return 0, err
+		}
+		return sw.Mtu, nil
+	}
+	if nic.DistributedVirtualPort != nil {
+		moid, err := utils.GetMoidStrict(client, utils.DISTRIBUTEDVIRTUALSWITCH, nic.DistributedVirtualPort.SwitchUuid)
+		if err != nil {
+			return 0, err
+		}
+		dvs, err := dvsFromMOID(client, moid)
+		if err != nil {
+			return 0, err
+		}
+		props, err := dvsProperties(dvs)
+		if err != nil {
+			return 0, err
+		}
+		config, ok := props.Config.(*types.VMwareDVSConfigInfo)
+		if !ok {
+			return 0, fmt.Errorf("unexpected configuration type %T for distributed switch %s", props.Config, nic.DistributedVirtualPort.SwitchUuid)
+		}
+		return config.MaxMtu, nil
+	}
+	return 0, fmt.Errorf("vmkernel adapter is not connected to a switch")
+}
+
+// warnVnicSubnetOverlap logs a warning for each vmkernel adapter on the host
+// that is on a different TCP/IP stack than the supplied spec but has a subnet
+// that overlaps with it. The host accepts such a configuration, but routing
+// between the stacks is ambiguous. Failures to look up the adapters are
+// logged and ignored, since the check is advisory only.
+func warnVnicSubnetOverlap(client *govmomi.Client, hostID, nicID string, nic *types.HostVirtualNicSpec) {
+	vnics, err := getVnicsFromHost(context.TODO(), client, hostID)
+	if err != nil {
+		log.Printf("[DEBUG] Could not read vmkernel adapters of host %s: %s", hostID, err)
+		return
+	}
+	for _, overlap := range overlappingVnics(nicID, nic, vnics) {
+		log.Printf(
+			"[WARN] The subnet of vmkernel adapter %s overlaps with the subnet of vmkernel adapter %s on a different TCP/IP stack of host %s. "+
+				"This can break routing on the host.",
+			nicIDOrNew(nicID),
+			overlap,
+			hostID,
+		)
+	}
+}
+
+// nicIDOrNew returns nicID, or a placeholder for an adapter that has not been
+// created yet.
+func nicIDOrNew(nicID string) string {
+	if nicID == "" {
+		return "(new)"
+	}
+	return nicID
+}
+
+// overlappingVnics returns the devices of the vmkernel adapters in vnics that
+// are on a different TCP/IP stack than nic and have a static subnet that
+// overlaps with a static subnet of nic. The adapter with the device nicID is
+// skipped.
+func overlappingVnics(nicID string, nic *types.HostVirtualNicSpec, vnics []types.HostVirtualNic) []string {
+	networks := vnicSpecNetworks(*nic, true)
+	if len(networks) < 1 {
+		return nil
+	}
+	var overlaps []string
+	for _, v := range vnics {
+		if v.Device == nicID || vnicNetStackKey(v.Spec.NetStackInstanceKey) == vnicNetStackKey(nic.NetStackInstanceKey) {
+			continue
+		}
+	search:
+		for _, a := range networks {
+			for _, b := range vnicSpecNetworks(v.Spec, false) {
+				if a.Contains(b.IP) || b.Contains(a.IP) {
+					overlaps = append(overlaps, v.Device)
+					break search
+				}
+			}
+		}
+	}
+	return overlaps
+}
+
+// vnicNetStackKey returns the key of a TCP/IP stack, with an empty key
+// meaning the default stack.
+func vnicNetStackKey(key string) string {
+	if isSystemDefaultNetStack(key) {
+		return string(types.HostNetStackInstanceSystemStackKeyDefaultTcpipStack)
+	}
+	return key
+}
+
+// vnicSpecNetworks returns the statically configured IPv4 and IPv6 subnets of
+// a vmkernel adapter spec. Link-local IPv6 addresses, which every adapter has,
+// are skipped. If isChange is set, the spec is treated as an update, and IPv6
+// addresses that are being removed are skipped.
+func vnicSpecNetworks(spec types.HostVirtualNicSpec, isChange bool) []*net.IPNet {
+	var networks []*net.IPNet
+	if spec.Ip == nil {
+		return networks
+	}
+	if !spec.Ip.Dhcp && spec.Ip.IpAddress != "" && spec.Ip.SubnetMask != "" {
+		ip := net.ParseIP(spec.Ip.IpAddress).To4()
+		mask := net.ParseIP(spec.Ip.SubnetMask).To4()
+		if ip != nil && mask != nil {
+			networks = append(networks, &net.IPNet{IP: ip.Mask(net.IPMask(mask)), Mask: net.IPMask(mask)})
+		}
+	}
+	if spec.Ip.IpV6Config != nil {
+		for _, addr := range spec.Ip.IpV6Config.IpV6Address {
+			if addr.Origin != string(types.HostIpConfigIpV6AddressConfigTypeManual) {
+				continue
+			}
+			if isChange && addr.Operation == "remove" {
+				continue
+			}
+			ip := net.ParseIP(addr.IpAddress)
+			if ip == nil || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			mask := net.CIDRMask(int(addr.PrefixLength), 128)
+			networks = append(networks, &net.IPNet{IP: ip.Mask(mask), Mask: mask})
+		}
+	}
+	return networks
+}
+
 func createVNic(d *schema.ResourceData, meta interface{}) (string, error) {
 	err := precheckEnableServices(d)
 	if err != nil {
 		return "", err
 	}
+	if err := precheckVnicMtu(d); err != nil {
+		return "", err
+	}
 
 	client := meta.(*Client).vimClient
 	ctx := context.TODO()
@@ -421,6 +735,13 @@ func createVNic(d *schema.ResourceData, meta interface{}) (string, error) {
 		return "", err
 	}
 
+	if err := precheckNetStackExists(client, hns, nic.NetStackInstanceKey); err != nil {
+		return "", err
+	}
+
+	warnVnicMtuExceedsSwitch(client, hns, nic)
+	warnVnicSubnetOverlap(client, hostID, "", nic)
+
 	portgroup := d.Get("portgroup").(string)
 	nicID, err := hns.AddVirtualNic(ctx, portgroup, *nic)
 	if err != nil {
@@ -428,21 +749,62 @@ func createVNic(d *schema.ResourceData, meta interface{}) (string, error) {
 	}
 	d.SetId(fmt.Sprintf("%s_%s", hostID, nicID))
 
+	remove := func() error {
+		return hns.RemoveVirtualNic(ctx, nicID)
+	}
+	if err := updateNetStackGateway(client, hns, nic); err != nil {
+		return "", rollbackVnic(d, err, remove)
+	}
+
 	err = updateVnicService(d, hostID, nicID, meta)
 	if err != nil {
-		return "", err
+		return "", rollbackVnic(d, err, remove)
 	}
 
 	return nicID, nil
 }
 
+// rollbackVnic removes a vnic that was created but could not be fully
+// configured, if rollback_on_failure is set, so that the host is left as it
+// was before the apply. The original error is always returned.
+func rollbackVnic(d *schema.ResourceData, cause error, remove func() error) error {
+	if !d.Get("rollback_on_failure").(bool) {
+		return cause
+	}
+	log.Printf("[DEBUG] Rolling back vnic %s after failure: %s", d.Id(), cause)
+	if err := remove(); err != nil {
+		return fmt.Errorf("%s; additionally, rolling back vnic %s failed: %s", cause, d.Id(), err)
+	}
+	d.SetId("")
+	return cause
+}
+
 func removeVnic(client *govmomi.Client, hostID, nicID string) error {
 	hns, err := getHostNetworkSystem(client, hostID)
 	if err != nil {
 		return err
 	}
 
-	return hns.RemoveVirtualNic(context.TODO(), nicID)
+	ctx := context.TODO()
+	return retryRemoveVnic(ctx, viapi.TaskRetryPolicyFor(client.Client), func() error {
+		return hns.RemoveVirtualNic(ctx, nicID)
+	})
+}
+
+// retryRemoveVnic runs remove with policy. A removal that failed with a
+// transient error may still have been applied on the host, so a retry that no
+// longer finds the vnic is treated as success.
+func retryRemoveVnic(ctx context.Context, policy viapi.TaskRetryPolicy, remove func() error) error {
+	retry := false
+	return policy.Retry(ctx, func() error {
+		err := remove()
+		if retry && viapi.IsAnyNotFoundError(err) {
+			log.Printf("[DEBUG] vnic no longer exists after retrying its removal")
+			return nil
+		}
+		retry = true
+		return err
+	})
 }
 
 func getHostNetworkSystem(client *govmomi.Client, hostID string) (*object.HostNetworkSystem, error) {
@@ -462,17 +824,42 @@ func getHostNetworkSystem(client *govmomi.Client, hostID string) (*object.HostNe
 	return hns, nil
 }
 
+func resourceVsphereNicCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !structure.ValuesAvailable("", []string{"portgroup", "distributed_switch_port", "distributed_port_group"}, d) {
+		return nil
+	}
+	return validateVnicBacking(
+		d.Get("portgroup").(string),
+		d.Get("distributed_switch_port").(string),
+		d.Get("distributed_port_group").(string),
+	)
+}
+
+// validateVnicBacking checks that a vmkernel adapter is attached to exactly
+// one network: either a standard portgroup, or a distributed port group given
+// by both the switch UUID and the port group key. Without a network, the
+// adapter has no backing and is rejected by the API with an unclear error.
+func validateVnicBacking(portgroup, dvp, dpg string) error {
+	switch {
+	case portgroup != "" && (dvp != "" || dpg != ""):
+		return fmt.Errorf("portgroup cannot be set together with distributed_switch_port or distributed_port_group")
+	case portgroup == "" && dvp == "" && dpg == "":
+		return fmt.Errorf("either portgroup, or distributed_switch_port and distributed_port_group, must be set")
+	case portgroup == "" && dpg == "":
+		return fmt.Errorf("distributed_port_group must be set when distributed_switch_port is set")
+	case portgroup == "" && dvp == "":
+		return fmt.Errorf("distributed_switch_port must be set when distributed_port_group is set")
+	}
+	return nil
+}
+
 func getNicSpecFromSchema(d *schema.ResourceData) (*types.HostVirtualNicSpec, error) {
 	portgroup := d.Get("portgroup").(string)
 	dvp := d.Get("distributed_switch_port").(string)
 	dpg := d.Get("distributed_port_group").(string)
-	mac := d.Get("mac").(string)
+	mac := canonicalMAC(d.Get("mac").(string))
 	mtu := int32(d.Get("mtu").(int))
 
-	if portgroup != "" && dvp != "" {
-		return nil, fmt.Errorf("portgroup and distributed_switch_port settings are mutually exclusive")
-	}
-
 	var dvpPortConnection *types.DistributedVirtualSwitchPortConnection
 	if portgroup != "" {
 		dvpPortConnection = nil
@@ -498,7 +885,9 @@ func getNicSpecFromSchema(d *schema.ResourceData) (*types.HostVirtualNicSpec, er
 		} else if ipv4Address != "" && ipv4Netmask != "" {
 			ipConfig.IpAddress = ipv4Address
 			ipConfig.SubnetMask = ipv4Netmask
-			routeConfig.DefaultGateway = ipv4Gateway
+			if ipv4Config["enable_default_gateway"].(bool) {
+				routeConfig.DefaultGateway = ipv4Gateway
+			}
 		}
 	}
 
@@ -523,7 +912,7 @@ func getNicSpecFromSchema(d *schema.ResourceData) (*types.HostVirtualNicSpec, er
 		for _, old := range oldAddrs {
 			addrFound := false
 			for _, newAddr := range newAddrs {
-				if old == newAddr {
+				if canonicalIPv6CIDR(old.(string)) == canonicalIPv6CIDR(newAddr.(string)) {
 					addrFound = true
 					break
 				}
@@ -537,7 +926,7 @@ func getNicSpecFromSchema(d *schema.ResourceData) (*types.HostVirtualNicSpec, er
 		for _, newAddr := range newAddrs {
 			addrFound := false
 			for _, old := range oldAddrs {
-				if newAddr == old {
+				if canonicalIPv6CIDR(newAddr.(string)) == canonicalIPv6CIDR(old.(string)) {
 					addrFound = true
 					break
 				}
@@ -557,7 +946,7 @@ func getNicSpecFromSchema(d *schema.ResourceData) (*types.HostVirtualNicSpec, er
 					return nil, fmt.Errorf("error while parsing IPv6 address")
 				}
 				tmpAddr := types.HostIpConfigIpV6Address{
-					IpAddress:    strings.ToLower(addr),
+					IpAddress:    canonicalIPv6Address(addr),
 					PrefixLength: int32(prefix),
 					Origin:       "manual",
 					Operation:    "remove",
@@ -573,7 +962,7 @@ func getNicSpecFromSchema(d *schema.ResourceData) (*types.HostVirtualNicSpec, er
 					return nil, fmt.Errorf("error while parsing IPv6 address")
 				}
 				tmpAddr := types.HostIpConfigIpV6Address{
-					IpAddress:    strings.ToLower(addr),
+					IpAddress:    canonicalIPv6Address(addr),
 					PrefixLength: int32(prefix),
 					Origin:       "manual",
 					Operation:    "add",
@@ -582,12 +971,22 @@ func getNicSpecFromSchema(d *schema.ResourceData) (*types.HostVirtualNicSpec, er
 			}
 			ipv6Spec.IpV6Address = addrs
 		}
-		routeConfig.IpV6DefaultGateway = ipv6Gateway
+		if ipv6Config["enable_default_gateway"].(bool) {
+			routeConfig.IpV6DefaultGateway = ipv6Gateway
+		}
 		ipConfig.IpV6Config = ipv6Spec
 	}
 
-	r := &types.HostVirtualNicIpRouteSpec{
-		IpRouteConfig: routeConfig,
+	// Point-to-point adapters, such as on a /31 storage network, have no
+	// gateway. Leave the route spec out so that the host does not install a
+	// default route, unless a gateway is being removed from the adapter or
+	// the default gateway of an existing adapter is being disabled.
+	var r *types.HostVirtualNicIpRouteSpec
+	if routeConfig.DefaultGateway != "" || routeConfig.IpV6DefaultGateway != "" || d.HasChanges("ipv4.0.gw", "ipv6.0.gw") ||
+		(d.Id() != "" && d.HasChanges("ipv4.0.enable_default_gateway", "ipv6.0.enable_default_gateway")) {
+		r = &types.HostVirtualNicIpRouteSpec{
+			IpRouteConfig: routeConfig,
+		}
 	}
 
 	netStackInstance := d.Get("netstack").(string)
@@ -605,18 +1004,10 @@ func getNicSpecFromSchema(d *schema.ResourceData) (*types.HostVirtualNicSpec, er
 }
 
 func getVnicFromHost(ctx context.Context, client *govmomi.Client, hostID, nicID string) (*types.HostVirtualNic, error) {
-	host, err := hostsystem.FromID(client, hostID)
+	vNics, err := getVnicsFromHost(ctx, client, hostID)
 	if err != nil {
 		return nil, err
 	}
-
-	var hostProps mo.HostSystem
-	err = host.Properties(ctx, host.Reference(), nil, &hostProps)
-	if err != nil {
-		log.Printf("[DEBUG] Failed to get the host's properties: %s", err)
-		return nil, err
-	}
-	vNics := hostProps.Config.Network.Vnic
 	nicIdx := -1
 	for idx, vnic := range vNics {
 		log.Printf("[DEBUG] Evaluating nic: %s", vnic.Device)
@@ -632,6 +1023,290 @@ func getVnicFromHost(ctx context.Context, client *govmomi.Client, hostID, nicID
 	return &vNics[nicIdx], nil
 }
 
+// getVnicsFromHost returns all of the vmkernel adapters configured on a host.
+func getVnicsFromHost(ctx context.Context, client *govmomi.Client, hostID string) ([]types.HostVirtualNic, error) {
+	host, err := hostsystem.FromID(client, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	var hostProps mo.HostSystem
+	err = host.Properties(ctx, host.Reference(), nil, &hostProps)
+	if err != nil {
+		log.Printf("[DEBUG] Failed to get the host's properties: %s", err)
+		return nil, err
+	}
+	if hostProps.Config == nil || hostProps.Config.Network == nil {
+		return nil, fmt.Errorf("network configuration for host %s is not available", hostID)
+	}
+	return hostProps.Config.Network.Vnic, nil
+}
+
+// vnicActiveUplink returns the physical NIC that carries the traffic of a
+// vmkernel adapter. This is the first physical NIC with a link in the active,
+// and then standby, uplinks of the teaming policy of the adapter's standard
+// or distributed portgroup. An empty string is returned if no such physical
+// NIC exists.
+func vnicActiveUplink(ctx context.Context, client *govmomi.Client, hostID string, vnic *types.HostVirtualNic) (string, error) {
+	host, err := hostsystem.FromID(client, hostID)
+	if err != nil {
+		return "", err
+	}
+	var hostProps mo.HostSystem
+	if err := host.Properties(ctx, host.Reference(), []string{"config.network"}, &hostProps); err != nil {
+		return "", err
+	}
+	if hostProps.Config == nil || hostProps.Config.Network == nil {
+		return "", fmt.Errorf("network configuration for host %s is not available", hostID)
+	}
+	netInfo := hostProps.Config.Network
+
+	if dvp := vnic.Spec.DistributedVirtualPort; dvp != nil {
+		pg, err := dvportgroup.FromKey(client, dvp.SwitchUuid, dvp.PortgroupKey)
+		if err != nil {
+			return "", err
+		}
+		props, err := dvportgroup.Properties(pg)
+		if err != nil {
+			return "", err
+		}
+		var active, standby []string
+		if setting, ok := props.Config.DefaultPortConfig.(*types.VMwareDVSPortSetting); ok && setting.UplinkTeamingPolicy != nil && setting.UplinkTeamingPolicy.UplinkPortOrder != nil {
+			active = setting.UplinkTeamingPolicy.UplinkPortOrder.ActiveUplinkPort
+			standby = setting.UplinkTeamingPolicy.UplinkPortOrder.StandbyUplinkPort
+		}
+		return firstLinkedPnic(netInfo, append(
+			distributedUplinkPnics(netInfo, dvp.SwitchUuid, active),
+			distributedUplinkPnics(netInfo, dvp.SwitchUuid, standby)...,
+		)), nil
+	}
+	return firstLinkedPnic(netInfo, standardPortgroupPnics(netInfo, vnic.Portgroup)), nil
+}
+
+// standardPortgroupPnics returns the active, and then standby, physical NICs
+// of the effective teaming policy of a standard portgroup.
+func standardPortgroupPnics(netInfo *types.HostNetworkInfo, portgroup string) []string {
+	for _, pg := range netInfo.Portgroup {
+		if pg.Spec.Name != portgroup {
+			continue
+		}
+		teaming := pg.ComputedPolicy.NicTeaming
+		if teaming == nil || teaming.NicOrder == nil {
+			return nil
+		}
+		return append(append([]string{}, teaming.NicOrder.ActiveNic...), teaming.NicOrder.StandbyNic...)
+	}
+	return nil
+}
+
+// distributedUplinkPnics maps the names of uplink ports of a distributed
+// switch to the physical NICs of the host that back them, keeping the order
+// of the uplinks.
+func distributedUplinkPnics(netInfo *types.HostNetworkInfo, switchUUID string, uplinks []string) []string {
+	var pnics []string
+	for _, ps := range netInfo.ProxySwitch {
+		if ps.DvsUuid != switchUUID {
+			continue
+		}
+		backing, ok := ps.Spec.Backing.(*types.DistributedVirtualSwitchHostMemberPnicBacking)
+		if !ok {
+			return nil
+		}
+		for _, uplink := range uplinks {
+			for _, port := range ps.UplinkPort {
+				if port.Value != uplink {
+					continue
+				}
+				for _, pnic := range backing.PnicSpec {
+					if pnic.UplinkPortKey == port.Key {
+						pnics = append(pnics, pnic.PnicDevice)
+					}
+				}
+			}
+		}
+	}
+	return pnics
+}
+
+// firstLinkedPnic returns the first physical NIC in pnics that has a link.
+func firstLinkedPnic(netInfo *types.HostNetworkInfo, pnics []string) string {
+	for _, device := range pnics {
+		for _, pnic := range netInfo.Pnic {
+			if pnic.Device == device && pnic.LinkSpeed != nil {
+				return device
+			}
+		}
+	}
+	return ""
+}
+
+// getVnicServicesFromHost returns the services enabled on the vmkernel
+// adapters of a host, keyed by adapter device name.
+func getVnicServicesFromHost(ctx context.Context, client *govmomi.Client, hostID string) (map[string][]string, error) {
+	hostSystem, err := hostsystem.FromID(client, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	hostVnicMgr, err := hostSystem.ConfigManager().VirtualNicManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hostVnicMgrInfo, err := hostVnicMgr.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string][]string)
+	for _, netConfig := range hostVnicMgrInfo.NetConfig {
+		for _, selected := range netConfig.SelectedVnic {
+			for _, candidate := range netConfig.CandidateVnic {
+				if candidate.Key == selected {
+					services[candidate.Device] = append(services[candidate.Device], netConfig.NicType)
+				}
+			}
+		}
+	}
+	return services, nil
+}
+
+// flattenHostVirtualNicIPv4 returns the IPv4 configuration of a vmkernel
+// adapter in the format of the ipv4 schema block, or nil if IPv4 is not
+// configured.
+func flattenHostVirtualNicIPv4(spec types.HostVirtualNicSpec) map[string]interface{} {
+	// IpAddress will be an empty string if ipv4 is off
+	if spec.Ip == nil || spec.Ip.IpAddress == "" {
+		return nil
+	}
+	// if DHCP is true then we should ignore whatever addresses are set here.
+	ipv4dict := make(map[string]interface{})
+	ipv4dict["dhcp"] = spec.Ip.Dhcp
+	if !spec.Ip.Dhcp {
+		ipv4dict["ip"] = spec.Ip.IpAddress
+		ipv4dict["netmask"] = spec.Ip.SubnetMask
+		ipv4dict["gw"] = ""
+		if spec.IpRouteSpec != nil && spec.IpRouteSpec.IpRouteConfig != nil {
+			ipv4dict["gw"] = vnicGateway(spec.IpRouteSpec.IpRouteConfig.GetHostIpRouteConfig().DefaultGateway)
+		}
+	}
+	return ipv4dict
+}
+
+// preserveVnicDefaultGateway sets enable_default_gateway in the flattened ipv4
+// or ipv6 block from the current state, as it is not reported by the host. If
+// the default gateway is disabled, the adapter has no gateway and the gw of
+// the current state is kept as well. Without a block in the state, such as on
+// import, the default gateway is reported as enabled.
+func preserveVnicDefaultGateway(d *schema.ResourceData, block string, dict map[string]interface{}) {
+	enabled := true
+	if len(d.Get(block).([]interface{})) > 0 {
+		enabled = d.Get(block + ".0.enable_default_gateway").(bool)
+	}
+	dict["enable_default_gateway"] = enabled
+	if !enabled {
+		dict["gw"] = d.Get(block + ".0.gw").(string)
+	}
+}
+
+// canonicalIPv6Address returns the canonical, compressed form of an IPv6
+// address, such as 2001:db8::1 for 2001:DB8:0:0:0:0:0:1. Values that cannot
+// be parsed are only lowercased.
+func canonicalIPv6Address(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return strings.ToLower(addr)
+	}
+	return ip.String()
+}
+
+// canonicalMAC returns the canonical form of a MAC address, in lowercase and
+// separated by colons, such as 00:50:56:ab:cd:ef for 00-50-56-AB-CD-EF. Values
+// that cannot be parsed are only lowercased.
+func canonicalMAC(mac string) string {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return strings.ToLower(mac)
+	}
+	return hw.String()
+}
+
+// validateVnicMAC checks that the mac attribute of a vmkernel adapter is a
+// 48-bit MAC address, and warns if it does not use one of the VMware OUIs.
+func validateVnicMAC(v interface{}, k string) ([]string, []error) {
+	mac := v.(string)
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return nil, []error{fmt.Errorf("%q must be a 48-bit MAC address, such as 00:50:56:ab:cd:ef, got %q", k, mac)}
+	}
+	oui := hw.String()[:8]
+	for _, o := range vnicVMwareOUIs {
+		if oui == o {
+			return nil, nil
+		}
+	}
+	return []string{fmt.Sprintf("%q %s does not use a VMware OUI (%s)", k, mac, strings.Join(vnicVMwareOUIs, ", "))}, nil
+}
+
+// canonicalIPv6CIDR returns the canonical form of an IPv6 address in
+// address/prefix notation, as used in the addresses attribute.
+func canonicalIPv6CIDR(cidr string) string {
+	addr, prefix, found := strings.Cut(cidr, "/")
+	if !found {
+		return canonicalIPv6Address(addr)
+	}
+	if n, err := strconv.Atoi(prefix); err == nil {
+		prefix = strconv.Itoa(n)
+	}
+	return canonicalIPv6Address(addr) + "/" + prefix
+}
+
+// flattenHostVirtualNicIPv6 returns the IPv6 configuration of a vmkernel
+// adapter in the format of the ipv6 schema block, or nil if IPv6 is not
+// configured.
+func flattenHostVirtualNicIPv6(spec types.HostVirtualNicSpec) map[string]interface{} {
+	// IpV6Config will be nil if ipv6 is off
+	if spec.Ip == nil || spec.Ip.IpV6Config == nil {
+		return nil
+	}
+	ipv6Config := spec.Ip.IpV6Config
+	dhcp := ipv6Config.DhcpV6Enabled != nil && *ipv6Config.DhcpV6Enabled
+	autoconfig := ipv6Config.AutoConfigurationEnabled != nil && *ipv6Config.AutoConfigurationEnabled
+
+	// First we need to filter out addresses that were configured via dhcp or autoconfig
+	// or link local or any other mechanism
+	addrList := make([]string, 0)
+	for _, addr := range ipv6Config.IpV6Address {
+		if addr.Origin == "manual" {
+			addrList = append(addrList, fmt.Sprintf("%s/%d", canonicalIPv6Address(addr.IpAddress), addr.PrefixLength))
+		}
+	}
+	if len(addrList) == 0 && !dhcp && !autoconfig {
+		return nil
+	}
+
+	ipv6dict := map[string]interface{}{
+		"dhcp":       dhcp,
+		"autoconfig": autoconfig,
+		"addresses":  addrList,
+	}
+	ipv6dict["gw"] = ""
+	if spec.IpRouteSpec != nil && spec.IpRouteSpec.IpRouteConfig != nil {
+		ipv6dict["gw"] = vnicGateway(canonicalIPv6Address(spec.IpRouteSpec.IpRouteConfig.GetHostIpRouteConfig().IpV6DefaultGateway))
+	}
+	return ipv6dict
+}
+
+// vnicGateway returns the default gateway reported for an adapter, or an
+// empty string if the adapter has no gateway. Hosts can report an adapter
+// without a gateway with the unspecified address, such as 0.0.0.0 or ::.
+func vnicGateway(gw string) string {
+	if ip := net.ParseIP(gw); ip != nil && ip.IsUnspecified() {
+		return ""
+	}
+	return gw
+}
+
 func splitHostIDNicID(d *schema.ResourceData) (string, string) {
 	idParts := strings.Split(d.Id(), "_")
 	return idParts[0], idParts[1]
@@ -8,16 +8,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/viapi"
 )
 
 // TODO: move away from tests being composed in this manner
@@ -39,6 +45,7 @@ func generateSteps(cfgFunc genTfConfig, netstack string) []resource.TestStep {
 					Config: cfgFunc(cfg),
 					Check: resource.ComposeTestCheckFunc(
 						testAccVsphereVNicNetworkSettings("vsphere_vnic.v1", ipv4, ipv6, netstack),
+						resource.TestMatchResourceAttr("vsphere_vnic.v1", "device", regexp.MustCompile(`^vmk\d+$`)),
 					),
 				},
 			}...)
@@ -124,6 +131,36 @@ func TestAccResourceVSphereVNic_hvs_vmotion(t *testing.T) {
 	})
 }
 
+func TestAccResourceVSphereVNic_hvs_vmotionGateway(t *testing.T) {
+	testAccSkipUnstable(t)
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccVSphereVNicDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testaccvspherevnicconfigHvs(combineSnippets(
+					ipv4Snippet("192.0.2.10|255.255.255.0|192.0.2.1"),
+					"",
+					netstackSnippet("vmotion"))),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_vnic.v1", "ipv4.0.gw", "192.0.2.1"),
+				),
+			},
+			{
+				Config: testaccvspherevnicconfigHvs(combineSnippets(
+					ipv4Snippet("192.0.2.10|255.255.255.0|192.0.2.1"),
+					"",
+					netstackSnippet("vmotion"))),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
 func TestAccResourceVSphereVNic_services_nonDefaultNetstack(t *testing.T) {
 	testAccSkipUnstable(t)
 	resource.Test(t, resource.TestCase{
@@ -177,6 +214,33 @@ func TestAccResourceVSphereVNic_services_invalid(t *testing.T) {
 	})
 }
 
+func TestAccResourceVSphereVNic_mtu_invalid(t *testing.T) {
+	testAccSkipUnstable(t)
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccVSphereVNicDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testaccvspherevnicconfigHvs(
+					combineSnippets(
+						ipv4Snippet("192.0.2.10|255.255.255.0|192.0.2.1"),
+						"",
+						netstackSnippet("defaultTcpipStack"),
+						"",
+						`mtu = 90000`,
+					),
+				),
+				ExpectError: regexp.MustCompile("expected mtu to be in the range"),
+				PlanOnly:    true,
+			},
+		},
+	})
+}
+
 func TestAccResourceVSphereVNic_services_valid(t *testing.T) {
 	testAccSkipUnstable(t)
 	resource.Test(t, resource.TestCase{
@@ -238,6 +302,542 @@ func TestAccResourceVSphereVNic_services_valid(t *testing.T) {
 	})
 }
 
+func TestAccResourceVSphereVNic_ipv6CanonicalForm(t *testing.T) {
+	testAccSkipUnstable(t)
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccVSphereVNicDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testaccvspherevnicconfigHvs(
+					combineSnippets(
+						ipv6Snippet("2001:db8::10/32|2001:db8::1"),
+						netstackSnippet("defaultTcpipStack"),
+					),
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVsphereVNicNetworkSettings("vsphere_vnic.v1", "", "2001:db8::10/32|2001:db8::1", "defaultTcpipStack"),
+				),
+			},
+			{
+				Config: testaccvspherevnicconfigHvs(
+					combineSnippets(
+						ipv6Snippet("2001:DB8:0:0:0:0:0:10/32|2001:DB8:0:0:0:0:0:1"),
+						netstackSnippet("defaultTcpipStack"),
+					),
+				),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestCanonicalIPv6CIDR(t *testing.T) {
+	cases := []struct {
+		name     string
+		subject  string
+		expected string
+	}{
+		{
+			name:     "compressed",
+			subject:  "2001:db8::1/64",
+			expected: "2001:db8::1/64",
+		},
+		{
+			name:     "expanded uppercase",
+			subject:  "2001:DB8:0:0:0:0:0:1/64",
+			expected: "2001:db8::1/64",
+		},
+		{
+			name:     "leading zeroes",
+			subject:  "2001:0db8:0000:0000:0000:0000:0000:0001/064",
+			expected: "2001:db8::1/64",
+		},
+		{
+			name:     "no prefix",
+			subject:  "2001:DB8::1",
+			expected: "2001:db8::1",
+		},
+		{
+			name:     "unparseable",
+			subject:  "NotAnAddress/64",
+			expected: "notanaddress/64",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := canonicalIPv6CIDR(tc.subject)
+			if tc.expected != actual {
+				t.Fatalf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateVnicMAC(t *testing.T) {
+	cases := []struct {
+		name        string
+		mac         string
+		expectWarn  bool
+		expectError bool
+	}{
+		{
+			name: "vmware oui",
+			mac:  "00:50:56:ab:cd:ef",
+		},
+		{
+			name: "uppercase with dashes",
+			mac:  "00-0C-29-AB-CD-EF",
+		},
+		{
+			name:       "other oui",
+			mac:        "02:00:00:ab:cd:ef",
+			expectWarn: true,
+		},
+		{
+			name:        "too short",
+			mac:         "00:50:56:ab:cd",
+			expectError: true,
+		},
+		{
+			name:        "not hexadecimal",
+			mac:         "00:50:56:xy:cd:ef",
+			expectError: true,
+		},
+		{
+			name:        "64-bit address",
+			mac:         "00:50:56:ab:cd:ef:01:02",
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			warns, errs := validateVnicMAC(tc.mac, "mac")
+			if tc.expectWarn != (len(warns) > 0) {
+				t.Fatalf("expected warnings to be %t, got %v", tc.expectWarn, warns)
+			}
+			if tc.expectError != (len(errs) > 0) {
+				t.Fatalf("expected errors to be %t, got %v", tc.expectError, errs)
+			}
+		})
+	}
+}
+
+func TestCanonicalMAC(t *testing.T) {
+	cases := []struct {
+		mac      string
+		expected string
+	}{
+		{mac: "00:50:56:ab:cd:ef", expected: "00:50:56:ab:cd:ef"},
+		{mac: "00:50:56:AB:CD:EF", expected: "00:50:56:ab:cd:ef"},
+		{mac: "00-50-56-AB-CD-EF", expected: "00:50:56:ab:cd:ef"},
+		{mac: "0050.56ab.cdef", expected: "00:50:56:ab:cd:ef"},
+		{mac: "INVALID", expected: "invalid"},
+	}
+	for _, tc := range cases {
+		if actual := canonicalMAC(tc.mac); tc.expected != actual {
+			t.Fatalf("expected canonical MAC of %q to be %q, got %q", tc.mac, tc.expected, actual)
+		}
+	}
+}
+
+func TestGetNicSpecFromSchemaDefaultGateway(t *testing.T) {
+	cases := []struct {
+		name         string
+		enabled      bool
+		expectedIPv4 string
+		expectedIPv6 string
+	}{
+		{
+			name:         "enabled",
+			enabled:      true,
+			expectedIPv4: "192.0.2.1",
+			expectedIPv6: "2001:db8::1",
+		},
+		{
+			name:    "disabled",
+			enabled: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, vNicSchema(), map[string]interface{}{
+				"host":      "host-1",
+				"portgroup": "pg-01",
+				"ipv4": []interface{}{
+					map[string]interface{}{
+						"ip":                     "192.0.2.10",
+						"netmask":                "255.255.255.0",
+						"gw":                     "192.0.2.1",
+						"enable_default_gateway": tc.enabled,
+					},
+				},
+				"ipv6": []interface{}{
+					map[string]interface{}{
+						"addresses":              []interface{}{"2001:db8::10/64"},
+						"gw":                     "2001:db8::1",
+						"enable_default_gateway": tc.enabled,
+					},
+				},
+			})
+			spec, err := getNicSpecFromSchema(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rc := spec.IpRouteSpec.IpRouteConfig.GetHostIpRouteConfig()
+			if rc.DefaultGateway != tc.expectedIPv4 {
+				t.Fatalf("expected IPv4 default gateway %q, got %q", tc.expectedIPv4, rc.DefaultGateway)
+			}
+			if rc.IpV6DefaultGateway != tc.expectedIPv6 {
+				t.Fatalf("expected IPv6 default gateway %q, got %q", tc.expectedIPv6, rc.IpV6DefaultGateway)
+			}
+			if spec.Ip.IpAddress != "192.0.2.10" {
+				t.Fatalf("expected IPv4 address to be set, got %q", spec.Ip.IpAddress)
+			}
+		})
+	}
+}
+
+func TestGetNicSpecFromSchemaDisableDefaultGateway(t *testing.T) {
+	sm := schema.InternalMap(vNicSchema())
+	ipv4 := func(enabled bool) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"ip":                     "192.0.2.10",
+				"netmask":                "255.255.255.0",
+				"gw":                     "192.0.2.1",
+				"enable_default_gateway": enabled,
+			},
+		}
+	}
+	state := schema.TestResourceDataRaw(t, sm, map[string]interface{}{
+		"host":      "host-1",
+		"portgroup": "pg-01",
+		"ipv4":      ipv4(true),
+	})
+	state.SetId("host-1_vmk1")
+	config := sdkterraform.NewResourceConfigRaw(map[string]interface{}{
+		"host":      "host-1",
+		"portgroup": "pg-01",
+		"ipv4":      ipv4(false),
+	})
+	diff, err := sm.Diff(context.Background(), state.State(), config, nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := sm.Data(state.State(), diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := getNicSpecFromSchema(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.IpRouteSpec == nil {
+		t.Fatal("expected a route spec that removes the default gateway, got none")
+	}
+	if rc := spec.IpRouteSpec.IpRouteConfig.GetHostIpRouteConfig(); rc.DefaultGateway != "" {
+		t.Fatalf("expected no IPv4 default gateway, got %q", rc.DefaultGateway)
+	}
+}
+
+func TestGetNicSpecFromSchemaNoGateway(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, vNicSchema(), map[string]interface{}{
+		"host":      "host-1",
+		"portgroup": "pg-storage",
+		"ipv4": []interface{}{
+			map[string]interface{}{
+				"ip":      "198.51.100.0",
+				"netmask": "255.255.255.254",
+			},
+		},
+	})
+	spec, err := getNicSpecFromSchema(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.IpRouteSpec != nil {
+		t.Fatalf("expected no route spec for an adapter without a gateway, got %#v", spec.IpRouteSpec.IpRouteConfig)
+	}
+	if spec.Ip.IpAddress != "198.51.100.0" || spec.Ip.SubnetMask != "255.255.255.254" {
+		t.Fatalf("expected the static address to be set, got %s/%s", spec.Ip.IpAddress, spec.Ip.SubnetMask)
+	}
+}
+
+func TestFlattenHostVirtualNicNoGateway(t *testing.T) {
+	cases := []struct {
+		name      string
+		routeSpec *types.HostVirtualNicIpRouteSpec
+	}{
+		{
+			name: "no route spec",
+		},
+		{
+			name: "unspecified gateways",
+			routeSpec: &types.HostVirtualNicIpRouteSpec{
+				IpRouteConfig: &types.HostIpRouteConfig{
+					DefaultGateway:     "0.0.0.0",
+					IpV6DefaultGateway: "::",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					IpAddress:  "198.51.100.0",
+					SubnetMask: "255.255.255.254",
+					IpV6Config: &types.HostIpConfigIpV6AddressConfiguration{
+						IpV6Address: []types.HostIpConfigIpV6Address{
+							{IpAddress: "2001:db8::10", PrefixLength: 127, Origin: "manual"},
+						},
+					},
+				},
+				IpRouteSpec: tc.routeSpec,
+			}
+			if gw := flattenHostVirtualNicIPv4(spec)["gw"]; gw != "" {
+				t.Fatalf("expected no IPv4 gateway, got %q", gw)
+			}
+			if gw := flattenHostVirtualNicIPv6(spec)["gw"]; gw != "" {
+				t.Fatalf("expected no IPv6 gateway, got %q", gw)
+			}
+		})
+	}
+}
+
+func TestVnicIPv6Modes(t *testing.T) {
+	cases := []struct {
+		name         string
+		config       map[string]interface{}
+		expectedDHCP bool
+		expectedAuto bool
+	}{
+		{
+			name:   "static",
+			config: map[string]interface{}{"addresses": []interface{}{"2001:db8::10/64"}},
+		},
+		{
+			name:         "autoconfig",
+			config:       map[string]interface{}{"autoconfig": true},
+			expectedAuto: true,
+		},
+		{
+			name:         "dhcp",
+			config:       map[string]interface{}{"dhcp": true, "autoconfig": true},
+			expectedDHCP: true,
+			expectedAuto: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, vNicSchema(), map[string]interface{}{
+				"host":      "host-1",
+				"portgroup": "pg-01",
+				"ipv6":      []interface{}{tc.config},
+			})
+			spec, err := getNicSpecFromSchema(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ipv6Config := spec.Ip.IpV6Config
+			if *ipv6Config.DhcpV6Enabled != tc.expectedDHCP {
+				t.Fatalf("expected DHCPv6 to be %t", tc.expectedDHCP)
+			}
+			if *ipv6Config.AutoConfigurationEnabled != tc.expectedAuto {
+				t.Fatalf("expected autoconfiguration to be %t", tc.expectedAuto)
+			}
+
+			// Reading the adapter back must match the configuration.
+			ipv6Config.IpV6Address = nil
+			addrs, _ := tc.config["addresses"].([]interface{})
+			for _, addr := range addrs {
+				ipv6Config.IpV6Address = append(ipv6Config.IpV6Address, types.HostIpConfigIpV6Address{
+					IpAddress:    strings.Split(addr.(string), "/")[0],
+					PrefixLength: 64,
+					Origin:       "manual",
+				})
+			}
+			dict := flattenHostVirtualNicIPv6(*spec)
+			for _, k := range []string{"dhcp", "autoconfig"} {
+				if expected := d.Get("ipv6.0." + k).(bool); dict[k].(bool) != expected {
+					t.Fatalf("expected %s to be read as %t, got %t", k, expected, dict[k])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateVnicBacking(t *testing.T) {
+	cases := []struct {
+		name      string
+		portgroup string
+		dvp       string
+		dpg       string
+		expected  string
+	}{
+		{
+			name:      "standard portgroup",
+			portgroup: "pg-01",
+		},
+		{
+			name: "distributed port group",
+			dvp:  "50 1d 2a 3b",
+			dpg:  "dvportgroup-10",
+		},
+		{
+			name:     "no network",
+			expected: "either portgroup, or distributed_switch_port and distributed_port_group, must be set",
+		},
+		{
+			name:      "portgroup and distributed switch",
+			portgroup: "pg-01",
+			dvp:       "50 1d 2a 3b",
+			expected:  "portgroup cannot be set together with distributed_switch_port or distributed_port_group",
+		},
+		{
+			name:      "portgroup and distributed port group",
+			portgroup: "pg-01",
+			dpg:       "dvportgroup-10",
+			expected:  "portgroup cannot be set together with distributed_switch_port or distributed_port_group",
+		},
+		{
+			name:     "distributed switch only",
+			dvp:      "50 1d 2a 3b",
+			expected: "distributed_port_group must be set when distributed_switch_port is set",
+		},
+		{
+			name:     "distributed port group only",
+			dpg:      "dvportgroup-10",
+			expected: "distributed_switch_port must be set when distributed_port_group is set",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateVnicBacking(tc.portgroup, tc.dvp, tc.dpg)
+			switch {
+			case tc.expected == "" && err != nil:
+				t.Fatalf("expected no error, got %s", err)
+			case tc.expected != "" && (err == nil || err.Error() != tc.expected):
+				t.Fatalf("expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestRollbackVnic(t *testing.T) {
+	cause := errors.New("could not enable services")
+	cases := []struct {
+		name            string
+		rollback        bool
+		removeErr       error
+		expectedRemoved bool
+		expectedID      string
+		expectedErr     string
+	}{
+		{
+			name:            "rollback disabled",
+			rollback:        false,
+			expectedRemoved: false,
+			expectedID:      "host-1_vmk1",
+			expectedErr:     "could not enable services",
+		},
+		{
+			name:            "rollback enabled",
+			rollback:        true,
+			expectedRemoved: true,
+			expectedID:      "",
+			expectedErr:     "could not enable services",
+		},
+		{
+			name:            "rollback fails",
+			rollback:        true,
+			removeErr:       errors.New("host not responding"),
+			expectedRemoved: true,
+			expectedID:      "host-1_vmk1",
+			expectedErr:     "could not enable services; additionally, rolling back vnic host-1_vmk1 failed: host not responding",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, vNicSchema(), map[string]interface{}{
+				"host":                "host-1",
+				"rollback_on_failure": tc.rollback,
+			})
+			d.SetId("host-1_vmk1")
+			removed := false
+			err := rollbackVnic(d, cause, func() error {
+				removed = true
+				return tc.removeErr
+			})
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+			}
+			if tc.expectedRemoved != removed {
+				t.Fatalf("expected removal to be %t, got %t", tc.expectedRemoved, removed)
+			}
+			if tc.expectedID != d.Id() {
+				t.Fatalf("expected ID %q, got %q", tc.expectedID, d.Id())
+			}
+		})
+	}
+}
+
+func TestRetryRemoveVnic(t *testing.T) {
+	transient := &url.Error{Op: "POST", URL: "/sdk", Err: errors.New("503 Service Unavailable")}
+	fault := &soap.Fault{}
+	fault.Detail.Fault = types.NotFound{}
+	notFound := soap.WrapSoapFault(fault)
+	cases := []struct {
+		name          string
+		errs          []error
+		expectErr     bool
+		expectAttempt int
+	}{
+		{
+			name:          "removed",
+			errs:          []error{nil},
+			expectAttempt: 1,
+		},
+		{
+			name:          "not found on the first attempt",
+			errs:          []error{notFound},
+			expectErr:     true,
+			expectAttempt: 1,
+		},
+		{
+			name:          "not found after a transient error",
+			errs:          []error{transient, notFound},
+			expectAttempt: 2,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempt := 0
+			err := retryRemoveVnic(context.Background(), viapi.TaskRetryPolicy{Retries: 3}, func() error {
+				err := tc.errs[attempt]
+				attempt++
+				return err
+			})
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+			if tc.expectAttempt != attempt {
+				t.Fatalf("expected %d attempts, got %d", tc.expectAttempt, attempt)
+			}
+		})
+	}
+}
+
 func testAccVsphereVNicNetworkSettings(name, ipv4State, ipv6State, netstack string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[name]
@@ -528,3 +1128,211 @@ func netstackSnippet(stack string) string {
 	  netstack = "%s"
 	`, stack)
 }
+
+func TestOverlappingVnics(t *testing.T) {
+	vnics := []types.HostVirtualNic{
+		{
+			Device: "vmk0",
+			Spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{IpAddress: "10.0.0.10", SubnetMask: "255.255.255.0"},
+			},
+		},
+		{
+			Device: "vmk1",
+			Spec: types.HostVirtualNicSpec{
+				Ip:                  &types.HostIpConfig{IpAddress: "10.0.1.10", SubnetMask: "255.255.255.0"},
+				NetStackInstanceKey: "vmotion",
+			},
+		},
+		{
+			Device: "vmk2",
+			Spec: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					IpV6Config: &types.HostIpConfigIpV6AddressConfiguration{
+						IpV6Address: []types.HostIpConfigIpV6Address{
+							{IpAddress: "fe80::1", PrefixLength: 64, Origin: "other"},
+							{IpAddress: "fd00::10", PrefixLength: 64, Origin: "manual"},
+						},
+					},
+				},
+				NetStackInstanceKey: "vSphereProvisioning",
+			},
+		},
+		{
+			Device: "vmk3",
+			Spec: types.HostVirtualNicSpec{
+				Ip:                  &types.HostIpConfig{Dhcp: true},
+				NetStackInstanceKey: "vSphereProvisioning",
+			},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		nicID    string
+		nic      types.HostVirtualNicSpec
+		expected []string
+	}{
+		{
+			name: "overlapping subnet on another stack",
+			nic: types.HostVirtualNicSpec{
+				Ip:                  &types.HostIpConfig{IpAddress: "10.0.0.20", SubnetMask: "255.255.0.0"},
+				NetStackInstanceKey: "vSphereProvisioning",
+			},
+			expected: []string{"vmk0", "vmk1"},
+		},
+		{
+			name: "overlapping subnet on the same stack",
+			nic: types.HostVirtualNicSpec{
+				Ip:                  &types.HostIpConfig{IpAddress: "10.0.0.20", SubnetMask: "255.255.255.0"},
+				NetStackInstanceKey: "defaultTcpipStack",
+			},
+		},
+		{
+			name: "separate subnets",
+			nic: types.HostVirtualNicSpec{
+				Ip:                  &types.HostIpConfig{IpAddress: "10.0.2.20", SubnetMask: "255.255.255.0"},
+				NetStackInstanceKey: "vSphereProvisioning",
+			},
+		},
+		{
+			name:  "updated adapter is skipped",
+			nicID: "vmk0",
+			nic: types.HostVirtualNicSpec{
+				Ip:                  &types.HostIpConfig{IpAddress: "10.0.0.20", SubnetMask: "255.255.255.0"},
+				NetStackInstanceKey: "vmotion",
+			},
+		},
+		{
+			name: "overlapping IPv6 subnet",
+			nic: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					IpV6Config: &types.HostIpConfigIpV6AddressConfiguration{
+						IpV6Address: []types.HostIpConfigIpV6Address{
+							{IpAddress: "fd00::20", PrefixLength: 64, Origin: "manual", Operation: "add"},
+						},
+					},
+				},
+			},
+			expected: []string{"vmk2"},
+		},
+		{
+			name: "removed IPv6 address is skipped",
+			nic: types.HostVirtualNicSpec{
+				Ip: &types.HostIpConfig{
+					IpV6Config: &types.HostIpConfigIpV6AddressConfiguration{
+						IpV6Address: []types.HostIpConfigIpV6Address{
+							{IpAddress: "fd00::20", PrefixLength: 64, Origin: "manual", Operation: "remove"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "DHCP",
+			nic: types.HostVirtualNicSpec{
+				Ip:                  &types.HostIpConfig{Dhcp: true},
+				NetStackInstanceKey: "vmotion",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := overlappingVnics(tc.nicID, &tc.nic, vnics)
+			if len(tc.expected) != len(actual) {
+				t.Fatalf("expected overlaps %v, got %v", tc.expected, actual)
+			}
+			for i := range tc.expected {
+				if tc.expected[i] != actual[i] {
+					t.Fatalf("expected overlaps %v, got %v", tc.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestVnicUplinkPnics(t *testing.T) {
+	linkUp := &types.PhysicalNicLinkInfo{SpeedMb: 10000, Duplex: true}
+	netInfo := &types.HostNetworkInfo{
+		Pnic: []types.PhysicalNic{
+			{Device: "vmnic0", LinkSpeed: linkUp},
+			{Device: "vmnic1"},
+			{Device: "vmnic2", LinkSpeed: linkUp},
+			{Device: "vmnic3", LinkSpeed: linkUp},
+		},
+		Portgroup: []types.HostPortGroup{
+			{
+				Spec: types.HostPortGroupSpec{Name: "management"},
+				ComputedPolicy: types.HostNetworkPolicy{
+					NicTeaming: &types.HostNicTeamingPolicy{
+						NicOrder: &types.HostNicOrderPolicy{
+							ActiveNic:  []string{"vmnic1"},
+							StandbyNic: []string{"vmnic0"},
+						},
+					},
+				},
+			},
+			{
+				Spec: types.HostPortGroupSpec{Name: "no-teaming"},
+			},
+		},
+		ProxySwitch: []types.HostProxySwitch{
+			{
+				DvsUuid: "dvs-uuid",
+				UplinkPort: []types.KeyValue{
+					{Key: "100", Value: "uplink1"},
+					{Key: "101", Value: "uplink2"},
+				},
+				Spec: types.HostProxySwitchSpec{
+					Backing: &types.DistributedVirtualSwitchHostMemberPnicBacking{
+						PnicSpec: []types.DistributedVirtualSwitchHostMemberPnicSpec{
+							{PnicDevice: "vmnic2", UplinkPortKey: "100"},
+							{PnicDevice: "vmnic3", UplinkPortKey: "101"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		pnics    []string
+		expected string
+	}{
+		{
+			name:     "standard portgroup fails over to standby",
+			pnics:    standardPortgroupPnics(netInfo, "management"),
+			expected: "vmnic0",
+		},
+		{
+			name:     "standard portgroup without teaming policy",
+			pnics:    standardPortgroupPnics(netInfo, "no-teaming"),
+			expected: "",
+		},
+		{
+			name:     "missing standard portgroup",
+			pnics:    standardPortgroupPnics(netInfo, "missing"),
+			expected: "",
+		},
+		{
+			name:     "distributed portgroup",
+			pnics:    distributedUplinkPnics(netInfo, "dvs-uuid", []string{"uplink2", "uplink1"}),
+			expected: "vmnic3",
+		},
+		{
+			name:     "other distributed switch",
+			pnics:    distributedUplinkPnics(netInfo, "other-uuid", []string{"uplink1"}),
+			expected: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := firstLinkedPnic(netInfo, tc.pnics); tc.expected != actual {
+				t.Fatalf("expected active uplink %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
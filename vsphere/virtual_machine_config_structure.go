@@ -5,16 +5,26 @@
 package vsphere
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/big"
+	"net"
 	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/mitchellh/copystructure"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/spbm"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
 	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/viapi"
@@ -23,6 +33,17 @@ import (
 
 var virtualMachineResourceAllocationTypeValues = []string{"cpu", "memory"}
 
+// virtualMachineCBRCEnableKey is the extraConfig key that enables the content
+// based read cache for a virtual machine.
+const virtualMachineCBRCEnableKey = "cbrc.enable"
+
+// extraConfigNumericPattern matches plain decimal numbers, such as 0, -1.50,
+// or 1e3, in extra_config values. Hexadecimal, infinite, and NaN values, as
+// well as integer parts with leading zeroes such as 007, are deliberately left
+// out so that they are compared as strings. The exponent is capped at three
+// digits to keep exact comparison cheap.
+var extraConfigNumericPattern = regexp.MustCompile(`^[+-]?((0|[1-9]\d*)(\.\d*)?|\.\d+)([eE][+-]?\d{1,3})?$`)
+
 var virtualMachineVirtualExecUsageAllowedValues = []string{
 	string(types.VirtualMachineFlagInfoVirtualExecUsageHvAuto),
 	string(types.VirtualMachineFlagInfoVirtualExecUsageHvOn),
@@ -35,6 +56,18 @@ var virtualMachineVirtualMmuUsageAllowedValues = []string{
 	string(types.VirtualMachineFlagInfoVirtualMmuUsageOff),
 }
 
+var virtualMachineMonitorTypeAllowedValues = []string{
+	string(types.VirtualMachineFlagInfoMonitorTypeRelease),
+	string(types.VirtualMachineFlagInfoMonitorTypeDebug),
+	string(types.VirtualMachineFlagInfoMonitorTypeStats),
+}
+
+var virtualMachineFaultToleranceTypeAllowedValues = []string{
+	string(types.VirtualMachineFaultToleranceTypeUnset),
+	string(types.VirtualMachineFaultToleranceTypeRecordReplay),
+	string(types.VirtualMachineFaultToleranceTypeCheckpointing),
+}
+
 var virtualMachineSwapPlacementAllowedValues = []string{
 	string(types.VirtualMachineConfigInfoSwapPlacementTypeInherit),
 	string(types.VirtualMachineConfigInfoSwapPlacementTypeVmDirectory),
@@ -51,6 +84,13 @@ var virtualMachineFirmwareAllowedValues = []string{
 	string(types.GuestOsDescriptorFirmwareTypeEfi),
 }
 
+// virtualMachineEFIOnlyGuestIDs lists the guest IDs of guest operating systems
+// that can only be installed with EFI firmware.
+var virtualMachineEFIOnlyGuestIDs = []string{
+	string(types.VirtualMachineGuestOsIdentifierWindows11_64Guest),
+	string(types.VirtualMachineGuestOsIdentifierWindows12_64Guest),
+}
+
 var virtualMachineLatencySensitivityAllowedValues = []string{
 	string(types.LatencySensitivitySensitivityLevelLow),
 	string(types.LatencySensitivitySensitivityLevelNormal),
@@ -58,8 +98,37 @@ var virtualMachineLatencySensitivityAllowedValues = []string{
 	string(types.LatencySensitivitySensitivityLevelHigh),
 }
 
+// The valid range, in milliseconds, for boot_retry_delay, and the thresholds
+// outside of which a value is most likely a unit mistake.
+const (
+	virtualMachineBootRetryDelayMin      = 1
+	virtualMachineBootRetryDelayMax      = 3600000
+	virtualMachineBootRetryDelayWarnLow  = 1000
+	virtualMachineBootRetryDelayWarnHigh = 300000
+)
+
 var virtualMachineHardwareVersionValidRanges = [][]int{{4, 4}, {7, 11}, {13, 15}, {17, 22}}
 
+// virtualMachine32BitGuestMaxMemory is the amount of memory, in MB, that a
+// 32-bit guest operating system can address without extensions.
+const virtualMachine32BitGuestMaxMemory = 4096
+
+// virtualMachineVPMCMinHardwareVersion is the minimum hardware version that
+// supports virtual CPU performance counters.
+const virtualMachineVPMCMinHardwareVersion = 9
+
+// virtualMachineNVDIMMMinHardwareVersion is the minimum hardware version that
+// supports NVDIMM devices.
+const virtualMachineNVDIMMMinHardwareVersion = 14
+
+// virtualMachineWatchdogTimerMinHardwareVersion is the minimum hardware
+// version that supports a virtual watchdog timer.
+const virtualMachineWatchdogTimerMinHardwareVersion = 17
+
+// virtualMachinePrecisionClockMinHardwareVersion is the minimum hardware
+// version that supports a virtual precision clock.
+const virtualMachinePrecisionClockMinHardwareVersion = 17
+
 // generateHardwareVersionDescription creates a description string from the
 // valid hardware version ranges.
 func generateHardwareVersionDescription() string {
@@ -88,14 +157,56 @@ func generateHardwareVersionErrorMessage() string {
 	return strings.Join(parts, ", ")
 }
 
+// isHotApplicableChange returns true if the change to the resource data at key
+// can be applied to a powered on virtual machine.
+//
+// CPUs can be added or removed when cpu_hot_add_enabled or
+// cpu_hot_remove_enabled is enabled, and memory can be added when
+// memory_hot_add_enabled is enabled. The pre-update values of these settings
+// are used, as changing the settings themselves requires a power down of the
+// virtual machine. Changes to extra_config are never hot-applicable, as some
+// keys, such as the cloud-init guestinfo keys, are only read when the virtual
+// machine boots. Whether they flag a reboot is controlled by
+// extra_config_reboot_required instead.
+func isHotApplicableChange(d *schema.ResourceData, key string) bool {
+	o, n := d.GetChange(key)
+	switch key {
+	case "num_cpus":
+		hotAdd, _ := d.GetChange("cpu_hot_add_enabled")
+		hotRemove, _ := d.GetChange("cpu_hot_remove_enabled")
+		if n.(int) > o.(int) {
+			return hotAdd.(bool)
+		}
+		return hotRemove.(bool)
+	case "memory":
+		hotAdd, _ := d.GetChange("memory_hot_add_enabled")
+		// Removing memory always requires a reboot.
+		return n.(int) > o.(int) && hotAdd.(bool)
+	}
+	return false
+}
+
+// flagRestartOnChange flags a reboot in the virtual machine by setting
+// reboot_required to true if the resource data at key has changed, and the
+// change is not hot-applicable. All of the reboot decisions for the
+// attributes of the virtual machine configuration are made here.
+func flagRestartOnChange(d *schema.ResourceData, key string) {
+	if !d.HasChange(key) {
+		return
+	}
+	if isHotApplicableChange(d, key) {
+		log.Printf("[DEBUG] %s: Resource argument %q can be changed without a VM restart", resourceVSphereVirtualMachineIDString(d), key)
+		return
+	}
+	log.Printf("[DEBUG] %s: Resource argument %q requires a VM restart", resourceVSphereVirtualMachineIDString(d), key)
+	_ = d.Set("reboot_required", true)
+}
+
 // getWithRestart fetches the resource data specified at key. If the value has
 // changed, a reboot is flagged in the virtual machine by setting
 // reboot_required to true.
 func getWithRestart(d *schema.ResourceData, key string) interface{} {
-	if d.HasChange(key) {
-		log.Printf("[DEBUG] %s: Resource argument %q requires a VM restart", resourceVSphereVirtualMachineIDString(d), key)
-		_ = d.Set("reboot_required", true)
-	}
+	flagRestartOnChange(d, key)
 	return d.Get(key)
 }
 
@@ -105,9 +216,7 @@ func getWithRestart(d *schema.ResourceData, key string) interface{} {
 //
 // This function always returns at least false, even if a value is unspecified.
 func getBoolWithRestart(d *schema.ResourceData, key string) *bool {
-	if d.HasChange(key) {
-		_ = d.Set("reboot_required", true)
-	}
+	flagRestartOnChange(d, key)
 	return structure.GetBool(d, key)
 }
 
@@ -117,9 +226,10 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 	s := map[string]*schema.Schema{
 		// VirtualMachineBootOptions
 		"boot_delay": {
-			Type:        schema.TypeInt,
-			Optional:    true,
-			Description: "The number of milliseconds to wait before starting the boot sequence.",
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Description:  "The number of milliseconds to wait before starting the boot sequence.",
+			ValidateFunc: validation.IntAtLeast(0),
 		},
 		"efi_secure_boot_enabled": {
 			Type:        schema.TypeBool,
@@ -127,10 +237,11 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			Description: "When the boot type set in firmware is efi, this enables EFI secure boot.",
 		},
 		"boot_retry_delay": {
-			Type:        schema.TypeInt,
-			Optional:    true,
-			Default:     10000,
-			Description: "The number of milliseconds to wait before retrying the boot sequence. This only valid if boot_retry_enabled is true.",
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      10000,
+			Description:  "The number of milliseconds (not seconds) to wait before retrying the boot sequence. This only valid if boot_retry_enabled is true. Must be between 1 and 3600000 (1 hour).",
+			ValidateFunc: validation.IntBetween(virtualMachineBootRetryDelayMin, virtualMachineBootRetryDelayMax),
 		},
 		"boot_retry_enabled": {
 			Type:        schema.TypeBool,
@@ -154,6 +265,13 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			Optional:    true,
 			Description: "Flag to specify if I/O MMU virtualization, also called Intel Virtualization Technology for Directed I/O (VT-d) and AMD I/O Virtualization (AMD-Vi or IOMMU), is enabled.",
 		},
+		"fault_tolerance_type": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			Description:  "The type of fault tolerance that the virtual machine is configured to use. Can be one of unset, recordReplay, or checkpointing.",
+			ValidateFunc: validation.StringInSlice(virtualMachineFaultToleranceTypeAllowedValues, false),
+		},
 		"hv_mode": {
 			Type:         schema.TypeString,
 			Optional:     true,
@@ -176,6 +294,24 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 				return len(d.Get("ovf_deploy").([]interface{})) > 0
 			},
 		},
+		"monitor_type": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			Description:  "The type of the virtual machine monitor (VMX) process to run. Can be one of release, debug, or stats.",
+			ValidateFunc: validation.StringInSlice(virtualMachineMonitorTypeAllowedValues, false),
+		},
+		"disable_acceleration": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Turn off video acceleration in the console window of this virtual machine.",
+		},
+		"record_replay_enabled": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Description: "Enable record and replay on this virtual machine. Only supported on vSphere versions before 6.0.",
+		},
 
 		// ToolsConfigInfo
 		"sync_time_with_host": {
@@ -196,6 +332,22 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			Description:  "Set the upgrade policy for VMware Tools. Can be one of `manual` or `upgradeAtPowerCycle`.",
 			ValidateFunc: validation.StringInSlice(virtualMachineUpgradePolicyAllowedValues, false),
 		},
+		"tools_upgrade_min_version": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The minimum VMware Tools version, in the form major.minor.patch, that is expected on the virtual machine. When the installed version is at or above this version, tools_upgrade_policy is not applied and the upgrade policy is set to manual.",
+			ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+				if _, err := parseToolsVersion(val.(string)); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s", key, err))
+				}
+				return
+			},
+		},
+		"tools_version": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The version of VMware Tools installed on the virtual machine, in the form major.minor.patch.",
+		},
 		"run_tools_scripts_after_power_on": {
 			Type:        schema.TypeBool,
 			Optional:    true,
@@ -234,6 +386,12 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			Description:  "Controls the scheduling delay of the virtual machine. Use a higher sensitivity for applications that require lower latency, such as VOIP, media player applications, or applications that require frequent access to mouse or keyboard devices. Can be one of low, normal, medium, or high.",
 			ValidateFunc: validation.StringInSlice(virtualMachineLatencySensitivityAllowedValues, false),
 		},
+		"latency_sensitivity_auto_reserve": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "When latency_sensitivity is high, reserve all of the memory and CPU capacity of the virtual machine unless memory_reservation or cpu_reservation are set.",
+		},
 
 		// VirtualMachineConfigSpec
 		"name": {
@@ -280,6 +438,24 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			Default:     1024,
 			Description: "The size of the virtual machine's memory, in MB.",
 		},
+		"cpu_affinity": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "The logical CPUs of the host that the virtual machine is scheduled on. Setting this disables vMotion and DRS automation for the virtual machine.",
+			Elem: &schema.Schema{
+				Type:         schema.TypeInt,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+		},
+		"memory_affinity": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "The NUMA nodes of the host that the memory of the virtual machine is allocated from. Setting this disables vMotion and DRS automation for the virtual machine.",
+			Elem: &schema.Schema{
+				Type:         schema.TypeInt,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+		},
 		"memory_reservation_locked_to_max": {
 			Type:     schema.TypeBool,
 			Optional: true,
@@ -340,6 +516,9 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			Optional:    true,
 			Description: "Extra configuration data for this virtual machine. Can be used to supply advanced parameters not normally in configuration, such as instance metadata, or configuration data for OVF images.",
 			Elem:        &schema.Schema{Type: schema.TypeString},
+			DiffSuppressFunc: func(_, old, new string, _ *schema.ResourceData) bool {
+				return extraConfigValuesEquivalent(old, new)
+			},
 		},
 		"extra_config_reboot_required": {
 			Type:        schema.TypeBool,
@@ -347,6 +526,55 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			Default:     true,
 			Description: "Allow the virtual machine to be rebooted when a change to `extra_config` occurs.",
 		},
+		"extra_config_apply_on_reboot": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Stage changes to `extra_config` on a powered on virtual machine until the next update that requires a reboot, instead of applying them immediately.",
+		},
+		"extra_config_staged": {
+			Type:        schema.TypeMap,
+			Computed:    true,
+			Description: "The changes to `extra_config` that are staged until the next update that requires a reboot. Removed keys have an empty value.",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"content_based_read_cache": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: "Content based read cache (View Storage Accelerator) settings for this virtual machine. Written to extra_config keys managed by the provider.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"enabled": {
+						Type:        schema.TypeBool,
+						Required:    true,
+						Description: "Enable the content based read cache for the disks of the virtual machine.",
+					},
+				},
+			},
+		},
+		"managed_by": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: "The vCenter extension that manages this virtual machine. Removing the block removes the marker.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"extension_key": {
+						Type:         schema.TypeString,
+						Required:     true,
+						Description:  "The key of the extension that manages the virtual machine.",
+						ValidateFunc: validation.StringIsNotEmpty,
+					},
+					"type": {
+						Type:         schema.TypeString,
+						Required:     true,
+						Description:  "The type of the virtual machine, as defined by the extension.",
+						ValidateFunc: validation.StringIsNotEmpty,
+					},
+				},
+			},
+		},
 		"replace_trigger": {
 			Type:        schema.TypeString,
 			Optional:    true,
@@ -360,6 +588,12 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			MaxItems:    1,
 			Elem:        &schema.Resource{Schema: vAppSubresourceSchema()},
 		},
+		"vapp_config_removal_enabled": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Remove the entire vApp configuration, including transport and IP allocation settings, from the virtual machine when the vapp block is removed.",
+		},
 		"vapp_transport": {
 			Type:        schema.TypeList,
 			Computed:    true,
@@ -371,6 +605,16 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			Computed:    true,
 			Description: "A unique identifier for a given version of the last configuration applied, such the timestamp of the last update to the configuration.",
 		},
+		"scheduled_hardware_upgrade_status": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The status of the last attempt to run a scheduled hardware upgrade of the virtual machine. One of none, pending, success, or failed.",
+		},
+		"scheduled_hardware_upgrade_fault": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The reason the last attempt to run a scheduled hardware upgrade of the virtual machine failed.",
+		},
 		"uuid": {
 			Type:        schema.TypeString,
 			Computed:    true,
@@ -387,6 +631,19 @@ func schemaVirtualMachineConfigSpec() map[string]*schema.Schema {
 			Computed:    true,
 			Description: "The ID of the storage policy to assign to the virtual machine home directory.",
 		},
+		"replication_group_id": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			RequiredWith: []string{"storage_policy_id"},
+			Description:  "The ID of the replication group to assign the virtual machine to, in the form <fault-domain-id>:<device-group-id>. Requires a storage policy that supports replication.",
+			ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+				if _, err := spbm.ParseReplicationGroupID(val.(string)); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s", key, err))
+				}
+				return
+			},
+		},
 		"hardware_version": {
 			Type:     schema.TypeInt,
 			Optional: true,
@@ -420,16 +677,33 @@ func vAppSubresourceSchema() map[string]*schema.Schema {
 			Description: "A map of customizable vApp properties and their values. Allows customization of VMs cloned from OVF templates which have customizable vApp properties.",
 			Elem:        &schema.Schema{Type: schema.TypeString},
 		},
+		"ovf_environment_transport": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "The transports used to deliver the OVF environment to the guest. One or both of com.vmware.guestInfo and iso.",
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validation.StringInSlice(vAppOvfEnvironmentTransports, false),
+			},
+		},
 	}
 }
 
+// vAppOvfEnvironmentTransports lists the transports that can deliver the OVF
+// environment of a virtual machine to the guest.
+var vAppOvfEnvironmentTransports = []string{
+	"com.vmware.guestInfo",
+	"iso",
+}
+
 // expandVirtualMachineBootOptions reads certain ResourceData keys and
 // returns a VirtualMachineBootOptions.
 func expandVirtualMachineBootOptions(d *schema.ResourceData, client *govmomi.Client) *types.VirtualMachineBootOptions {
+	// Boot options are only applied when the virtual machine is powered on.
 	obj := &types.VirtualMachineBootOptions{
-		BootDelay:        int64(d.Get("boot_delay").(int)),
-		BootRetryEnabled: structure.GetBool(d, "boot_retry_enabled"),
-		BootRetryDelay:   int64(d.Get("boot_retry_delay").(int)),
+		BootDelay:        int64(getWithRestart(d, "boot_delay").(int)),
+		BootRetryEnabled: getBoolWithRestart(d, "boot_retry_enabled"),
+		BootRetryDelay:   int64(getWithRestart(d, "boot_retry_delay").(int)),
 	}
 
 	version := viapi.ParseVersionFromClient(client)
@@ -441,6 +715,102 @@ func expandVirtualMachineBootOptions(d *schema.ResourceData, client *govmomi.Cli
 	return obj
 }
 
+// validateCPUPerformanceCounters checks that the hardware version supports
+// virtual CPU performance counters when cpu_performance_counters_enabled is
+// set. A hardware version of 0 is not known yet and is not checked.
+func validateCPUPerformanceCounters(enabled bool, hardwareVersion int) error {
+	if !enabled || hardwareVersion == 0 || hardwareVersion >= virtualMachineVPMCMinHardwareVersion {
+		return nil
+	}
+	return fmt.Errorf("cpu_performance_counters_enabled requires hardware_version %d or higher, got %d", virtualMachineVPMCMinHardwareVersion, hardwareVersion)
+}
+
+// validateDeviceHardwareVersion checks that the hardware version is at least
+// minHardwareVersion when count devices of the block key are configured. A
+// hardware version of 0 is not known yet and is not checked.
+func validateDeviceHardwareVersion(key string, count, hardwareVersion, minHardwareVersion int) error {
+	if count < 1 || hardwareVersion == 0 || hardwareVersion >= minHardwareVersion {
+		return nil
+	}
+	return fmt.Errorf("%s requires hardware_version %d or higher, got %d", key, minHardwareVersion, hardwareVersion)
+}
+
+// validateRecordReplay checks that record and replay is only enabled on
+// vSphere versions that support it. Record and replay was removed in vSphere
+// 6.0.
+func validateRecordReplay(enabled bool, version viapi.VSphereVersion) error {
+	if !enabled || version.Older(viapi.VSphereVersion{Product: version.Product, Major: 6}) {
+		return nil
+	}
+	return fmt.Errorf("record_replay_enabled is not supported on %s, record and replay was removed in vSphere 6.0", version)
+}
+
+// validateVirtualMachineFirmware checks that firmware is consistent with EFI
+// secure boot and the guest ID. Secure boot requires EFI firmware, as do the
+// guest operating systems in virtualMachineEFIOnlyGuestIDs.
+func validateVirtualMachineFirmware(firmware string, secureBoot bool, guestID string) error {
+	if firmware != string(types.GuestOsDescriptorFirmwareTypeBios) {
+		return nil
+	}
+	if secureBoot {
+		return errors.New("efi_secure_boot_enabled requires firmware to be efi, got bios")
+	}
+	if slices.Contains(virtualMachineEFIOnlyGuestIDs, guestID) {
+		return fmt.Errorf("guest_id %q requires firmware to be efi, got bios", guestID)
+	}
+	return nil
+}
+
+// bootRetryDelayWarning returns a warning message if the supplied
+// boot_retry_delay value, in milliseconds, looks like it was supplied in the
+// wrong unit. An empty string is returned if the value looks sane.
+func bootRetryDelayWarning(delay int) string {
+	switch {
+	case delay < virtualMachineBootRetryDelayWarnLow:
+		return fmt.Sprintf("boot_retry_delay is %dms, which is less than one second. Note that this value is in milliseconds, not seconds", delay)
+	case delay > virtualMachineBootRetryDelayWarnHigh:
+		return fmt.Sprintf("boot_retry_delay is %dms, which is more than five minutes. Note that this value is in milliseconds, not seconds", delay)
+	}
+	return ""
+}
+
+// guestID64BitVariant returns the 64-bit variant of a 32-bit guest ID, such as
+// ubuntu64Guest for ubuntuGuest, or windows7_64Guest for windows7Guest. false
+// is returned if the guest ID has no known 64-bit variant.
+func guestID64BitVariant(guestID string) (string, bool) {
+	if !strings.HasSuffix(guestID, "Guest") || strings.Contains(guestID, "64") {
+		return "", false
+	}
+	base := strings.TrimSuffix(guestID, "Guest")
+	for _, candidate := range []string{base + "_64Guest", base + "64Guest"} {
+		for _, id := range types.VirtualMachineGuestOsIdentifier("").Values() {
+			if string(id) == candidate {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// guestIDBitnessWarning returns a warning message if a 32-bit guest ID is
+// used for a virtual machine with more than 4 GB of memory or more than one
+// virtual CPU, and a 64-bit variant of the guest ID exists. An empty string is
+// returned otherwise.
+func guestIDBitnessWarning(guestID string, memory, numCPUs int) string {
+	if memory <= virtualMachine32BitGuestMaxMemory && numCPUs <= 1 {
+		return ""
+	}
+	variant, ok := guestID64BitVariant(guestID)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(
+		"guest_id %q is a 32-bit guest operating system, but the virtual machine has %dMB of memory and %d virtual CPUs. "+
+			"If the guest operating system is 64-bit, use guest_id %q instead",
+		guestID, memory, numCPUs, variant,
+	)
+}
+
 // flattenVirtualMachineBootOptions reads various fields from a
 // VirtualMachineBootOptions into the passed in ResourceData.
 func flattenVirtualMachineBootOptions(d *schema.ResourceData, obj *types.VirtualMachineBootOptions) error {
@@ -459,10 +829,27 @@ func expandVirtualMachineFlagInfo(d *schema.ResourceData, client *govmomi.Client
 		VirtualExecUsage: getWithRestart(d, "hv_mode").(string),
 		VirtualMmuUsage:  getWithRestart(d, "ept_rvi_mode").(string),
 		EnableLogging:    getBoolWithRestart(d, "enable_logging"),
+		MonitorType:      getWithRestart(d, "monitor_type").(string),
+	}
+
+	// Only send disable_acceleration when it is set, so that the setting of
+	// existing virtual machines is left alone.
+	if configuredBool(d.GetRawConfig(), "disable_acceleration") != nil {
+		obj.DisableAcceleration = getBoolWithRestart(d, "disable_acceleration")
 	}
 
 	version := viapi.ParseVersionFromClient(client)
 
+	// Record and replay was removed in 6.0.0.
+	if version.Older(viapi.VSphereVersion{Product: version.Product, Major: 6}) {
+		obj.RecordReplayEnabled = getBoolWithRestart(d, "record_replay_enabled")
+	}
+
+	// Minimum Supported Version: 6.0.0
+	if version.AtLeast(viapi.VSphereVersion{Product: version.Product, Major: 6}) {
+		obj.FaultToleranceType = getWithRestart(d, "fault_tolerance_type").(string)
+	}
+
 	// Minimum Supported Version: 6.7.0
 	if version.AtLeast(viapi.VSphereVersion{Product: version.Product, Major: 6, Minor: 7}) {
 		obj.VbsEnabled = getBoolWithRestart(d, "vbs_enabled")
@@ -478,10 +865,22 @@ func flattenVirtualMachineFlagInfo(d *schema.ResourceData, obj *types.VirtualMac
 	_ = d.Set("hv_mode", obj.VirtualExecUsage)
 	_ = d.Set("ept_rvi_mode", obj.VirtualMmuUsage)
 	_ = d.Set("enable_logging", obj.EnableLogging)
+	_ = d.Set("monitor_type", obj.MonitorType)
+	_ = d.Set("disable_acceleration", obj.DisableAcceleration)
 
 	version := viapi.ParseVersionFromClient(client)
 
+	// Record and replay was removed in 6.0.0.
+	if version.Older(viapi.VSphereVersion{Product: version.Product, Major: 6}) {
+		_ = d.Set("record_replay_enabled", obj.RecordReplayEnabled)
+	}
+
 	// Minimum Supported Version: 6.0.0
+	if version.AtLeast(viapi.VSphereVersion{Product: version.Product, Major: 6}) {
+		_ = d.Set("fault_tolerance_type", obj.FaultToleranceType)
+	}
+
+	// Minimum Supported Version: 6.7.0
 	if version.AtLeast(viapi.VSphereVersion{Product: version.Product, Major: 6, Minor: 7}) {
 		_ = d.Set("vbs_enabled", obj.VbsEnabled)
 		_ = d.Set("vvtd_enabled", obj.VvtdEnabled)
@@ -502,6 +901,14 @@ func expandToolsConfigInfo(d *schema.ResourceData, client *govmomi.Client) *type
 		BeforeGuestReboot:   getBoolWithRestart(d, "run_tools_scripts_before_guest_reboot"),
 	}
 
+	if obj.ToolsUpgradePolicy == string(types.UpgradePolicyUpgradeAtPowerCycle) {
+		installed, _ := parseToolsVersion(d.Get("tools_version").(string))
+		if toolsUpgradeGated(d.Get("tools_upgrade_min_version").(string), installed) {
+			log.Printf("[DEBUG] %s: VMware Tools %s meets the minimum version %s, not upgrading at power cycle", resourceVSphereVirtualMachineIDString(d), d.Get("tools_version").(string), d.Get("tools_upgrade_min_version").(string))
+			obj.ToolsUpgradePolicy = string(types.UpgradePolicyManual)
+		}
+	}
+
 	version := viapi.ParseVersionFromClient(client)
 
 	// Minimum Supported Version: 7.0.1
@@ -512,11 +919,62 @@ func expandToolsConfigInfo(d *schema.ResourceData, client *govmomi.Client) *type
 	return obj
 }
 
+// parseToolsVersion parses a VMware Tools version in the form
+// major.minor.patch into the numeric form used by vSphere, which encodes the
+// version as major*1024 + minor*32 + patch. An empty string returns 0.
+func parseToolsVersion(s string) (int32, error) {
+	if s == "" {
+		return 0, nil
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid VMware Tools version %q: must be of the form major.minor.patch", s)
+	}
+	var v [3]int64
+	for i, p := range parts {
+		n, err := strconv.ParseInt(p, 10, 32)
+		if err != nil || n < 0 || (i > 0 && n > 31) {
+			return 0, fmt.Errorf("invalid VMware Tools version %q: must be of the form major.minor.patch", s)
+		}
+		v[i] = n
+	}
+	return int32(v[0]<<10 | v[1]<<5 | v[2]), nil
+}
+
+// formatToolsVersion returns the major.minor.patch form of a numeric VMware
+// Tools version. An empty string is returned if the version is not known,
+// such as when VMware Tools is not installed or is managed by the guest.
+func formatToolsVersion(v int32) string {
+	if v <= 0 || v == math.MaxInt32 {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.%d", v>>10, (v>>5)&31, v&31)
+}
+
+// toolsUpgradeGated returns true if the installed VMware Tools version is known
+// and at or above minVersion, in which case an upgrade at power cycle is not
+// needed.
+func toolsUpgradeGated(minVersion string, installed int32) bool {
+	threshold, err := parseToolsVersion(minVersion)
+	if err != nil || threshold == 0 || installed <= 0 || installed == math.MaxInt32 {
+		return false
+	}
+	return installed >= threshold
+}
+
 // flattenToolsConfigInfo reads various fields from a
 // ToolsConfigInfo into the passed in ResourceData.
 func flattenToolsConfigInfo(d *schema.ResourceData, obj *types.ToolsConfigInfo, client *govmomi.Client) error {
 	_ = d.Set("sync_time_with_host", obj.SyncTimeWithHost)
-	_ = d.Set("tools_upgrade_policy", obj.ToolsUpgradePolicy)
+	_ = d.Set("tools_version", formatToolsVersion(obj.ToolsVersion))
+	// Keep the configured policy if it was not applied because the installed
+	// version already meets tools_upgrade_min_version.
+	gated := d.Get("tools_upgrade_policy").(string) == string(types.UpgradePolicyUpgradeAtPowerCycle) &&
+		obj.ToolsUpgradePolicy == string(types.UpgradePolicyManual) &&
+		toolsUpgradeGated(d.Get("tools_upgrade_min_version").(string), obj.ToolsVersion)
+	if !gated {
+		_ = d.Set("tools_upgrade_policy", obj.ToolsUpgradePolicy)
+	}
 	_ = d.Set("run_tools_scripts_after_power_on", obj.AfterPowerOn)
 	_ = d.Set("run_tools_scripts_after_resume", obj.AfterResume)
 	_ = d.Set("run_tools_scripts_before_guest_standby", obj.BeforeGuestStandby)
@@ -543,12 +1001,14 @@ func schemaVirtualMachineResourceAllocation() map[string]*schema.Schema {
 	shareCountFmt := "The amount of shares to allocate to %s for a custom share level."
 	limitFmt := "The maximum amount of memory (in MB) or CPU (in MHz) that this virtual machine can consume, regardless of available resources."
 	reservationFmt := "The amount of memory (in MB) or CPU (in MHz) that this virtual machine is guaranteed."
+	expandableFmt := "Whether the %s reservation of this virtual machine is expandable, as reported by vSphere. Expandable reservations only apply to resource pools and vApps."
 
 	for _, t := range virtualMachineResourceAllocationTypeValues {
 		shareLevelKey := fmt.Sprintf("%s_share_level", t)
 		shareCountKey := fmt.Sprintf("%s_share_count", t)
 		limitKey := fmt.Sprintf("%s_limit", t)
 		reservationKey := fmt.Sprintf("%s_reservation", t)
+		expandableKey := fmt.Sprintf("%s_reservation_expandable", t)
 
 		s[shareLevelKey] = &schema.Schema{
 			Type:         schema.TypeString,
@@ -572,10 +1032,16 @@ func schemaVirtualMachineResourceAllocation() map[string]*schema.Schema {
 			ValidateFunc: validation.IntAtLeast(-1),
 		}
 		s[reservationKey] = &schema.Schema{
-			Type:         schema.TypeInt,
-			Optional:     true,
-			Description:  reservationFmt,
-			ValidateFunc: validation.IntAtLeast(0),
+			Type:             schema.TypeInt,
+			Optional:         true,
+			Description:      reservationFmt,
+			ValidateFunc:     validation.IntAtLeast(0),
+			DiffSuppressFunc: suppressLatencySensitivityReservationDiff,
+		}
+		s[expandableKey] = &schema.Schema{
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: fmt.Sprintf(expandableFmt, t),
 		}
 	}
 
@@ -603,6 +1069,49 @@ func expandVirtualMachineResourceAllocation(d *schema.ResourceData, key string)
 	return obj
 }
 
+// expandVirtualMachineAffinity reads the list of CPU or NUMA node indices in
+// key and returns a VirtualMachineAffinityInfo. A change flags a reboot. An
+// empty affinity set is only sent when the list has been cleared, to remove
+// the affinity from the virtual machine.
+func expandVirtualMachineAffinity(d *schema.ResourceData, key string) *types.VirtualMachineAffinityInfo {
+	if d.HasChange(key) {
+		_ = d.Set("reboot_required", true)
+	}
+	l := d.Get(key).([]interface{})
+	if len(l) < 1 && !d.HasChange(key) {
+		return nil
+	}
+	obj := &types.VirtualMachineAffinityInfo{
+		AffinitySet: []int32{},
+	}
+	for _, v := range l {
+		obj.AffinitySet = append(obj.AffinitySet, int32(v.(int)))
+	}
+	return obj
+}
+
+// flattenVirtualMachineAffinity reads the affinity set of a
+// VirtualMachineAffinityInfo into key.
+func flattenVirtualMachineAffinity(d *schema.ResourceData, key string, obj *types.VirtualMachineAffinityInfo) error {
+	var l []interface{}
+	if obj != nil {
+		for _, v := range obj.AffinitySet {
+			l = append(l, int(v))
+		}
+	}
+	return d.Set(key, l)
+}
+
+// affinityWarning returns a warning when CPU or memory affinity is set, as
+// affinity prevents the virtual machine from being migrated with vMotion or
+// balanced by DRS.
+func affinityWarning(cpuAffinity, memoryAffinity []interface{}) string {
+	if len(cpuAffinity) < 1 && len(memoryAffinity) < 1 {
+		return ""
+	}
+	return "cpu_affinity or memory_affinity is set. The virtual machine cannot be migrated with vMotion, and DRS does not automatically balance it"
+}
+
 // expandLatencySensitivity reads certain ResourceData keys and returns a
 // LatencySensitivity.
 func expandLatencySensitivity(d *schema.ResourceData) *types.LatencySensitivity {
@@ -612,6 +1121,86 @@ func expandLatencySensitivity(d *schema.ResourceData) *types.LatencySensitivity
 	return obj
 }
 
+// latencySensitivityAutoReserve returns true if the reservations of the
+// virtual machine should be set automatically to satisfy a high latency
+// sensitivity.
+func latencySensitivityAutoReserve(d *schema.ResourceData) bool {
+	return d.Get("latency_sensitivity_auto_reserve").(bool) &&
+		d.Get("latency_sensitivity").(string) == string(types.LatencySensitivitySensitivityLevelHigh)
+}
+
+// suppressLatencySensitivityReservationDiff suppresses the diff on a
+// reservation that is left unset when latency_sensitivity_auto_reserve sets it
+// on the virtual machine. The memory reservation is also set by vSphere when
+// memory_reservation_locked_to_max is enabled.
+func suppressLatencySensitivityReservationDiff(k, _, n string, d *schema.ResourceData) bool {
+	if n != "" && n != "0" {
+		return false
+	}
+	if k == "memory_reservation" && d.Get("memory_reservation_locked_to_max").(bool) {
+		return true
+	}
+	return latencySensitivityAutoReserve(d)
+}
+
+// expandLatencySensitivityReservations sets the memory and CPU reservations
+// in the supplied config spec to the full memory and CPU capacity of the
+// virtual machine when latency_sensitivity_auto_reserve is enabled and
+// latency_sensitivity is high. Reservations that are set in the configuration
+// are left alone.
+//
+// The CPU capacity depends on the clock speed of the host, so the CPU
+// reservation is only set when the host of the virtual machine is known.
+func expandLatencySensitivityReservations(d *schema.ResourceData, client *govmomi.Client, obj *types.VirtualMachineConfigSpec) error {
+	if !latencySensitivityAutoReserve(d) {
+		return nil
+	}
+	var cpuMhz int32
+	if hsID := d.Get("host_system_id").(string); hsID != "" {
+		hs, err := hostsystem.FromID(client, hsID)
+		if err != nil {
+			return fmt.Errorf("error locating host system for latency sensitivity reservations: %s", err)
+		}
+		hprops, err := hostsystem.Properties(hs)
+		if err != nil {
+			return fmt.Errorf("error fetching host system properties for latency sensitivity reservations: %s", err)
+		}
+		if hprops.Summary.Hardware != nil {
+			cpuMhz = hprops.Summary.Hardware.CpuMhz
+		}
+	}
+	setLatencySensitivityReservations(d, obj, cpuMhz)
+	return nil
+}
+
+// setLatencySensitivityReservations sets the memory and CPU reservations in
+// the supplied config spec for a high latency sensitivity, using cpuMhz as the
+// clock speed of a single CPU. The CPU reservation is left alone if cpuMhz is
+// 0.
+//
+// Only the reservations set in the configuration are checked, as the
+// reservations are read back into the state and would otherwise keep the
+// reservations from following later changes to memory and num_cpus.
+func setLatencySensitivityReservations(d *schema.ResourceData, obj *types.VirtualMachineConfigSpec, cpuMhz int32) {
+	raw := d.GetRawConfig()
+	if configuredInt(raw, "memory_reservation") == 0 {
+		obj.MemoryAllocation.Reservation = structure.Int64Ptr(int64(d.Get("memory").(int)))
+		// The reservation now matches the memory size, so there is no need to
+		// unlock it from the maximum. See getMemoryReservationLockedToMax.
+		obj.MemoryReservationLockedToMax = nil
+		if d.Get("memory_reservation_locked_to_max").(bool) {
+			obj.MemoryReservationLockedToMax = structure.BoolPtr(true)
+		}
+	}
+	if configuredInt(raw, "cpu_reservation") == 0 {
+		if cpuMhz == 0 {
+			log.Printf("[WARN] %s: Host CPU speed is unknown, not setting CPU reservation for high latency sensitivity", resourceVSphereVirtualMachineIDString(d))
+			return
+		}
+		obj.CpuAllocation.Reservation = structure.Int64Ptr(int64(d.Get("num_cpus").(int)) * int64(cpuMhz))
+	}
+}
+
 // flattenLatencySensitivity reads various fields from a LatencySensitivity and
 // sets appropriate keys in the supplied ResourceData.
 func flattenLatencySensitivity(d *schema.ResourceData, obj *types.LatencySensitivity) error {
@@ -630,9 +1219,11 @@ func flattenVirtualMachineResourceAllocation(d *schema.ResourceData, obj *types.
 	shareCountKey := fmt.Sprintf("%s_share_count", key)
 	limitKey := fmt.Sprintf("%s_limit", key)
 	reservationKey := fmt.Sprintf("%s_reservation", key)
+	expandableKey := fmt.Sprintf("%s_reservation_expandable", key)
 
 	_ = structure.SetInt64Ptr(d, limitKey, obj.Limit)
 	_ = structure.SetInt64Ptr(d, reservationKey, obj.Reservation)
+	_ = d.Set(expandableKey, obj.ExpandableReservation != nil && *obj.ExpandableReservation)
 	if obj.Shares != nil {
 		_ = d.Set(shareLevelKey, obj.Shares.Level)
 		_ = d.Set(shareCountKey, obj.Shares.Shares)
@@ -648,16 +1239,14 @@ func flattenVirtualMachineResourceAllocation(d *schema.ResourceData, obj *types.
 // are removed from extraConfig on the update.
 func expandExtraConfig(d *schema.ResourceData) []types.BaseOptionValue {
 	if d.HasChange("extra_config") {
-		// While there's a possibility that modification of some settings in
-		// extraConfig may not require a restart, there's no real way for us to
-		// know, hence we just default to requiring a reboot here.
-		rebootRequired := true
-		// Check for an override to the default reboot when changes are made to the extraConfig.
-		_rebootRequired, ok := d.Get("extra_config_reboot_required").(bool)
-		if ok {
-			rebootRequired = _rebootRequired
+		// There's no real way for us to know if a setting in extraConfig
+		// requires a restart, hence we default to requiring a reboot here, unless
+		// extra_config_reboot_required overrides it. Staged changes wait for a
+		// reboot required by another change, so they must not require one
+		// themselves.
+		if d.Get("extra_config_reboot_required").(bool) && !d.Get("extra_config_apply_on_reboot").(bool) {
+			flagRestartOnChange(d, "extra_config")
 		}
-		_ = d.Set("reboot_required", rebootRequired)
 	} else {
 		// There's no change here, so we might as well just return a nil set, which
 		// is a no-op for modification of extraConfig.
@@ -713,6 +1302,78 @@ func expandExtraConfig(d *schema.ResourceData) []types.BaseOptionValue {
 	return opts
 }
 
+// stageExtraConfig removes the extra_config changes from spec when
+// extra_config_apply_on_reboot is set, the virtual machine is powered on, and
+// no other change requires a reboot. The changes are recorded in
+// extra_config_staged so that they are applied by the next update that
+// requires a reboot. The returned value is true if the changes were staged.
+func stageExtraConfig(d *schema.ResourceData, spec *types.VirtualMachineConfigSpec, powerState types.VirtualMachinePowerState) bool {
+	if !d.HasChange("extra_config") || !extraConfigStagingActive(d, powerState) {
+		return false
+	}
+	log.Printf("[DEBUG] %s: Staging extra_config changes until the next reboot", resourceVSphereVirtualMachineIDString(d))
+	staged := d.Get("extra_config_staged").(map[string]interface{})
+	for _, v := range expandExtraConfig(d) {
+		ov := v.GetOptionValue()
+		staged[ov.Key] = ov.Value
+	}
+	_ = d.Set("extra_config_staged", staged)
+	spec.ExtraConfig = expandContentBasedReadCache(d)
+	return true
+}
+
+// applyStagedExtraConfig adds the extra_config changes staged by
+// stageExtraConfig to spec and clears them, unless they are still to be
+// staged. Keys that are also changed in spec keep the value in spec. The
+// returned value is true if any staged changes were added.
+func applyStagedExtraConfig(d *schema.ResourceData, spec *types.VirtualMachineConfigSpec, powerState types.VirtualMachinePowerState) bool {
+	staged := d.Get("extra_config_staged").(map[string]interface{})
+	if len(staged) < 1 || extraConfigStagingActive(d, powerState) {
+		return false
+	}
+	log.Printf("[DEBUG] %s: Applying staged extra_config changes", resourceVSphereVirtualMachineIDString(d))
+	changed := make(map[string]bool)
+	for _, v := range spec.ExtraConfig {
+		changed[v.GetOptionValue().Key] = true
+	}
+	for k, v := range staged {
+		if !changed[k] {
+			spec.ExtraConfig = append(spec.ExtraConfig, &types.OptionValue{
+				Key:   k,
+				Value: types.AnyType(v),
+			})
+		}
+	}
+	_ = d.Set("extra_config_staged", map[string]interface{}{})
+	return true
+}
+
+// extraConfigStagingActive returns true if extra_config changes are to be
+// staged, which is when extra_config_apply_on_reboot is set, the virtual
+// machine is powered on, and no other change requires a reboot.
+func extraConfigStagingActive(d *schema.ResourceData, powerState types.VirtualMachinePowerState) bool {
+	if !d.Get("extra_config_apply_on_reboot").(bool) {
+		return false
+	}
+	return powerState != types.VirtualMachinePowerStatePoweredOff && !d.Get("reboot_required").(bool)
+}
+
+// virtualMachineConfigSpecChangedWithoutExtraConfig returns true if any
+// attribute of the virtual machine configuration, other than the extra_config
+// attributes, has changed.
+func virtualMachineConfigSpecChangedWithoutExtraConfig(d *schema.ResourceData) bool {
+	for k := range schemaVirtualMachineConfigSpec() {
+		switch k {
+		case "extra_config", "extra_config_reboot_required", "extra_config_apply_on_reboot", "extra_config_staged":
+			continue
+		}
+		if d.HasChange(k) {
+			return true
+		}
+	}
+	return false
+}
+
 // flattenExtraConfig reads in the extraConfig from a running virtual machine
 // and *only* sets the keys in extra_config that we know about. This is to
 // prevent Terraform from interfering with values that are maintained
@@ -727,18 +1388,192 @@ func flattenExtraConfig(d *schema.ResourceData, opts []types.BaseOptionValue) er
 		// No opts to read is a no-op
 		return nil
 	}
+	// Staged keys keep their configured value until they are applied.
+	staged := d.Get("extra_config_staged").(map[string]interface{})
 	ec := make(map[string]interface{})
+	for k, cv := range d.Get("extra_config").(map[string]interface{}) {
+		if _, ok := staged[k]; ok {
+			ec[k] = cv
+		}
+	}
 	for _, v := range opts {
 		ov := v.GetOptionValue()
-		for k := range d.Get("extra_config").(map[string]interface{}) {
+		if _, ok := staged[ov.Key]; ok {
+			continue
+		}
+		for k, cv := range d.Get("extra_config").(map[string]interface{}) {
 			if ov.Key == k {
-				ec[ov.Key] = ov.Value
+				ec[ov.Key] = normalizeExtraConfigValue(cv, ov.Value)
 			}
 		}
 	}
 	return d.Set("extra_config", ec)
 }
 
+// expandContentBasedReadCache returns the extraConfig changes for the
+// content_based_read_cache block. Nothing is returned when the block has not
+// changed.
+func expandContentBasedReadCache(d *schema.ResourceData) []types.BaseOptionValue {
+	if !d.HasChange("content_based_read_cache") {
+		return nil
+	}
+	// The cache is set up when the virtual machine is powered on.
+	_ = d.Set("reboot_required", true)
+	old, newValue := d.GetChange("content_based_read_cache")
+	return expandContentBasedReadCacheOptions(old.([]interface{}), newValue.([]interface{}))
+}
+
+// expandContentBasedReadCacheOptions returns the extraConfig keys that change
+// between the old and new content_based_read_cache blocks. Keys for a removed
+// block are returned with an empty value so that they are removed from
+// extraConfig.
+func expandContentBasedReadCacheOptions(oldBlock, newBlock []interface{}) []types.BaseOptionValue {
+	oldOpts := contentBasedReadCacheOptions(oldBlock)
+	newOpts := contentBasedReadCacheOptions(newBlock)
+	var opts []types.BaseOptionValue
+	for _, k := range []string{virtualMachineCBRCEnableKey} {
+		oldValue, inOld := oldOpts[k]
+		newValue, inNew := newOpts[k]
+		if inOld == inNew && oldValue == newValue {
+			continue
+		}
+		opts = append(opts, &types.OptionValue{
+			Key:   k,
+			Value: newValue,
+		})
+	}
+	return opts
+}
+
+// contentBasedReadCacheOptions returns the extraConfig keys and values for a
+// content_based_read_cache block.
+func contentBasedReadCacheOptions(l []interface{}) map[string]string {
+	opts := make(map[string]string)
+	if len(l) < 1 || l[0] == nil {
+		return opts
+	}
+	m := l[0].(map[string]interface{})
+	opts[virtualMachineCBRCEnableKey] = strings.ToUpper(strconv.FormatBool(m["enabled"].(bool)))
+	return opts
+}
+
+// expandManagedByInfo returns the ManagedByInfo for the managed_by block.
+// Nothing is returned when the block has not changed. When the block has been
+// removed, an empty extension key is returned to remove the marker.
+func expandManagedByInfo(d *schema.ResourceData) *types.ManagedByInfo {
+	if !d.HasChange("managed_by") {
+		return nil
+	}
+	return expandManagedByInfoBlock(d.Get("managed_by").([]interface{}))
+}
+
+// expandManagedByInfoBlock returns the ManagedByInfo for the contents of a
+// managed_by block. An empty block returns an empty extension key.
+func expandManagedByInfoBlock(l []interface{}) *types.ManagedByInfo {
+	if len(l) < 1 || l[0] == nil {
+		return &types.ManagedByInfo{}
+	}
+	m := l[0].(map[string]interface{})
+	return &types.ManagedByInfo{
+		ExtensionKey: m["extension_key"].(string),
+		Type:         m["type"].(string),
+	}
+}
+
+// flattenManagedByInfo reads the ManagedByInfo of the virtual machine. Like
+// flattenContentBasedReadCache, the marker is only tracked once the block is
+// in configuration, to avoid conflicts with solutions that manage the virtual
+// machine outside of Terraform.
+func flattenManagedByInfo(d *schema.ResourceData, obj *types.ManagedByInfo) error {
+	if len(d.Get("managed_by").([]interface{})) < 1 {
+		return nil
+	}
+	if obj == nil || obj.ExtensionKey == "" {
+		return d.Set("managed_by", nil)
+	}
+	return d.Set("managed_by", []interface{}{
+		map[string]interface{}{
+			"extension_key": obj.ExtensionKey,
+			"type":          obj.Type,
+		},
+	})
+}
+
+// flattenContentBasedReadCache reads the content based read cache settings
+// from extraConfig. Like flattenExtraConfig, the keys are only tracked once
+// the block is in configuration, to avoid conflicts with settings maintained
+// outside of Terraform.
+func flattenContentBasedReadCache(d *schema.ResourceData, opts []types.BaseOptionValue) error {
+	if len(d.Get("content_based_read_cache").([]interface{})) < 1 {
+		return nil
+	}
+	for _, v := range opts {
+		ov := v.GetOptionValue()
+		if ov.Key != virtualMachineCBRCEnableKey {
+			continue
+		}
+		value, _ := ov.Value.(string)
+		return d.Set("content_based_read_cache", []interface{}{
+			map[string]interface{}{
+				"enabled": strings.EqualFold(value, "true"),
+			},
+		})
+	}
+	return d.Set("content_based_read_cache", nil)
+}
+
+// validateContentBasedReadCacheExtraConfig checks that the keys managed by
+// content_based_read_cache are not also set in extra_config.
+func validateContentBasedReadCacheExtraConfig(extraConfig map[string]interface{}, cbrc []interface{}) error {
+	if len(cbrc) < 1 {
+		return nil
+	}
+	for k := range contentBasedReadCacheOptions(cbrc) {
+		if _, ok := extraConfig[k]; ok {
+			return fmt.Errorf("extra_config key %q cannot be used with content_based_read_cache", k)
+		}
+	}
+	return nil
+}
+
+// normalizeExtraConfigValue returns the configured value for an extra_config
+// key when vSphere has returned a numerically equivalent value in a different
+// form, such as 0.0 for 0. Any other value is returned as read.
+func normalizeExtraConfigValue(configured, actual interface{}) interface{} {
+	cs, ok := configured.(string)
+	if !ok {
+		return actual
+	}
+	as, ok := actual.(string)
+	if !ok {
+		return actual
+	}
+	if extraConfigValuesEquivalent(cs, as) {
+		return cs
+	}
+	return actual
+}
+
+// extraConfigValuesEquivalent reports whether two extra_config values are
+// equal, either as strings or as exactly equal decimal numbers.
+func extraConfigValuesEquivalent(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if !extraConfigNumericPattern.MatchString(a) || !extraConfigNumericPattern.MatchString(b) {
+		return false
+	}
+	ar, ok := new(big.Rat).SetString(a)
+	if !ok {
+		return false
+	}
+	br, ok := new(big.Rat).SetString(b)
+	if !ok {
+		return false
+	}
+	return ar.Cmp(br) == 0
+}
+
 // expandVAppConfig reads in all the vapp key/value pairs and returns
 // the appropriate VmConfigSpec.
 //
@@ -755,9 +1590,12 @@ func expandVAppConfig(d *schema.ResourceData, client *govmomi.Client) (*types.Vm
 	// know which ones they are, so we will restart for every change.
 	_ = d.Set("reboot_required", true)
 
-	var props []types.VAppPropertySpec
-
-	_, newValue := d.GetChange("vapp")
+	oldValue, newValue := d.GetChange("vapp")
+	if vAppConfigRemoved(oldValue.([]interface{}), newValue.([]interface{}), d.Get("vapp_config_removal_enabled").(bool)) {
+		// The vApp configuration is removed through VAppConfigRemoved, so no
+		// properties are changed.
+		return nil, nil
+	}
 	newMap := make(map[string]interface{})
 
 	newVApps := newValue.([]interface{})
@@ -772,91 +1610,226 @@ func expandVAppConfig(d *schema.ResourceData, client *govmomi.Client) (*types.Vm
 		}
 	}
 
+	transport := expandVAppOvfEnvironmentTransport(d)
+
 	uuid := d.Id()
 	if uuid == "" {
 		// No virtual machine has been created, this usually means that this is a
 		// brand new virtual machine. vApp properties are not supported on this
 		// workflow, so if there are any defined, return an error indicating such.
-		// Return with a no-op otherwise.
+		// Only the transport is set otherwise.
 		if len(newMap) > 0 {
 			return nil, fmt.Errorf("vApp properties can only be set on cloned virtual machines")
 		}
-		return nil, nil
+		if transport == nil {
+			return nil, nil
+		}
+		return &types.VmConfigSpec{
+			OvfEnvironmentTransport: transport,
+		}, nil
+	}
+	vm, err := virtualmachine.FromUUID(client, d.Id())
+	if err != nil {
+		return nil, err
+	}
+	vmProps, err := virtualmachine.Properties(vm)
+	if err != nil {
+		return nil, err
 	}
-	vm, _ := virtualmachine.FromUUID(client, d.Id())
-	vmProps, _ := virtualmachine.Properties(vm)
 	if vmProps.Config.VAppConfig == nil {
-		return nil, fmt.Errorf("this VM lacks a vApp configuration and cannot have vApp properties set on it")
+		if len(newMap) > 0 || transport == nil {
+			return nil, fmt.Errorf("this VM lacks a vApp configuration and cannot have vApp properties set on it")
+		}
+		return &types.VmConfigSpec{
+			OvfEnvironmentTransport: transport,
+		}, nil
 	}
 	allProperties := vmProps.Config.VAppConfig.GetVmConfigInfo().Property
 
 	enableHiddenProperties := d.Get("ovf_deploy.0.enable_hidden_properties").(bool)
+	props, err := expandVAppPropertySpecs(allProperties, newMap, enableHiddenProperties)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, p := range allProperties {
-		if enableHiddenProperties {
-			defaultValue := " "
-			if p.DefaultValue != "" {
-				defaultValue = p.DefaultValue
-			}
-			userConfigurable := true
-			prop := types.VAppPropertySpec{
-				ArrayUpdateSpec: types.ArrayUpdateSpec{
-					Operation: types.ArrayUpdateOperationEdit,
-				},
-				Info: &types.VAppPropertyInfo{
-					Key:              p.Key,
-					Id:               p.Id,
-					Value:            defaultValue,
-					UserConfigurable: &userConfigurable,
-				},
-			}
+	return &types.VmConfigSpec{
+		Property:                props,
+		OvfEnvironmentTransport: transport,
+	}, nil
+}
+
+// expandVAppOvfEnvironmentTransport returns the OVF environment transports in
+// vapp.0.ovf_environment_transport if they have changed, and nil otherwise.
+// Transports that are no longer configured are left on the virtual machine.
+// A change flags a reboot, as the guest only reads the OVF environment when it
+// boots.
+func expandVAppOvfEnvironmentTransport(d *schema.ResourceData) []string {
+	if !d.HasChange("vapp.0.ovf_environment_transport") {
+		return nil
+	}
+	transport := structure.SliceInterfacesToStrings(d.Get("vapp.0.ovf_environment_transport").([]interface{}))
+	if len(transport) < 1 {
+		return nil
+	}
+	flagRestartOnChange(d, "vapp.0.ovf_environment_transport")
+	log.Printf("[DEBUG] %s: Setting OVF environment transport to %s", resourceVSphereVirtualMachineIDString(d), strings.Join(transport, ","))
+	return transport
+}
 
-			newValue, ok := newMap[p.Id]
+// expandVAppConfigRemoved returns true if the vApp configuration of the
+// virtual machine must be removed, and nil otherwise.
+func expandVAppConfigRemoved(d *schema.ResourceData) *bool {
+	if !d.HasChange("vapp") {
+		return nil
+	}
+	oldValue, newValue := d.GetChange("vapp")
+	if !vAppConfigRemoved(oldValue.([]interface{}), newValue.([]interface{}), d.Get("vapp_config_removal_enabled").(bool)) {
+		return nil
+	}
+	log.Printf("[DEBUG] %s: Removing vApp configuration", resourceVSphereVirtualMachineIDString(d))
+	return types.NewBool(true)
+}
+
+// vAppConfigRemoved returns true if the vapp block has been removed and
+// removal of the vApp configuration has been enabled.
+func vAppConfigRemoved(oldValue, newValue []interface{}, enabled bool) bool {
+	if !enabled {
+		return false
+	}
+	return len(oldValue) > 0 && oldValue[0] != nil && (len(newValue) < 1 || newValue[0] == nil)
+}
+
+// expandVAppPropertySpecs returns the property specs that set the values in
+// newMap, and the default value for all other configurable properties.
+//
+// All properties are validated before any spec is returned, so that the
+// properties are either all applied in a single reconfigure, or none are. The
+// errors for all invalid properties are returned together. The specs are
+// sorted by key for a stable ordering.
+func expandVAppPropertySpecs(allProperties []types.VAppPropertyInfo, newMap map[string]interface{}, enableHiddenProperties bool) ([]types.VAppPropertySpec, error) {
+	var props []types.VAppPropertySpec
+	var errs []error
+	for _, p := range allProperties {
+		newValue, ok := newMap[p.Id]
+		delete(newMap, p.Id)
+		userConfigurable := p.UserConfigurable != nil && *p.UserConfigurable
+		if !enableHiddenProperties && !userConfigurable {
 			if ok {
-				prop.Info.Value = newValue.(string)
-				delete(newMap, p.Id)
+				errs = append(errs, fmt.Errorf("vApp property %q with userConfigurable=false specified in vapp.properties", p.Id))
 			}
-			props = append(props, prop)
-		} else {
-			if *p.UserConfigurable {
-				defaultValue := " "
-				if p.DefaultValue != "" {
-					defaultValue = p.DefaultValue
-				}
-				prop := types.VAppPropertySpec{
-					ArrayUpdateSpec: types.ArrayUpdateSpec{
-						Operation: types.ArrayUpdateOperationEdit,
-					},
-					Info: &types.VAppPropertyInfo{
-						Key:              p.Key,
-						Id:               p.Id,
-						Value:            defaultValue,
-						UserConfigurable: p.UserConfigurable,
-					},
-				}
+			continue
+		}
 
-				newValue, ok := newMap[p.Id]
-				if ok {
-					prop.Info.Value = newValue.(string)
-					delete(newMap, p.Id)
-				}
-				props = append(props, prop)
-			} else {
-				_, ok := newMap[p.Id]
-				if ok {
-					return nil, fmt.Errorf("vApp property with userConfigurable=false specified in vapp.properties: %+v", reflect.ValueOf(newMap).MapKeys())
-				}
+		value := " "
+		if p.DefaultValue != "" {
+			value = p.DefaultValue
+		}
+		if ok {
+			value = newValue.(string)
+			if err := validateVAppPropertyValue(p.Type, value); err != nil {
+				errs = append(errs, fmt.Errorf("vApp property %q: %s", p.Id, err))
+				continue
 			}
 		}
+		if enableHiddenProperties {
+			userConfigurable = true
+		}
+		props = append(props, types.VAppPropertySpec{
+			ArrayUpdateSpec: types.ArrayUpdateSpec{
+				Operation: types.ArrayUpdateOperationEdit,
+			},
+			Info: &types.VAppPropertyInfo{
+				Key:              p.Key,
+				Id:               p.Id,
+				Value:            value,
+				UserConfigurable: &userConfigurable,
+			},
+		})
 	}
 
 	if len(newMap) > 0 {
-		return nil, fmt.Errorf("unsupported vApp properties in vapp.properties: %+v", reflect.ValueOf(newMap).MapKeys())
+		keys := make([]string, 0, len(newMap))
+		for k := range newMap {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		errs = append(errs, fmt.Errorf("unsupported vApp properties in vapp.properties: %s", strings.Join(keys, ", ")))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 
-	return &types.VmConfigSpec{
-		Property: props,
-	}, nil
+	sort.Slice(props, func(i, j int) bool {
+		return props[i].Info.Key < props[j].Info.Key
+	})
+	return props, nil
+}
+
+// vAppPropertyRangePattern matches the optional range of a vApp property
+// type, such as int(1..10) or string(..64).
+var vAppPropertyRangePattern = regexp.MustCompile(`^\((-?[0-9.]*)\.\.(-?[0-9.]*)\)$`)
+
+// validateVAppPropertyValue checks that value is valid for the OVF type of a
+// vApp property, such as boolean, int(1..10), real, ip, string(..64), or
+// string["a", "b"]. Unknown types are not validated.
+func validateVAppPropertyValue(typ, value string) error {
+	base, qualifier := typ, ""
+	if i := strings.IndexAny(typ, "(["); i >= 0 {
+		base, qualifier = typ[:i], typ[i:]
+	}
+	// An IP address type may be qualified by a network name, as in ip:network.
+	if strings.HasPrefix(base, "ip:") {
+		base = "ip"
+	}
+	var rangeMin, rangeMax string
+	if m := vAppPropertyRangePattern.FindStringSubmatch(qualifier); m != nil {
+		rangeMin, rangeMax = m[1], m[2]
+	}
+
+	switch base {
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("value %q is not a boolean", value)
+		}
+	case "int", "real":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || (base == "int" && n != float64(int64(n))) {
+			return fmt.Errorf("value %q is not of type %s", value, base)
+		}
+		return validateVAppPropertyRange(n, rangeMin, rangeMax, value)
+	case "string", "password":
+		if strings.HasPrefix(qualifier, "[") {
+			choices := strings.Split(strings.Trim(qualifier, "[]"), ",")
+			for _, c := range choices {
+				if strings.Trim(strings.TrimSpace(c), `"`) == value {
+					return nil
+				}
+			}
+			return fmt.Errorf("value %q must be one of %s", value, qualifier)
+		}
+		return validateVAppPropertyRange(float64(len(value)), rangeMin, rangeMax, value)
+	case "ip":
+		if value != "" && net.ParseIP(value) == nil {
+			return fmt.Errorf("value %q is not an IP address", value)
+		}
+	}
+	return nil
+}
+
+// validateVAppPropertyRange checks that n is within the bounds of a vApp
+// property range. Empty bounds are not checked.
+func validateVAppPropertyRange(n float64, rangeMin, rangeMax, value string) error {
+	if rangeMin != "" {
+		if lower, err := strconv.ParseFloat(rangeMin, 64); err == nil && n < lower {
+			return fmt.Errorf("value %q is below the minimum of %s", value, rangeMin)
+		}
+	}
+	if rangeMax != "" {
+		if upper, err := strconv.ParseFloat(rangeMax, 64); err == nil && n > upper {
+			return fmt.Errorf("value %q is above the maximum of %s", value, rangeMax)
+		}
+	}
+	return nil
 }
 
 // flattenVAppConfig reads in the vAppConfig from a running virtual machine
@@ -869,24 +1842,43 @@ func flattenVAppConfig(d *schema.ResourceData, config types.BaseVmConfigInfo) er
 	// Set `vapp_config here while config is available to avoid extra API calls
 	_ = d.Set("vapp_transport", config.GetVmConfigInfo().OvfEnvironmentTransport)
 
+	// The transport is only read back into the vapp block when it is
+	// configured, it is always available in vapp_transport.
+	var transport []string
+	if len(d.Get("vapp.0.ovf_environment_transport").([]interface{})) > 0 {
+		transport = config.GetVmConfigInfo().OvfEnvironmentTransport
+	}
+
 	props := config.GetVmConfigInfo().Property
-	if len(props) < 1 {
+	if len(props) < 1 && len(transport) < 1 {
 		// No props to read is a no-op
 		return nil
 	}
+	// Properties that are not user configurable are only read back if they
+	// were set with ovf_deploy.0.enable_hidden_properties, as they are
+	// otherwise not managed by the provider. A property without
+	// UserConfigurable set is not user configurable. ovf_deploy is not part of
+	// the data source schema, which reads all user configurable properties.
+	enableHiddenProperties, _ := d.Get("ovf_deploy.0.enable_hidden_properties").(bool)
+	configured, _ := d.Get("vapp.0.properties").(map[string]interface{})
 	vac := make(map[string]interface{})
 	for _, v := range props {
-		if *v.UserConfigurable {
-			if v.Value != "" && v.Value != v.DefaultValue {
-				vac[v.Id] = v.Value
+		userConfigurable := v.UserConfigurable != nil && *v.UserConfigurable
+		if !userConfigurable {
+			if _, ok := configured[v.Id]; !ok || !enableHiddenProperties {
+				continue
 			}
 		}
+		if v.Value != "" && v.Value != v.DefaultValue {
+			vac[v.Id] = v.Value
+		}
 	}
 	// Only set if properties exist to prevent creating an unnecessary diff
-	if len(vac) > 0 {
+	if len(vac) > 0 || len(transport) > 0 {
 		return d.Set("vapp", []interface{}{
 			map[string]interface{}{
-				"properties": vac,
+				"properties":                vac,
+				"ovf_environment_transport": transport,
 			},
 		})
 	}
@@ -894,69 +1886,32 @@ func flattenVAppConfig(d *schema.ResourceData, config types.BaseVmConfigInfo) er
 }
 
 // expandCPUCountConfig is a helper for expandVirtualMachineConfigSpec that
-// determines if we need to restart the VM due to a change in CPU count. This
-// is determined by the net change in CPU count and the pre-update values of
-// cpu_hot_add_enabled and cpu_hot_remove_enabled. The pre-update value is
-// important here as while CPU hot-add/remove is supported while the values are
-// enabled on the virtual machine, modification of hot-add/remove themselves is
-// an operation that requires a power down of the VM.
+// returns the CPU count, and flags a reboot if the change in CPU count cannot
+// be applied with CPU hot-add or hot-remove. See isHotApplicableChange.
 func expandCPUCountConfig(d *schema.ResourceData) int32 {
-	occ, ncc := d.GetChange("num_cpus")
-	cha, _ := d.GetChange("cpu_hot_add_enabled")
-	currentHotAdd := cha.(bool)
-	chr, _ := d.GetChange("cpu_hot_remove_enabled")
-	currentHotRemove := chr.(bool)
-	oldCPUCount := int32(occ.(int))
-	newCPUCount := int32(ncc.(int))
-
-	switch {
-	case oldCPUCount < newCPUCount:
-		// Adding CPUs
-		if !currentHotAdd {
-			log.Printf("[DEBUG] %s: CPU operation requires a VM restart", resourceVSphereVirtualMachineIDString(d))
-			_ = d.Set("reboot_required", true)
-		}
-	case oldCPUCount > newCPUCount:
-		// Removing CPUs
-		if !currentHotRemove {
-			log.Printf("[DEBUG] %s: CPU operation requires a VM restart", resourceVSphereVirtualMachineIDString(d))
-			_ = d.Set("reboot_required", true)
-		}
-	}
-	return newCPUCount
+	flagRestartOnChange(d, "num_cpus")
+	return int32(d.Get("num_cpus").(int))
 }
 
 // expandMemorySizeConfig is a helper for expandVirtualMachineConfigSpec that
-// determines if we need to restart the system to increase the amount of
-// available memory on the system. This is determined by the current (or in
-// other words, the old, pre-update setting) of memory_hot_add_enabled.
+// returns the memory size, and flags a reboot if the change in memory size
+// cannot be applied with memory hot-add. See isHotApplicableChange.
 func expandMemorySizeConfig(d *schema.ResourceData) int64 {
-	om, nm := d.GetChange("memory")
-	cha, _ := d.GetChange("memory_hot_add_enabled")
-	currentHotAdd := cha.(bool)
-	oldMem := int64(om.(int))
-	newMem := int64(nm.(int))
-
-	switch {
-	case oldMem < newMem:
-		// Adding CPUs
-		if !currentHotAdd {
-			log.Printf("[DEBUG] %s: Memory operation requires a VM restart", resourceVSphereVirtualMachineIDString(d))
-			_ = d.Set("reboot_required", true)
-		}
-	case oldMem > newMem:
-		// Removing memory always requires a reboot
-		log.Printf("[DEBUG] %s: Memory operation requires a VM restart", resourceVSphereVirtualMachineIDString(d))
-		_ = d.Set("reboot_required", true)
-	}
-	return newMem
+	flagRestartOnChange(d, "memory")
+	return int64(d.Get("memory").(int))
 }
 
-// expandVirtualMachineProfileSpec reads storage policy ID from ResourceData and
-// returns VirtualMachineProfileSpec.
+// expandVirtualMachineProfileSpec reads the storage policy and replication
+// group IDs from ResourceData and returns VirtualMachineProfileSpec.
 func expandVirtualMachineProfileSpec(d *schema.ResourceData) []types.BaseVirtualMachineProfileSpec {
 	if policyID := d.Get("storage_policy_id").(string); policyID != "" {
-		return spbm.PolicySpecByID(policyID)
+		spec := spbm.PolicySpecByID(policyID)
+		if rgID, err := spbm.ParseReplicationGroupID(d.Get("replication_group_id").(string)); err == nil {
+			spec[0].(*types.VirtualMachineDefinedProfileSpec).ReplicationSpec = &types.ReplicationSpec{
+				ReplicationGroupId: *rgID,
+			}
+		}
+		return spec
 	}
 
 	return nil
@@ -987,17 +1942,24 @@ func expandVirtualMachineConfigSpec(d *schema.ResourceData, client *govmomi.Clie
 		CpuAllocation:                expandVirtualMachineResourceAllocation(d, "cpu"),
 		MemoryAllocation:             expandVirtualMachineResourceAllocation(d, "memory"),
 		MemoryReservationLockedToMax: getMemoryReservationLockedToMax(d),
-		ExtraConfig:                  expandExtraConfig(d),
+		CpuAffinity:                  expandVirtualMachineAffinity(d, "cpu_affinity"),
+		MemoryAffinity:               expandVirtualMachineAffinity(d, "memory_affinity"),
+		ExtraConfig:                  append(expandExtraConfig(d), expandContentBasedReadCache(d)...),
 		SwapPlacement:                getWithRestart(d, "swap_placement_policy").(string),
 		BootOptions:                  expandVirtualMachineBootOptions(d, client),
 		VAppConfig:                   vappConfig,
+		VAppConfigRemoved:            expandVAppConfigRemoved(d),
 		Firmware:                     getWithRestart(d, "firmware").(string),
 		NestedHVEnabled:              getBoolWithRestart(d, "nested_hv_enabled"),
 		VPMCEnabled:                  getBoolWithRestart(d, "cpu_performance_counters_enabled"),
 		LatencySensitivity:           expandLatencySensitivity(d),
+		ManagedBy:                    expandManagedByInfo(d),
 		VmProfile:                    expandVirtualMachineProfileSpec(d),
 		Version:                      virtualmachine.GetHardwareVersionID(d.Get("hardware_version").(int)),
 	}
+	if err := expandLatencySensitivityReservations(d, client, &obj); err != nil {
+		return types.VirtualMachineConfigSpec{}, err
+	}
 
 	return obj, nil
 }
@@ -1043,15 +2005,28 @@ func flattenVirtualMachineConfigInfo(d *schema.ResourceData, obj *types.VirtualM
 	if err := flattenVirtualMachineResourceAllocation(d, obj.MemoryAllocation, "memory"); err != nil {
 		return err
 	}
+	if err := flattenVirtualMachineAffinity(d, "cpu_affinity", obj.CpuAffinity); err != nil {
+		return err
+	}
+	if err := flattenVirtualMachineAffinity(d, "memory_affinity", obj.MemoryAffinity); err != nil {
+		return err
+	}
 	if err := flattenExtraConfig(d, obj.ExtraConfig); err != nil {
 		return err
 	}
+	if err := flattenContentBasedReadCache(d, obj.ExtraConfig); err != nil {
+		return err
+	}
+	if err := flattenManagedByInfo(d, obj.ManagedBy); err != nil {
+		return err
+	}
 	if err := flattenVAppConfig(d, obj.VAppConfig); err != nil {
 		return err
 	}
 	if err := flattenLatencySensitivity(d, obj.LatencySensitivity); err != nil {
 		return err
 	}
+	flattenScheduledHardwareUpgradeInfo(d, obj.ScheduledHardwareUpgradeInfo)
 
 	// This method does not operate any different than the above method but we
 	// return its error result directly to ensure there are no warnings in the
@@ -1059,6 +2034,27 @@ func flattenVirtualMachineConfigInfo(d *schema.ResourceData, obj *types.VirtualM
 	return flattenVirtualMachineBootOptions(d, obj.BootOptions)
 }
 
+// flattenScheduledHardwareUpgradeInfo reads the status of the last scheduled
+// hardware upgrade. Virtual machines without a scheduled upgrade report a
+// status of none.
+func flattenScheduledHardwareUpgradeInfo(d *schema.ResourceData, obj *types.ScheduledHardwareUpgradeInfo) {
+	status := string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusNone)
+	var fault string
+	if obj != nil {
+		if obj.ScheduledHardwareUpgradeStatus != "" {
+			status = obj.ScheduledHardwareUpgradeStatus
+		}
+		if obj.Fault != nil {
+			fault = obj.Fault.LocalizedMessage
+			if fault == "" && obj.Fault.Fault != nil {
+				fault = reflect.TypeOf(obj.Fault.Fault).Elem().Name()
+			}
+		}
+	}
+	_ = d.Set("scheduled_hardware_upgrade_status", status)
+	_ = d.Set("scheduled_hardware_upgrade_fault", fault)
+}
+
 // expandVirtualMachineConfigSpecChanged compares an existing
 // VirtualMachineConfigInfo with a VirtualMachineConfigSpec generated from
 // existing resource data and compares them to see if there is a change. The new spec
@@ -1100,25 +2096,108 @@ func expandVirtualMachineConfigSpecChanged(d *schema.ResourceData, client *govmo
 	return newSpec, isVMConfigSpecChanged, nil
 }
 
-// getMemoryReservationLockedToMax determines if the memory_reservation is not
-// set to be equal to memory. If they are not equal, then the memory
-// reservation needs to be unlocked from the maximum. Rather than supporting
-// the locking reservation to max option, we can set memory_reservation to
-// memory in the configuration. Not supporting the option causes problems when
-// cloning from a template that has it enabled. The solution is to set it to
-// false when needed, but leave it alone when the change is not necessary.
+// getMemoryReservationLockedToMax returns the value of
+// memory_reservation_locked_to_max to send to vSphere.
+//
+// When memory_reservation_locked_to_max is enabled, it is authoritative and
+// the reservation follows the memory size. A memory_reservation lower than
+// memory is rejected by validateMemoryReservationLockedToMax. Otherwise, the
+// memory reservation needs to be unlocked from the maximum if it is not equal
+// to memory, such as when cloning from a template that has the option
+// enabled, and is left alone when the change is not necessary.
 func getMemoryReservationLockedToMax(d *schema.ResourceData) *bool {
-	memory := d.Get("memory").(int)
-	memoryReservation := d.Get("memory_reservation").(int)
-	memoryLockMax := d.Get("memory_reservation_locked_to_max").(bool)
+	if d.Get("memory_reservation_locked_to_max").(bool) {
+		return structure.BoolPtr(true)
+	}
 
-	if memory != memoryReservation {
+	if d.Get("memory").(int) != d.Get("memory_reservation").(int) {
 		return structure.BoolPtr(false)
 	}
 
-	if memory == memoryReservation && memoryLockMax {
-		return structure.BoolPtr(true)
+	return nil
+}
+
+// configuredMemoryReservation returns the memory_reservation set in the
+// configuration, or 0 if it is not set or not known yet. Unlike the value in
+// the diff, this does not include a reservation read back from vSphere.
+func configuredMemoryReservation(d *schema.ResourceDiff) int {
+	return configuredInt(d.GetRawConfig(), "memory_reservation")
+}
+
+// configuredInt returns the top-level integer attribute key set in the raw
+// configuration raw, or 0 if it is not set or not known yet.
+func configuredInt(raw cty.Value, key string) int {
+	if !raw.IsKnown() || raw.IsNull() {
+		return 0
+	}
+	v := raw.GetAttr(key)
+	if !v.IsKnown() || v.IsNull() {
+		return 0
+	}
+	n, _ := v.AsBigFloat().Int64()
+	return int(n)
+}
+
+// configuredBool returns the top-level boolean attribute key set in the raw
+// configuration raw, or nil if it is not set or not known yet.
+func configuredBool(raw cty.Value, key string) *bool {
+	if !raw.IsKnown() || raw.IsNull() {
+		return nil
+	}
+	v := raw.GetAttr(key)
+	if !v.IsKnown() || v.IsNull() {
+		return nil
+	}
+	return structure.BoolPtr(v.True())
+}
+
+// validateCPUTopology checks that num_cpus is not lower than, and evenly
+// divisible by, num_cores_per_socket. This most commonly fails when num_cpus
+// is decreased without adjusting num_cores_per_socket.
+func validateCPUTopology(numCPUs, coresPerSocket int) error {
+	if coresPerSocket < 1 {
+		return nil
 	}
+	if numCPUs < coresPerSocket {
+		return fmt.Errorf("num_cpus (%d) cannot be lower than num_cores_per_socket (%d)", numCPUs, coresPerSocket)
+	}
+	if numCPUs%coresPerSocket != 0 {
+		return fmt.Errorf("num_cpus (%d) must be evenly divisible by num_cores_per_socket (%d)", numCPUs, coresPerSocket)
+	}
+	return nil
+}
+
+// validateMemorySize checks that memory is not lower than memory_reservation,
+// which vSphere rejects. A lower memory size is only rejected when the virtual
+// machine is reconfigured, which happens after it has been powered off for the
+// change.
+func validateMemorySize(memory, reservation int) error {
+	if reservation > memory {
+		return fmt.Errorf("memory (%d) cannot be lower than memory_reservation (%d)", memory, reservation)
+	}
+	return nil
+}
 
+// validateMemoryHotAdd checks that hot-adding memory from oldMemory to
+// newMemory is within the hot-add limit of the virtual machine, and in
+// multiples of its hot-add increment. A limit or increment of 0 is not
+// reported by vSphere and not checked.
+func validateMemoryHotAdd(oldMemory, newMemory int, limit, increment int64) error {
+	if limit > 0 && int64(newMemory) > limit {
+		return fmt.Errorf("memory (%d) exceeds the memory hot-add limit of the virtual machine (%d MB), power off the virtual machine to apply the change", newMemory, limit)
+	}
+	if increment > 0 && int64(newMemory-oldMemory)%increment != 0 {
+		return fmt.Errorf("memory can only be hot-added in multiples of %d MB, got an increase of %d MB", increment, newMemory-oldMemory)
+	}
 	return nil
 }
+
+// validateMemoryReservationLockedToMax checks that memory_reservation does not
+// conflict with memory_reservation_locked_to_max. A reservation of 0 is not
+// set and is filled in by vSphere.
+func validateMemoryReservationLockedToMax(locked bool, memory, reservation int) error {
+	if !locked || reservation == 0 || reservation == memory {
+		return nil
+	}
+	return fmt.Errorf("memory_reservation_locked_to_max requires memory_reservation (%d) to be unset or equal to memory (%d)", reservation, memory)
+}
@@ -0,0 +1,2291 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/spbm"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+)
+
+func TestBootRetryDelayWarning(t *testing.T) {
+	cases := []struct {
+		name     string
+		delay    int
+		expected bool
+	}{
+		{
+			name:     "seconds mistaken for milliseconds",
+			delay:    10,
+			expected: true,
+		},
+		{
+			name:     "just under lower threshold",
+			delay:    999,
+			expected: true,
+		},
+		{
+			name:     "lower threshold",
+			delay:    1000,
+			expected: false,
+		},
+		{
+			name:     "default",
+			delay:    10000,
+			expected: false,
+		},
+		{
+			name:     "upper threshold",
+			delay:    300000,
+			expected: false,
+		},
+		{
+			name:     "just over upper threshold",
+			delay:    300001,
+			expected: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := bootRetryDelayWarning(tc.delay) != ""
+			if tc.expected != actual {
+				t.Fatalf("expected warning to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExtraConfigValuesEquivalent(t *testing.T) {
+	cases := []struct {
+		name     string
+		a        string
+		b        string
+		expected bool
+	}{
+		{
+			name:     "identical strings",
+			a:        "foo",
+			b:        "foo",
+			expected: true,
+		},
+		{
+			name:     "different strings",
+			a:        "foo",
+			b:        "bar",
+			expected: false,
+		},
+		{
+			name:     "integer and decimal zero",
+			a:        "0",
+			b:        "0.0",
+			expected: true,
+		},
+		{
+			name:     "trailing zeroes",
+			a:        "1.5",
+			b:        "1.500",
+			expected: true,
+		},
+		{
+			name:     "leading plus sign",
+			a:        "+42",
+			b:        "42",
+			expected: true,
+		},
+		{
+			name:     "exponent",
+			a:        "1e3",
+			b:        "1000",
+			expected: true,
+		},
+		{
+			name:     "negative values",
+			a:        "-1",
+			b:        "-1.0",
+			expected: true,
+		},
+		{
+			name:     "leading decimal point",
+			a:        ".5",
+			b:        "0.5",
+			expected: true,
+		},
+		{
+			name:     "different numbers",
+			a:        "1",
+			b:        "1.01",
+			expected: false,
+		},
+		{
+			name:     "integers beyond float64 precision",
+			a:        "9007199254740993",
+			b:        "9007199254740992",
+			expected: false,
+		},
+		{
+			name:     "leading zeroes are compared as string",
+			a:        "007",
+			b:        "7",
+			expected: false,
+		},
+		{
+			name:     "hexadecimal is compared as string",
+			a:        "0x10",
+			b:        "16",
+			expected: false,
+		},
+		{
+			name:     "infinity is compared as string",
+			a:        "Inf",
+			b:        "+Inf",
+			expected: false,
+		},
+		{
+			name:     "empty value",
+			a:        "",
+			b:        "0",
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := extraConfigValuesEquivalent(tc.a, tc.b)
+			if tc.expected != actual {
+				t.Fatalf("expected %q and %q equivalence to be %t, got %t", tc.a, tc.b, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFlattenExtraConfigNumericValues(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, schemaVirtualMachineConfigSpec(), map[string]interface{}{
+		"extra_config": map[string]interface{}{
+			"guestinfo.zero":    "0",
+			"guestinfo.decimal": "2.50",
+			"guestinfo.changed": "1",
+			"guestinfo.string":  "foo",
+		},
+	})
+	opts := []types.BaseOptionValue{
+		&types.OptionValue{Key: "guestinfo.zero", Value: "0.0"},
+		&types.OptionValue{Key: "guestinfo.decimal", Value: "2.5"},
+		&types.OptionValue{Key: "guestinfo.changed", Value: "2"},
+		&types.OptionValue{Key: "guestinfo.string", Value: "foo"},
+		&types.OptionValue{Key: "guestinfo.unmanaged", Value: "1.0"},
+	}
+	if err := flattenExtraConfig(d, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"guestinfo.zero":    "0",
+		"guestinfo.decimal": "2.50",
+		"guestinfo.changed": "2",
+		"guestinfo.string":  "foo",
+	}
+	actual := d.Get("extra_config").(map[string]interface{})
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %d extra_config keys, got %d: %#v", len(expected), len(actual), actual)
+	}
+	for k, v := range expected {
+		if actual[k] != v {
+			t.Fatalf("expected extra_config key %s to be %q, got %q", k, v, actual[k])
+		}
+	}
+}
+
+func TestSetLatencySensitivityReservations(t *testing.T) {
+	cases := []struct {
+		name                string
+		state               map[string]interface{}
+		config              map[string]interface{}
+		cpuMhz              int32
+		expectedMemory      int64
+		expectedCPU         int64
+		expectedAutoReserve bool
+	}{
+		{
+			name: "high latency sensitivity",
+			config: map[string]interface{}{
+				"latency_sensitivity":              "high",
+				"latency_sensitivity_auto_reserve": true,
+				"num_cpus":                         4,
+				"memory":                           8192,
+			},
+			cpuMhz:              2000,
+			expectedMemory:      8192,
+			expectedCPU:         8000,
+			expectedAutoReserve: true,
+		},
+		{
+			name: "configured reservations are kept",
+			config: map[string]interface{}{
+				"latency_sensitivity":              "high",
+				"latency_sensitivity_auto_reserve": true,
+				"num_cpus":                         4,
+				"memory":                           8192,
+				"memory_reservation":               4096,
+				"cpu_reservation":                  1000,
+			},
+			cpuMhz:              2000,
+			expectedMemory:      4096,
+			expectedCPU:         1000,
+			expectedAutoReserve: true,
+		},
+		{
+			name: "unknown host CPU speed",
+			config: map[string]interface{}{
+				"latency_sensitivity":              "high",
+				"latency_sensitivity_auto_reserve": true,
+				"num_cpus":                         4,
+				"memory":                           8192,
+			},
+			expectedMemory:      8192,
+			expectedCPU:         0,
+			expectedAutoReserve: true,
+		},
+		{
+			name: "memory and num_cpus increased",
+			state: map[string]interface{}{
+				"latency_sensitivity":              "high",
+				"latency_sensitivity_auto_reserve": true,
+				"num_cpus":                         4,
+				"memory":                           8192,
+				"memory_reservation":               8192,
+				"cpu_reservation":                  8000,
+			},
+			config: map[string]interface{}{
+				"latency_sensitivity":              "high",
+				"latency_sensitivity_auto_reserve": true,
+				"num_cpus":                         8,
+				"memory":                           16384,
+			},
+			cpuMhz:              2000,
+			expectedMemory:      16384,
+			expectedCPU:         16000,
+			expectedAutoReserve: true,
+		},
+		{
+			name: "normal latency sensitivity",
+			config: map[string]interface{}{
+				"latency_sensitivity":              "normal",
+				"latency_sensitivity_auto_reserve": true,
+				"num_cpus":                         4,
+				"memory":                           8192,
+			},
+			cpuMhz:              2000,
+			expectedAutoReserve: false,
+		},
+		{
+			name: "auto reserve disabled",
+			config: map[string]interface{}{
+				"latency_sensitivity": "high",
+				"num_cpus":            4,
+				"memory":              8192,
+			},
+			cpuMhz:              2000,
+			expectedAutoReserve: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := testVirtualMachineResourceDataRaw(t, tc.state, tc.config)
+			if actual := latencySensitivityAutoReserve(d); tc.expectedAutoReserve != actual {
+				t.Fatalf("expected auto reserve to be %t, got %t", tc.expectedAutoReserve, actual)
+			}
+			if !tc.expectedAutoReserve {
+				return
+			}
+			obj := &types.VirtualMachineConfigSpec{
+				CpuAllocation:    expandVirtualMachineResourceAllocation(d, "cpu"),
+				MemoryAllocation: expandVirtualMachineResourceAllocation(d, "memory"),
+			}
+			setLatencySensitivityReservations(d, obj, tc.cpuMhz)
+			var memory, cpu int64
+			if obj.MemoryAllocation.Reservation != nil {
+				memory = *obj.MemoryAllocation.Reservation
+			}
+			if obj.CpuAllocation.Reservation != nil {
+				cpu = *obj.CpuAllocation.Reservation
+			}
+			if memory != tc.expectedMemory {
+				t.Fatalf("expected memory reservation to be %d, got %d", tc.expectedMemory, memory)
+			}
+			if cpu != tc.expectedCPU {
+				t.Fatalf("expected CPU reservation to be %d, got %d", tc.expectedCPU, cpu)
+			}
+		})
+	}
+}
+
+func TestValidateCPUPerformanceCounters(t *testing.T) {
+	cases := []struct {
+		name            string
+		enabled         bool
+		hardwareVersion int
+		expectErr       bool
+	}{
+		{
+			name:            "disabled on old hardware version",
+			enabled:         false,
+			hardwareVersion: 8,
+			expectErr:       false,
+		},
+		{
+			name:            "enabled below minimum hardware version",
+			enabled:         true,
+			hardwareVersion: 8,
+			expectErr:       true,
+		},
+		{
+			name:            "enabled on minimum hardware version",
+			enabled:         true,
+			hardwareVersion: 9,
+			expectErr:       false,
+		},
+		{
+			name:            "enabled on newer hardware version",
+			enabled:         true,
+			hardwareVersion: 21,
+			expectErr:       false,
+		},
+		{
+			name:            "enabled with unknown hardware version",
+			enabled:         true,
+			hardwareVersion: 0,
+			expectErr:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCPUPerformanceCounters(tc.enabled, tc.hardwareVersion)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestExpandVirtualMachineProfileSpecReplicationGroup(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+		"storage_policy_id":    "policy-1",
+		"replication_group_id": "fd-1:dg-1",
+	})
+	spec := expandVirtualMachineProfileSpec(d)
+	if len(spec) != 1 {
+		t.Fatalf("expected 1 profile spec, got %d", len(spec))
+	}
+	profile := spec[0].(*types.VirtualMachineDefinedProfileSpec)
+	if profile.ProfileId != "policy-1" {
+		t.Fatalf("expected profile ID to be %q, got %q", "policy-1", profile.ProfileId)
+	}
+	if profile.ReplicationSpec == nil {
+		t.Fatal("expected replication spec to be set")
+	}
+	if actual := spbm.FormatReplicationGroupID(&profile.ReplicationSpec.ReplicationGroupId); actual != "fd-1:dg-1" {
+		t.Fatalf("expected replication group ID to be %q, got %q", "fd-1:dg-1", actual)
+	}
+}
+
+func TestExpandContentBasedReadCacheOptions(t *testing.T) {
+	enabled := []interface{}{map[string]interface{}{"enabled": true}}
+	disabled := []interface{}{map[string]interface{}{"enabled": false}}
+	cases := []struct {
+		name     string
+		oldBlock []interface{}
+		newBlock []interface{}
+		expected map[string]string
+	}{
+		{
+			name:     "added",
+			newBlock: enabled,
+			expected: map[string]string{virtualMachineCBRCEnableKey: "TRUE"},
+		},
+		{
+			name:     "changed",
+			oldBlock: enabled,
+			newBlock: disabled,
+			expected: map[string]string{virtualMachineCBRCEnableKey: "FALSE"},
+		},
+		{
+			name:     "unchanged",
+			oldBlock: enabled,
+			newBlock: enabled,
+			expected: map[string]string{},
+		},
+		{
+			name:     "removed",
+			oldBlock: enabled,
+			expected: map[string]string{virtualMachineCBRCEnableKey: ""},
+		},
+		{
+			name:     "never set",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := expandContentBasedReadCacheOptions(tc.oldBlock, tc.newBlock)
+			if len(tc.expected) != len(opts) {
+				t.Fatalf("expected %d options, got %d", len(tc.expected), len(opts))
+			}
+			for _, v := range opts {
+				ov := v.GetOptionValue()
+				expected, ok := tc.expected[ov.Key]
+				if !ok {
+					t.Fatalf("unexpected option %q", ov.Key)
+				}
+				if ov.Value != expected {
+					t.Fatalf("expected option %q to be %q, got %q", ov.Key, expected, ov.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestFlattenContentBasedReadCache(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, schemaVirtualMachineConfigSpec(), map[string]interface{}{
+		"content_based_read_cache": []interface{}{map[string]interface{}{"enabled": false}},
+	})
+	opts := []types.BaseOptionValue{
+		&types.OptionValue{Key: virtualMachineCBRCEnableKey, Value: "TRUE"},
+	}
+	if err := flattenContentBasedReadCache(d, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Get("content_based_read_cache.0.enabled").(bool) {
+		t.Fatal("expected content based read cache to be enabled")
+	}
+
+	if err := flattenContentBasedReadCache(d, nil); err != nil {
+		t.Fatal(err)
+	}
+	if actual := len(d.Get("content_based_read_cache").([]interface{})); actual != 0 {
+		t.Fatalf("expected content based read cache to be removed, got %d blocks", actual)
+	}
+}
+
+func TestValidateContentBasedReadCacheExtraConfig(t *testing.T) {
+	cbrc := []interface{}{map[string]interface{}{"enabled": true}}
+	if err := validateContentBasedReadCacheExtraConfig(map[string]interface{}{"guestinfo.foo": "bar"}, cbrc); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if err := validateContentBasedReadCacheExtraConfig(map[string]interface{}{virtualMachineCBRCEnableKey: "TRUE"}, nil); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if err := validateContentBasedReadCacheExtraConfig(map[string]interface{}{virtualMachineCBRCEnableKey: "TRUE"}, cbrc); err == nil {
+		t.Fatal("expected error, got none")
+	}
+}
+
+func TestExpandVAppPropertySpecs(t *testing.T) {
+	configurable := true
+	notConfigurable := false
+	allProperties := []types.VAppPropertyInfo{
+		{Key: 2, Id: "hostname", Type: "string(..16)", UserConfigurable: &configurable},
+		{Key: 1, Id: "port", Type: "int(1..65535)", UserConfigurable: &configurable},
+		{Key: 3, Id: "ip", Type: "ip", DefaultValue: "10.0.0.1", UserConfigurable: &configurable},
+		{Key: 4, Id: "internal", Type: "string", UserConfigurable: &notConfigurable},
+	}
+
+	cases := []struct {
+		name      string
+		values    map[string]interface{}
+		expectErr bool
+		expected  map[string]string
+	}{
+		{
+			name:   "valid",
+			values: map[string]interface{}{"hostname": "vm-01", "port": "8080"},
+			expected: map[string]string{
+				"hostname": "vm-01",
+				"port":     "8080",
+				"ip":       "10.0.0.1",
+			},
+		},
+		{
+			name:      "invalid property partway",
+			values:    map[string]interface{}{"hostname": "vm-01", "port": "http", "ip": "10.0.0.2"},
+			expectErr: true,
+		},
+		{
+			name:      "not user configurable",
+			values:    map[string]interface{}{"internal": "foo"},
+			expectErr: true,
+		},
+		{
+			name:      "unknown property",
+			values:    map[string]interface{}{"unknown": "foo"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			props, err := expandVAppPropertySpecs(allProperties, tc.values, false)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				if props != nil {
+					t.Fatalf("expected no property specs, got %d", len(props))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tc.expected) != len(props) {
+				t.Fatalf("expected %d property specs, got %d", len(tc.expected), len(props))
+			}
+			for i, p := range props {
+				if i > 0 && props[i-1].Info.Key > p.Info.Key {
+					t.Fatalf("expected property specs to be sorted by key")
+				}
+				if tc.expected[p.Info.Id] != p.Info.Value {
+					t.Fatalf("expected property %q to be %q, got %q", p.Info.Id, tc.expected[p.Info.Id], p.Info.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateVAppPropertyValue(t *testing.T) {
+	cases := []struct {
+		typ       string
+		value     string
+		expectErr bool
+	}{
+		{typ: "boolean", value: "True"},
+		{typ: "boolean", value: "yes", expectErr: true},
+		{typ: "int", value: "42"},
+		{typ: "int", value: "4.2", expectErr: true},
+		{typ: "int(1..10)", value: "11", expectErr: true},
+		{typ: "real(0..1)", value: "0.5"},
+		{typ: "string(..4)", value: "hello", expectErr: true},
+		{typ: "string(2..)", value: "hi"},
+		{typ: `string["small", "large"]`, value: "large"},
+		{typ: `string["small", "large"]`, value: "medium", expectErr: true},
+		{typ: "password(8..)", value: "secret", expectErr: true},
+		{typ: "ip", value: "fd00::1"},
+		{typ: "ip:network", value: "10.0.0.256", expectErr: true},
+		{typ: "expression", value: "${foo}"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.typ+"="+tc.value, func(t *testing.T) {
+			err := validateVAppPropertyValue(tc.typ, tc.value)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestToolsVersionRoundTrip(t *testing.T) {
+	v, err := parseToolsVersion("12.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 12384 {
+		t.Fatalf("expected 12384, got %d", v)
+	}
+	if actual := formatToolsVersion(v); actual != "12.3.0" {
+		t.Fatalf("expected 12.3.0, got %q", actual)
+	}
+	for _, s := range []string{"12", "12.3", "12.32.0", "a.b.c", "-1.0.0"} {
+		if _, err := parseToolsVersion(s); err == nil {
+			t.Fatalf("expected error for %q, got none", s)
+		}
+	}
+}
+
+func TestToolsUpgradeGated(t *testing.T) {
+	cases := []struct {
+		name       string
+		minVersion string
+		installed  string
+		unmanaged  bool
+		expected   bool
+	}{
+		{
+			name:       "installed below threshold",
+			minVersion: "12.3.0",
+			installed:  "12.1.5",
+			expected:   false,
+		},
+		{
+			name:       "installed at threshold",
+			minVersion: "12.3.0",
+			installed:  "12.3.0",
+			expected:   true,
+		},
+		{
+			name:       "installed above threshold",
+			minVersion: "12.3.0",
+			installed:  "13.0.0",
+			expected:   true,
+		},
+		{
+			name:      "no threshold",
+			installed: "12.3.0",
+			expected:  false,
+		},
+		{
+			name:       "not installed",
+			minVersion: "12.3.0",
+			expected:   false,
+		},
+		{
+			name:       "guest managed",
+			minVersion: "12.3.0",
+			unmanaged:  true,
+			expected:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			installed, err := parseToolsVersion(tc.installed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.unmanaged {
+				installed = math.MaxInt32
+			}
+			if actual := toolsUpgradeGated(tc.minVersion, installed); tc.expected != actual {
+				t.Fatalf("expected gating to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVirtualMachineResourceAllocationExpandableReservation(t *testing.T) {
+	cases := []struct {
+		name     string
+		reported *bool
+		expected bool
+	}{
+		{
+			name:     "not reported",
+			reported: nil,
+			expected: false,
+		},
+		{
+			name:     "not expandable",
+			reported: structure.BoolPtr(false),
+			expected: false,
+		},
+		{
+			name:     "expandable",
+			reported: structure.BoolPtr(true),
+			expected: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+				"cpu_reservation":    1000,
+				"memory_reservation": 2048,
+			})
+			for _, key := range virtualMachineResourceAllocationTypeValues {
+				obj := expandVirtualMachineResourceAllocation(d, key)
+				if obj.ExpandableReservation != nil {
+					t.Fatalf("expected %s expandable reservation not to be sent, got %t", key, *obj.ExpandableReservation)
+				}
+
+				obj.ExpandableReservation = tc.reported
+				if err := flattenVirtualMachineResourceAllocation(d, obj, key); err != nil {
+					t.Fatal(err)
+				}
+				if actual := d.Get(key + "_reservation_expandable").(bool); actual != tc.expected {
+					t.Fatalf("expected %s_reservation_expandable to be %t, got %t", key, tc.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestGuestIDBitnessWarning(t *testing.T) {
+	cases := []struct {
+		name     string
+		guestID  string
+		memory   int
+		numCPUs  int
+		expected string
+	}{
+		{
+			name:     "32-bit guest with more than 4GB of memory",
+			guestID:  "ubuntuGuest",
+			memory:   8192,
+			numCPUs:  1,
+			expected: "ubuntu64Guest",
+		},
+		{
+			name:     "32-bit guest with multiple vCPUs",
+			guestID:  "windows7Guest",
+			memory:   2048,
+			numCPUs:  2,
+			expected: "windows7_64Guest",
+		},
+		{
+			name:    "32-bit guest with small hardware",
+			guestID: "otherLinuxGuest",
+			memory:  4096,
+			numCPUs: 1,
+		},
+		{
+			name:    "64-bit guest",
+			guestID: "otherLinux64Guest",
+			memory:  8192,
+			numCPUs: 4,
+		},
+		{
+			name:    "guest without 64-bit variant",
+			guestID: "dosGuest",
+			memory:  8192,
+			numCPUs: 4,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := guestIDBitnessWarning(tc.guestID, tc.memory, tc.numCPUs)
+			if tc.expected == "" {
+				if msg != "" {
+					t.Fatalf("expected no warning, got %q", msg)
+				}
+				return
+			}
+			if !strings.Contains(msg, fmt.Sprintf("%q", tc.expected)) {
+				t.Fatalf("expected warning suggesting %q, got %q", tc.expected, msg)
+			}
+		})
+	}
+}
+
+func TestStageExtraConfig(t *testing.T) {
+	cases := []struct {
+		name           string
+		applyOnReboot  bool
+		powerState     types.VirtualMachinePowerState
+		rebootRequired bool
+		expected       bool
+	}{
+		{
+			name:          "staged while powered on",
+			applyOnReboot: true,
+			powerState:    types.VirtualMachinePowerStatePoweredOn,
+			expected:      true,
+		},
+		{
+			name:          "applied while powered off",
+			applyOnReboot: true,
+			powerState:    types.VirtualMachinePowerStatePoweredOff,
+			expected:      false,
+		},
+		{
+			name:           "applied with another reboot",
+			applyOnReboot:  true,
+			powerState:     types.VirtualMachinePowerStatePoweredOn,
+			rebootRequired: true,
+			expected:       false,
+		},
+		{
+			name:          "applied immediately",
+			applyOnReboot: false,
+			powerState:    types.VirtualMachinePowerStatePoweredOn,
+			expected:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+				"extra_config": map[string]interface{}{
+					"guestinfo.userdata": "foo",
+				},
+				"extra_config_apply_on_reboot": tc.applyOnReboot,
+			})
+			spec := types.VirtualMachineConfigSpec{
+				ExtraConfig: expandExtraConfig(d),
+			}
+			if tc.applyOnReboot && d.Get("reboot_required").(bool) {
+				t.Fatalf("expected staged extra_config not to require a reboot")
+			}
+			if tc.rebootRequired {
+				_ = d.Set("reboot_required", true)
+			}
+
+			actual := stageExtraConfig(d, &spec, tc.powerState)
+			if tc.expected != actual {
+				t.Fatalf("expected extra_config staging to be %t, got %t", tc.expected, actual)
+			}
+			if tc.expected {
+				if len(spec.ExtraConfig) != 0 {
+					t.Fatalf("expected staged extra_config to be removed from the spec, got %#v", spec.ExtraConfig)
+				}
+				if actual := d.Get("extra_config").(map[string]interface{})["guestinfo.userdata"]; actual != "foo" {
+					t.Fatalf("expected extra_config to keep the configured value, got %#v", d.Get("extra_config"))
+				}
+				if actual := d.Get("extra_config_staged").(map[string]interface{})["guestinfo.userdata"]; actual != "foo" {
+					t.Fatalf("expected the change to be recorded in extra_config_staged, got %#v", d.Get("extra_config_staged"))
+				}
+				return
+			}
+			if len(spec.ExtraConfig) != 1 {
+				t.Fatalf("expected extra_config to remain in the spec, got %#v", spec.ExtraConfig)
+			}
+		})
+	}
+}
+
+func TestApplyStagedExtraConfig(t *testing.T) {
+	cases := []struct {
+		name           string
+		powerState     types.VirtualMachinePowerState
+		rebootRequired bool
+		expected       bool
+	}{
+		{
+			name:       "kept while powered on",
+			powerState: types.VirtualMachinePowerStatePoweredOn,
+			expected:   false,
+		},
+		{
+			name:           "applied with a reboot",
+			powerState:     types.VirtualMachinePowerStatePoweredOn,
+			rebootRequired: true,
+			expected:       true,
+		},
+		{
+			name:       "applied while powered off",
+			powerState: types.VirtualMachinePowerStatePoweredOff,
+			expected:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+				"extra_config": map[string]interface{}{
+					"guestinfo.userdata": "foo",
+				},
+				"extra_config_apply_on_reboot": true,
+			})
+			_ = d.Set("extra_config_staged", map[string]interface{}{
+				"guestinfo.userdata": "foo",
+				"guestinfo.metadata": "",
+			})
+			if tc.rebootRequired {
+				_ = d.Set("reboot_required", true)
+			}
+			spec := types.VirtualMachineConfigSpec{
+				ExtraConfig: []types.BaseOptionValue{
+					&types.OptionValue{Key: "guestinfo.metadata", Value: "bar"},
+				},
+			}
+
+			actual := applyStagedExtraConfig(d, &spec, tc.powerState)
+			if tc.expected != actual {
+				t.Fatalf("expected staged extra_config to be applied to be %t, got %t", tc.expected, actual)
+			}
+			if !tc.expected {
+				if len(spec.ExtraConfig) != 1 {
+					t.Fatalf("expected spec to be unchanged, got %#v", spec.ExtraConfig)
+				}
+				if len(d.Get("extra_config_staged").(map[string]interface{})) != 2 {
+					t.Fatalf("expected staged changes to be kept, got %#v", d.Get("extra_config_staged"))
+				}
+				return
+			}
+			values := make(map[string]interface{})
+			for _, v := range spec.ExtraConfig {
+				values[v.GetOptionValue().Key] = v.GetOptionValue().Value
+			}
+			expected := map[string]interface{}{
+				"guestinfo.userdata": "foo",
+				"guestinfo.metadata": "bar",
+			}
+			if !reflect.DeepEqual(expected, values) {
+				t.Fatalf("expected extra config %#v, got %#v", expected, values)
+			}
+			if len(d.Get("extra_config_staged").(map[string]interface{})) != 0 {
+				t.Fatalf("expected staged changes to be cleared, got %#v", d.Get("extra_config_staged"))
+			}
+		})
+	}
+}
+
+func TestFlattenExtraConfigStaged(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+		"extra_config": map[string]interface{}{
+			"guestinfo.userdata": "new",
+			"guestinfo.metadata": "foo",
+		},
+	})
+	_ = d.Set("extra_config_staged", map[string]interface{}{
+		"guestinfo.userdata": "new",
+	})
+	opts := []types.BaseOptionValue{
+		&types.OptionValue{Key: "guestinfo.userdata", Value: "old"},
+		&types.OptionValue{Key: "guestinfo.metadata", Value: "foo"},
+	}
+	if err := flattenExtraConfig(d, opts); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"guestinfo.userdata": "new",
+		"guestinfo.metadata": "foo",
+	}
+	if actual := d.Get("extra_config").(map[string]interface{}); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected extra_config %#v, got %#v", expected, actual)
+	}
+}
+
+// testVirtualMachineResourceDataChange returns the ResourceData of an update
+// of a virtual machine from oldConfig to oldConfig with the keys in newConfig
+// replaced.
+func testVirtualMachineResourceDataChange(t *testing.T, oldConfig, newConfig map[string]interface{}) *schema.ResourceData {
+	sm := schema.InternalMap(resourceVSphereVirtualMachine().Schema)
+	state := schema.TestResourceDataRaw(t, sm, oldConfig)
+	state.SetId("vm-1")
+	config := make(map[string]interface{})
+	for k, v := range oldConfig {
+		config[k] = v
+	}
+	for k, v := range newConfig {
+		config[k] = v
+	}
+	diff, err := sm.Diff(context.Background(), state.State(), terraform.NewResourceConfigRaw(config), nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := sm.Data(state.State(), diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestVirtualMachineConfigSpecHotApplicable(t *testing.T) {
+	cases := []struct {
+		name     string
+		old      map[string]interface{}
+		new      map[string]interface{}
+		expected bool
+	}{
+		{
+			name:     "cpu hot-add",
+			old:      map[string]interface{}{"num_cpus": 2, "cpu_hot_add_enabled": true},
+			new:      map[string]interface{}{"num_cpus": 4},
+			expected: false,
+		},
+		{
+			name:     "cpu add without hot-add",
+			old:      map[string]interface{}{"num_cpus": 2},
+			new:      map[string]interface{}{"num_cpus": 4},
+			expected: true,
+		},
+		{
+			name:     "cpu hot-remove",
+			old:      map[string]interface{}{"num_cpus": 4, "cpu_hot_remove_enabled": true},
+			new:      map[string]interface{}{"num_cpus": 2},
+			expected: false,
+		},
+		{
+			name:     "cpu remove with only hot-add",
+			old:      map[string]interface{}{"num_cpus": 4, "cpu_hot_add_enabled": true},
+			new:      map[string]interface{}{"num_cpus": 2},
+			expected: true,
+		},
+		{
+			name:     "enabling cpu hot-add",
+			old:      map[string]interface{}{"num_cpus": 2},
+			new:      map[string]interface{}{"num_cpus": 4, "cpu_hot_add_enabled": true},
+			expected: true,
+		},
+		{
+			name:     "memory hot-add",
+			old:      map[string]interface{}{"memory": 2048, "memory_hot_add_enabled": true},
+			new:      map[string]interface{}{"memory": 4096},
+			expected: false,
+		},
+		{
+			name:     "memory remove",
+			old:      map[string]interface{}{"memory": 4096, "memory_hot_add_enabled": true},
+			new:      map[string]interface{}{"memory": 2048},
+			expected: true,
+		},
+		{
+			name: "cpu and memory hot-add",
+			old: map[string]interface{}{
+				"num_cpus":               2,
+				"cpu_hot_add_enabled":    true,
+				"memory":                 2048,
+				"memory_hot_add_enabled": true,
+			},
+			new:      map[string]interface{}{"num_cpus": 4, "memory": 4096},
+			expected: false,
+		},
+		{
+			name:     "guestinfo extra_config",
+			old:      map[string]interface{}{"extra_config": map[string]interface{}{"guestinfo.a": "1", "guestinfo.b": "1"}},
+			new:      map[string]interface{}{"extra_config": map[string]interface{}{"guestinfo.a": "2", "guestinfo.c": "1"}},
+			expected: true,
+		},
+		{
+			name: "guestinfo extra_config without reboot",
+			old: map[string]interface{}{
+				"extra_config":                 map[string]interface{}{"guestinfo.a": "1"},
+				"extra_config_reboot_required": false,
+			},
+			new:      map[string]interface{}{"extra_config": map[string]interface{}{"guestinfo.a": "2"}},
+			expected: false,
+		},
+		{
+			name:     "other extra_config",
+			old:      map[string]interface{}{"extra_config": map[string]interface{}{"guestinfo.a": "1"}},
+			new:      map[string]interface{}{"extra_config": map[string]interface{}{"guestinfo.a": "2", "isolation.tools.copy.disable": "TRUE"}},
+			expected: true,
+		},
+		{
+			name:     "removed extra_config",
+			old:      map[string]interface{}{"extra_config": map[string]interface{}{"isolation.tools.copy.disable": "TRUE"}},
+			new:      map[string]interface{}{"extra_config": map[string]interface{}{}},
+			expected: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := testVirtualMachineResourceDataChange(t, tc.old, tc.new)
+			expandCPUCountConfig(d)
+			expandMemorySizeConfig(d)
+			getBoolWithRestart(d, "cpu_hot_add_enabled")
+			expandExtraConfig(d)
+			if actual := d.Get("reboot_required").(bool); actual != tc.expected {
+				t.Fatalf("expected reboot_required to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFlattenScheduledHardwareUpgradeInfo(t *testing.T) {
+	cases := []struct {
+		name           string
+		info           *types.ScheduledHardwareUpgradeInfo
+		expectedStatus string
+		expectedFault  string
+	}{
+		{
+			name:           "no scheduled upgrade",
+			expectedStatus: "none",
+		},
+		{
+			name: "pending",
+			info: &types.ScheduledHardwareUpgradeInfo{
+				UpgradePolicy:                  string(types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyOnSoftPowerOff),
+				VersionKey:                     "vmx-19",
+				ScheduledHardwareUpgradeStatus: string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusPending),
+			},
+			expectedStatus: "pending",
+		},
+		{
+			name: "failed",
+			info: &types.ScheduledHardwareUpgradeInfo{
+				ScheduledHardwareUpgradeStatus: string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusFailed),
+				Fault: &types.LocalizedMethodFault{
+					Fault:            &types.AlreadyUpgraded{},
+					LocalizedMessage: "The virtual machine is already upgraded.",
+				},
+			},
+			expectedStatus: "failed",
+			expectedFault:  "The virtual machine is already upgraded.",
+		},
+		{
+			name: "failed without message",
+			info: &types.ScheduledHardwareUpgradeInfo{
+				ScheduledHardwareUpgradeStatus: string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusFailed),
+				Fault: &types.LocalizedMethodFault{
+					Fault: &types.AlreadyUpgraded{},
+				},
+			},
+			expectedStatus: "failed",
+			expectedFault:  "AlreadyUpgraded",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, schemaVirtualMachineConfigSpec(), map[string]interface{}{})
+			flattenScheduledHardwareUpgradeInfo(d, tc.info)
+			if actual := d.Get("scheduled_hardware_upgrade_status").(string); tc.expectedStatus != actual {
+				t.Fatalf("expected status %q, got %q", tc.expectedStatus, actual)
+			}
+			if actual := d.Get("scheduled_hardware_upgrade_fault").(string); tc.expectedFault != actual {
+				t.Fatalf("expected fault %q, got %q", tc.expectedFault, actual)
+			}
+		})
+	}
+}
+
+func TestExpandVirtualMachineAffinity(t *testing.T) {
+	cases := []struct {
+		name           string
+		config         map[string]interface{}
+		expected       *types.VirtualMachineAffinityInfo
+		rebootRequired bool
+	}{
+		{
+			name:     "unset",
+			config:   map[string]interface{}{},
+			expected: nil,
+		},
+		{
+			name: "set",
+			config: map[string]interface{}{
+				"cpu_affinity": []interface{}{0, 2},
+			},
+			expected:       &types.VirtualMachineAffinityInfo{AffinitySet: []int32{0, 2}},
+			rebootRequired: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, tc.config)
+			actual := expandVirtualMachineAffinity(d, "cpu_affinity")
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, actual)
+			}
+			if tc.rebootRequired != d.Get("reboot_required").(bool) {
+				t.Fatalf("expected reboot_required to be %t, got %t", tc.rebootRequired, d.Get("reboot_required").(bool))
+			}
+
+		})
+	}
+}
+
+func TestExpandVirtualMachineBootOptions(t *testing.T) {
+	client := &govmomi.Client{
+		Client: &vim25.Client{
+			ServiceContent: types.ServiceContent{
+				About: types.AboutInfo{Name: "VMware vCenter Server", Version: "8.0.2", Build: "1"},
+			},
+		},
+	}
+	cases := []struct {
+		name     string
+		config   map[string]interface{}
+		expected types.VirtualMachineBootOptions
+	}{
+		{
+			name: "boot delay",
+			config: map[string]interface{}{
+				"boot_delay": 5000,
+			},
+			expected: types.VirtualMachineBootOptions{
+				BootDelay:      5000,
+				BootRetryDelay: 10000,
+			},
+		},
+		{
+			name: "boot retry",
+			config: map[string]interface{}{
+				"boot_retry_enabled": true,
+				"boot_retry_delay":   30000,
+			},
+			expected: types.VirtualMachineBootOptions{
+				BootRetryEnabled: structure.BoolPtr(true),
+				BootRetryDelay:   30000,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, tc.config)
+			actual := expandVirtualMachineBootOptions(d, client)
+			if tc.expected.BootDelay != actual.BootDelay || tc.expected.BootRetryDelay != actual.BootRetryDelay {
+				t.Fatalf("expected boot delays %d and %d, got %d and %d", tc.expected.BootDelay, tc.expected.BootRetryDelay, actual.BootDelay, actual.BootRetryDelay)
+			}
+			if tc.expected.BootRetryEnabled != nil && (actual.BootRetryEnabled == nil || !*actual.BootRetryEnabled) {
+				t.Fatalf("expected boot retry to be enabled")
+			}
+			if !d.Get("reboot_required").(bool) {
+				t.Fatalf("expected reboot_required to be true")
+			}
+		})
+	}
+}
+
+func TestFlattenVirtualMachineAffinity(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+	if err := flattenVirtualMachineAffinity(d, "memory_affinity", &types.VirtualMachineAffinityInfo{AffinitySet: []int32{1, 3}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{1, 3}
+	if actual := d.Get("memory_affinity").([]interface{}); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %#v, got %#v", expected, actual)
+	}
+
+	if err := flattenVirtualMachineAffinity(d, "memory_affinity", nil); err != nil {
+		t.Fatal(err)
+	}
+	if actual := d.Get("memory_affinity").([]interface{}); len(actual) != 0 {
+		t.Fatalf("expected no memory affinity, got %#v", actual)
+	}
+}
+
+func TestVirtualMachineFlagInfoFaultToleranceType(t *testing.T) {
+	cases := []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{
+			name:     "supported",
+			version:  "8.0.2",
+			expected: string(types.VirtualMachineFaultToleranceTypeCheckpointing),
+		},
+		{
+			name:     "unsupported",
+			version:  "5.5.0",
+			expected: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &govmomi.Client{
+				Client: &vim25.Client{
+					ServiceContent: types.ServiceContent{
+						About: types.AboutInfo{Name: "VMware vCenter Server", Version: tc.version, Build: "1"},
+					},
+				},
+			}
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+				"fault_tolerance_type": string(types.VirtualMachineFaultToleranceTypeCheckpointing),
+			})
+			obj := expandVirtualMachineFlagInfo(d, client)
+			if tc.expected != obj.FaultToleranceType {
+				t.Fatalf("expected fault tolerance type %q, got %q", tc.expected, obj.FaultToleranceType)
+			}
+
+			flattened := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+			if err := flattenVirtualMachineFlagInfo(flattened, obj, client); err != nil {
+				t.Fatal(err)
+			}
+			if actual := flattened.Get("fault_tolerance_type").(string); tc.expected != actual {
+				t.Fatalf("expected flattened fault tolerance type %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVirtualMachineFlagInfoDebugFlags(t *testing.T) {
+	cases := []struct {
+		name         string
+		version      string
+		recordReplay *bool
+	}{
+		{
+			name:         "record and replay supported",
+			version:      "5.5.0",
+			recordReplay: types.NewBool(true),
+		},
+		{
+			name:         "record and replay removed",
+			version:      "8.0.2",
+			recordReplay: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &govmomi.Client{
+				Client: &vim25.Client{
+					ServiceContent: types.ServiceContent{
+						About: types.AboutInfo{Name: "VMware vCenter Server", Version: tc.version, Build: "1"},
+					},
+				},
+			}
+			d := testVirtualMachineResourceDataRaw(t, map[string]interface{}{}, map[string]interface{}{
+				"monitor_type":          string(types.VirtualMachineFlagInfoMonitorTypeDebug),
+				"disable_acceleration":  true,
+				"record_replay_enabled": true,
+			})
+			obj := expandVirtualMachineFlagInfo(d, client)
+			if obj.MonitorType != string(types.VirtualMachineFlagInfoMonitorTypeDebug) {
+				t.Fatalf("expected monitor type %q, got %q", types.VirtualMachineFlagInfoMonitorTypeDebug, obj.MonitorType)
+			}
+			if obj.DisableAcceleration == nil || !*obj.DisableAcceleration {
+				t.Fatalf("expected acceleration to be disabled")
+			}
+			if !reflect.DeepEqual(tc.recordReplay, obj.RecordReplayEnabled) {
+				t.Fatalf("expected record and replay %s, got %s", spew.Sdump(tc.recordReplay), spew.Sdump(obj.RecordReplayEnabled))
+			}
+
+			flattened := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+			if err := flattenVirtualMachineFlagInfo(flattened, obj, client); err != nil {
+				t.Fatal(err)
+			}
+			if actual := flattened.Get("monitor_type").(string); actual != string(types.VirtualMachineFlagInfoMonitorTypeDebug) {
+				t.Fatalf("expected flattened monitor type %q, got %q", types.VirtualMachineFlagInfoMonitorTypeDebug, actual)
+			}
+			if !flattened.Get("disable_acceleration").(bool) {
+				t.Fatalf("expected flattened acceleration to be disabled")
+			}
+			if expected := tc.recordReplay != nil; flattened.Get("record_replay_enabled").(bool) != expected {
+				t.Fatalf("expected flattened record and replay to be %t", expected)
+			}
+		})
+	}
+}
+
+func TestExpandVirtualMachineFlagInfoDisableAcceleration(t *testing.T) {
+	cases := []struct {
+		name     string
+		state    map[string]interface{}
+		config   map[string]interface{}
+		expected *bool
+	}{
+		{
+			name:     "not set",
+			state:    map[string]interface{}{"disable_acceleration": true},
+			config:   map[string]interface{}{},
+			expected: nil,
+		},
+		{
+			name:     "enabled",
+			state:    map[string]interface{}{},
+			config:   map[string]interface{}{"disable_acceleration": true},
+			expected: types.NewBool(true),
+		},
+		{
+			name:     "disabled",
+			state:    map[string]interface{}{"disable_acceleration": true},
+			config:   map[string]interface{}{"disable_acceleration": false},
+			expected: types.NewBool(false),
+		},
+	}
+
+	client := &govmomi.Client{
+		Client: &vim25.Client{
+			ServiceContent: types.ServiceContent{
+				About: types.AboutInfo{Name: "VMware vCenter Server", Version: "8.0.2", Build: "1"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := testVirtualMachineResourceDataRaw(t, tc.state, tc.config)
+			obj := expandVirtualMachineFlagInfo(d, client)
+			if !reflect.DeepEqual(tc.expected, obj.DisableAcceleration) {
+				t.Fatalf("expected disable acceleration %s, got %s", spew.Sdump(tc.expected), spew.Sdump(obj.DisableAcceleration))
+			}
+		})
+	}
+}
+
+func TestValidateRecordReplay(t *testing.T) {
+	cases := []struct {
+		name      string
+		enabled   bool
+		version   viapi.VSphereVersion
+		expectErr bool
+	}{
+		{
+			name:      "disabled",
+			enabled:   false,
+			version:   viapi.VSphereVersion{Product: "VMware vCenter Server", Major: 8},
+			expectErr: false,
+		},
+		{
+			name:      "enabled on supported version",
+			enabled:   true,
+			version:   viapi.VSphereVersion{Product: "VMware vCenter Server", Major: 5, Minor: 5},
+			expectErr: false,
+		},
+		{
+			name:      "enabled on unsupported version",
+			enabled:   true,
+			version:   viapi.VSphereVersion{Product: "VMware vCenter Server", Major: 6, Minor: 5},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRecordReplay(tc.enabled, tc.version)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestExpandManagedByInfo(t *testing.T) {
+	managedBy := []interface{}{
+		map[string]interface{}{
+			"extension_key": "com.example.controller",
+			"type":          "appliance",
+		},
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+	if actual := expandManagedByInfo(d); actual != nil {
+		t.Fatalf("expected no marker when managed_by is unset, got %#v", actual)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+		"managed_by": managedBy,
+	})
+	expected := &types.ManagedByInfo{ExtensionKey: "com.example.controller", Type: "appliance"}
+	if actual := expandManagedByInfo(d); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %#v, got %#v", expected, actual)
+	}
+
+	if actual := expandManagedByInfoBlock(nil); !reflect.DeepEqual(&types.ManagedByInfo{}, actual) {
+		t.Fatalf("expected removed block to clear the marker, got %#v", actual)
+	}
+}
+
+func TestFlattenManagedByInfo(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+	info := &types.ManagedByInfo{ExtensionKey: "com.vmware.vcenter", Type: "system"}
+	if err := flattenManagedByInfo(d, info); err != nil {
+		t.Fatal(err)
+	}
+	if actual := d.Get("managed_by").([]interface{}); len(actual) != 0 {
+		t.Fatalf("expected untracked marker to be ignored, got %#v", actual)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+		"managed_by": []interface{}{
+			map[string]interface{}{
+				"extension_key": "com.example.controller",
+				"type":          "appliance",
+			},
+		},
+	})
+	if err := flattenManagedByInfo(d, info); err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{
+		map[string]interface{}{
+			"extension_key": "com.vmware.vcenter",
+			"type":          "system",
+		},
+	}
+	if actual := d.Get("managed_by").([]interface{}); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %#v, got %#v", expected, actual)
+	}
+}
+
+func TestVAppConfigRemoved(t *testing.T) {
+	vapp := []interface{}{
+		map[string]interface{}{
+			"properties": map[string]interface{}{
+				"guestinfo.hostname": "vm-01",
+			},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		oldValue []interface{}
+		newValue []interface{}
+		enabled  bool
+		expected bool
+	}{
+		{
+			name:     "block removed",
+			oldValue: vapp,
+			newValue: []interface{}{},
+			enabled:  true,
+			expected: true,
+		},
+		{
+			name:     "block removed without opt-in",
+			oldValue: vapp,
+			newValue: []interface{}{},
+			enabled:  false,
+			expected: false,
+		},
+		{
+			name:     "properties emptied",
+			oldValue: vapp,
+			newValue: []interface{}{map[string]interface{}{"properties": map[string]interface{}{}}},
+			enabled:  true,
+			expected: false,
+		},
+		{
+			name:     "block added",
+			oldValue: []interface{}{},
+			newValue: vapp,
+			enabled:  true,
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := vAppConfigRemoved(tc.oldValue, tc.newValue, tc.enabled); tc.expected != actual {
+				t.Fatalf("expected vApp config removal to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpandVAppConfigRemovedUnchanged(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+		"vapp_config_removal_enabled": true,
+	})
+	if actual := expandVAppConfigRemoved(d); actual != nil {
+		t.Fatalf("expected no vApp config removal without a vapp block, got %t", *actual)
+	}
+}
+
+func TestExpandVAppConfigOvfEnvironmentTransport(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+		"vapp": []interface{}{
+			map[string]interface{}{
+				"ovf_environment_transport": []interface{}{"com.vmware.guestInfo"},
+			},
+		},
+	})
+	spec, err := expandVAppConfig(d, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec == nil || !reflect.DeepEqual(spec.OvfEnvironmentTransport, []string{"com.vmware.guestInfo"}) {
+		t.Fatalf("expected OVF environment transport to be set, got %s", spew.Sdump(spec))
+	}
+	if !d.Get("reboot_required").(bool) {
+		t.Fatalf("expected reboot to be required")
+	}
+}
+
+func TestExpandVAppOvfEnvironmentTransport(t *testing.T) {
+	cases := []struct {
+		name     string
+		old      []interface{}
+		new      []interface{}
+		expected []string
+	}{
+		{
+			name:     "changed",
+			old:      []interface{}{"iso"},
+			new:      []interface{}{"com.vmware.guestInfo"},
+			expected: []string{"com.vmware.guestInfo"},
+		},
+		{
+			name: "unchanged",
+			old:  []interface{}{"iso"},
+			new:  []interface{}{"iso"},
+		},
+		{
+			name: "removed",
+			old:  []interface{}{"iso"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vapp := func(transport []interface{}) map[string]interface{} {
+				return map[string]interface{}{
+					"vapp": []interface{}{
+						map[string]interface{}{"ovf_environment_transport": transport},
+					},
+				}
+			}
+			d := testVirtualMachineResourceDataChange(t, vapp(tc.old), vapp(tc.new))
+			actual := expandVAppOvfEnvironmentTransport(d)
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected OVF environment transport %v, got %v", tc.expected, actual)
+			}
+			if expected := tc.expected != nil; d.Get("reboot_required").(bool) != expected {
+				t.Fatalf("expected reboot_required to be %t", expected)
+			}
+		})
+	}
+}
+
+func TestFlattenVAppConfigOvfEnvironmentTransport(t *testing.T) {
+	cases := []struct {
+		name       string
+		configured []interface{}
+		expected   []interface{}
+	}{
+		{
+			name:       "configured",
+			configured: []interface{}{"iso"},
+			expected:   []interface{}{"com.vmware.guestInfo"},
+		},
+		{
+			name: "not configured",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := map[string]interface{}{}
+			if tc.configured != nil {
+				raw["vapp"] = []interface{}{
+					map[string]interface{}{
+						"ovf_environment_transport": tc.configured,
+					},
+				}
+			}
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, raw)
+			config := &types.VmConfigInfo{
+				OvfEnvironmentTransport: []string{"com.vmware.guestInfo"},
+			}
+			if err := flattenVAppConfig(d, config); err != nil {
+				t.Fatal(err)
+			}
+			actual, _ := d.Get("vapp.0.ovf_environment_transport").([]interface{})
+			if len(tc.expected) != len(actual) || (len(actual) > 0 && !reflect.DeepEqual(tc.expected, actual)) {
+				t.Fatalf("expected ovf_environment_transport %v, got %v", tc.expected, actual)
+			}
+			if transport := d.Get("vapp_transport").([]interface{}); len(transport) != 1 {
+				t.Fatalf("expected vapp_transport to be set, got %v", transport)
+			}
+		})
+	}
+}
+
+func TestFlattenVAppConfigHiddenProperties(t *testing.T) {
+	configurable := true
+	notConfigurable := false
+	config := &types.VmConfigInfo{
+		Property: []types.VAppPropertyInfo{
+			{Key: 1, Id: "hostname", Value: "vm-01", UserConfigurable: &configurable},
+			{Key: 2, Id: "internal", Value: "foo", UserConfigurable: &notConfigurable},
+			{Key: 3, Id: "unset", Value: "bar"},
+			{Key: 4, Id: "unmanaged", Value: "baz", UserConfigurable: &notConfigurable},
+		},
+	}
+
+	cases := []struct {
+		name                   string
+		enableHiddenProperties bool
+		expected               map[string]interface{}
+	}{
+		{
+			name:                   "hidden properties enabled",
+			enableHiddenProperties: true,
+			expected: map[string]interface{}{
+				"hostname": "vm-01",
+				"internal": "foo",
+				"unset":    "bar",
+			},
+		},
+		{
+			name: "hidden properties disabled",
+			expected: map[string]interface{}{
+				"hostname": "vm-01",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+				"ovf_deploy": []interface{}{
+					map[string]interface{}{
+						"remote_ovf_url":           "https://example.com/template.ova",
+						"enable_hidden_properties": tc.enableHiddenProperties,
+					},
+				},
+				"vapp": []interface{}{
+					map[string]interface{}{
+						"properties": map[string]interface{}{
+							"hostname": "vm-01",
+							"internal": "foo",
+							"unset":    "bar",
+						},
+					},
+				},
+			})
+			if err := flattenVAppConfig(d, config); err != nil {
+				t.Fatal(err)
+			}
+			actual := d.Get("vapp.0.properties").(map[string]interface{})
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected vApp properties %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFlattenVAppConfigNilUserConfigurable(t *testing.T) {
+	configurable := true
+	config := &types.VmConfigInfo{
+		Property: []types.VAppPropertyInfo{
+			{Key: 1, Id: "hostname", Value: "vm-01", UserConfigurable: &configurable},
+			{Key: 2, Id: "unset", Value: "bar"},
+		},
+	}
+	expected := map[string]interface{}{"hostname": "vm-01"}
+
+	schemas := map[string]map[string]*schema.Schema{
+		"resource":    resourceVSphereVirtualMachine().Schema,
+		"data source": dataSourceVSphereVirtualMachine().Schema,
+	}
+	for name, s := range schemas {
+		t.Run(name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, s, map[string]interface{}{})
+			if err := flattenVAppConfig(d, config); err != nil {
+				t.Fatal(err)
+			}
+			actual := d.Get("vapp.0.properties").(map[string]interface{})
+			if !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("expected vApp properties %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateDeviceHardwareVersion(t *testing.T) {
+	cases := []struct {
+		key                string
+		minHardwareVersion int
+	}{
+		{key: "nvdimm", minHardwareVersion: virtualMachineNVDIMMMinHardwareVersion},
+		{key: "watchdog_timer", minHardwareVersion: virtualMachineWatchdogTimerMinHardwareVersion},
+		{key: "precision_clock", minHardwareVersion: virtualMachinePrecisionClockMinHardwareVersion},
+	}
+	checks := []struct {
+		name            string
+		count           int
+		hardwareVersion func(minHardwareVersion int) int
+		expectErr       bool
+	}{
+		{
+			name:            "no devices on old hardware version",
+			count:           0,
+			hardwareVersion: func(minVersion int) int { return minVersion - 1 },
+			expectErr:       false,
+		},
+		{
+			name:            "devices below minimum hardware version",
+			count:           1,
+			hardwareVersion: func(minVersion int) int { return minVersion - 1 },
+			expectErr:       true,
+		},
+		{
+			name:            "devices on minimum hardware version",
+			count:           1,
+			hardwareVersion: func(minVersion int) int { return minVersion },
+			expectErr:       false,
+		},
+		{
+			name:            "devices with unknown hardware version",
+			count:           2,
+			hardwareVersion: func(int) int { return 0 },
+			expectErr:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		for _, check := range checks {
+			t.Run(tc.key+"/"+check.name, func(t *testing.T) {
+				err := validateDeviceHardwareVersion(tc.key, check.count, check.hardwareVersion(tc.minHardwareVersion), tc.minHardwareVersion)
+				if check.expectErr != (err != nil) {
+					t.Fatalf("expected error to be %t, got %v", check.expectErr, err)
+				}
+			})
+		}
+	}
+}
+
+func TestValidateVirtualMachineFirmware(t *testing.T) {
+	cases := []struct {
+		name       string
+		firmware   string
+		secureBoot bool
+		guestID    string
+		expectErr  bool
+	}{
+		{
+			name:     "bios with bios guest",
+			firmware: "bios",
+			guestID:  "otherGuest64",
+		},
+		{
+			name:       "bios with secure boot",
+			firmware:   "bios",
+			secureBoot: true,
+			guestID:    "otherGuest64",
+			expectErr:  true,
+		},
+		{
+			name:      "bios with efi-only guest",
+			firmware:  "bios",
+			guestID:   "windows11_64Guest",
+			expectErr: true,
+		},
+		{
+			name:     "efi with bios guest",
+			firmware: "efi",
+			guestID:  "otherGuest64",
+		},
+		{
+			name:       "efi with secure boot",
+			firmware:   "efi",
+			secureBoot: true,
+			guestID:    "otherGuest64",
+		},
+		{
+			name:       "efi with secure boot and efi-only guest",
+			firmware:   "efi",
+			secureBoot: true,
+			guestID:    "windows12_64Guest",
+		},
+		{
+			name:     "bios with windows server guest",
+			firmware: "bios",
+			guestID:  "windows2019srvNext_64Guest",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateVirtualMachineFirmware(tc.firmware, tc.secureBoot, tc.guestID)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateMemoryReservationLockedToMax(t *testing.T) {
+	cases := []struct {
+		name        string
+		locked      bool
+		memory      int
+		reservation int
+		expectErr   bool
+	}{
+		{
+			name:        "not locked with lower reservation",
+			memory:      2048,
+			reservation: 1024,
+		},
+		{
+			name:        "locked with lower reservation",
+			locked:      true,
+			memory:      2048,
+			reservation: 1024,
+			expectErr:   true,
+		},
+		{
+			name:        "locked with equal reservation",
+			locked:      true,
+			memory:      2048,
+			reservation: 2048,
+		},
+		{
+			name:   "locked with unset reservation",
+			locked: true,
+			memory: 2048,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateMemoryReservationLockedToMax(tc.locked, tc.memory, tc.reservation)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestResourceVSphereVirtualMachineCustomizeDiffMemory(t *testing.T) {
+	state := map[string]interface{}{
+		"memory":                           2048,
+		"memory_reservation":               2048,
+		"memory_reservation_locked_to_max": true,
+	}
+	cases := []struct {
+		name      string
+		config    map[string]interface{}
+		expectErr bool
+	}{
+		{
+			name: "memory increased with locked reservation",
+			config: map[string]interface{}{
+				"memory":                           4096,
+				"memory_reservation_locked_to_max": true,
+			},
+		},
+		{
+			name: "memory increased with configured equal reservation",
+			config: map[string]interface{}{
+				"memory":                           4096,
+				"memory_reservation":               4096,
+				"memory_reservation_locked_to_max": true,
+			},
+		},
+		{
+			name: "memory increased with configured lower reservation",
+			config: map[string]interface{}{
+				"memory":                           4096,
+				"memory_reservation":               2048,
+				"memory_reservation_locked_to_max": true,
+			},
+			expectErr: true,
+		},
+		{
+			name: "memory decreased with locked reservation",
+			config: map[string]interface{}{
+				"memory":                           1024,
+				"memory_reservation_locked_to_max": true,
+			},
+		},
+		{
+			name: "memory decreased with unlocked reservation",
+			config: map[string]interface{}{
+				"memory": 1024,
+			},
+		},
+		{
+			name: "memory decreased below configured reservation",
+			config: map[string]interface{}{
+				"memory":             1024,
+				"memory_reservation": 2048,
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := testVirtualMachineCustomizeDiff(t, state, tc.config, func(d *schema.ResourceDiff) error {
+				return resourceVSphereVirtualMachineCustomizeDiffMemory(d, nil)
+			})
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestResourceVSphereVirtualMachineCustomizeDiffPendingCustomization(t *testing.T) {
+	cases := []struct {
+		name     string
+		clear    bool
+		pending  string
+		expected bool
+	}{
+		{
+			name:     "clear pending customization",
+			clear:    true,
+			pending:  "[datastore1] vm/imcf-abc123",
+			expected: true,
+		},
+		{
+			name:    "keep pending customization",
+			pending: "[datastore1] vm/imcf-abc123",
+		},
+		{
+			name:  "nothing pending",
+			clear: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := map[string]interface{}{
+				"clear_pending_customization": tc.clear,
+				"pending_customization":       tc.pending,
+			}
+			config := map[string]interface{}{
+				"clear_pending_customization": tc.clear,
+			}
+			err := testVirtualMachineCustomizeDiff(t, state, config, func(d *schema.ResourceDiff) error {
+				if err := resourceVSphereVirtualMachineCustomizeDiffPendingCustomization(d); err != nil {
+					return err
+				}
+				if d.HasChange("pending_customization") != tc.expected {
+					t.Fatalf("expected a change of pending_customization to be %t", tc.expected)
+				}
+				if tc.expected && d.Get("pending_customization").(string) != "" {
+					t.Fatalf("expected pending_customization to be planned empty, got %q", d.Get("pending_customization"))
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// testVirtualMachineCustomizeDiff runs f as the CustomizeDiff of an update of
+// a virtual machine from state to config, and returns its error. Only the
+// top-level keys in config are set in the raw configuration.
+func testVirtualMachineCustomizeDiff(t *testing.T, state, config map[string]interface{}, f func(*schema.ResourceDiff) error) error {
+	sm := schema.InternalMap(resourceVSphereVirtualMachine().Schema)
+	d := schema.TestResourceDataRaw(t, sm, state)
+	d.SetId("vm-1")
+	s := d.State()
+	s.RawConfig = testVirtualMachineRawConfig(config)
+
+	_, err := sm.Diff(context.Background(), s, terraform.NewResourceConfigRaw(config), func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+		return f(d)
+	}, nil, true)
+	return err
+}
+
+// testVirtualMachineRawConfig returns the raw configuration of a virtual
+// machine with the top-level keys in config set. Only integer, bool and string
+// values are supported.
+func testVirtualMachineRawConfig(config map[string]interface{}) cty.Value {
+	attrs := make(map[string]cty.Value)
+	for k, ty := range resourceVSphereVirtualMachine().CoreConfigSchema().ImpliedType().AttributeTypes() {
+		switch v := config[k].(type) {
+		case int:
+			attrs[k] = cty.NumberIntVal(int64(v))
+		case bool:
+			attrs[k] = cty.BoolVal(v)
+		case string:
+			attrs[k] = cty.StringVal(v)
+		default:
+			attrs[k] = cty.NullVal(ty)
+		}
+	}
+	return cty.ObjectVal(attrs)
+}
+
+// testVirtualMachineResourceDataRaw returns the ResourceData of an update of
+// a virtual machine from state to config, with the top-level keys in config
+// set in the raw configuration.
+func testVirtualMachineResourceDataRaw(t *testing.T, state, config map[string]interface{}) *schema.ResourceData {
+	sm := schema.InternalMap(resourceVSphereVirtualMachine().Schema)
+	s := schema.TestResourceDataRaw(t, sm, state)
+	s.SetId("vm-1")
+	diff, err := sm.Diff(context.Background(), s.State(), terraform.NewResourceConfigRaw(config), nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff == nil {
+		diff = new(terraform.InstanceDiff)
+	}
+	diff.RawConfig = testVirtualMachineRawConfig(config)
+	d, err := sm.Data(s.State(), diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestValidateCPUTopology(t *testing.T) {
+	cases := []struct {
+		name           string
+		numCPUs        int
+		coresPerSocket int
+		expectErr      bool
+	}{
+		{
+			name:           "single socket",
+			numCPUs:        4,
+			coresPerSocket: 4,
+		},
+		{
+			name:           "multiple sockets",
+			numCPUs:        8,
+			coresPerSocket: 4,
+		},
+		{
+			name:           "decreased below cores per socket",
+			numCPUs:        2,
+			coresPerSocket: 4,
+			expectErr:      true,
+		},
+		{
+			name:           "not evenly divisible",
+			numCPUs:        6,
+			coresPerSocket: 4,
+			expectErr:      true,
+		},
+		{
+			name:           "cores per socket not set",
+			numCPUs:        3,
+			coresPerSocket: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCPUTopology(tc.numCPUs, tc.coresPerSocket)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateMemorySize(t *testing.T) {
+	cases := []struct {
+		name        string
+		memory      int
+		reservation int
+		expectErr   bool
+	}{
+		{
+			name:   "no reservation",
+			memory: 2048,
+		},
+		{
+			name:        "lower reservation",
+			memory:      2048,
+			reservation: 1024,
+		},
+		{
+			name:        "equal reservation",
+			memory:      2048,
+			reservation: 2048,
+		},
+		{
+			name:        "memory below reservation",
+			memory:      1024,
+			reservation: 2048,
+			expectErr:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateMemorySize(tc.memory, tc.reservation)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateMemoryHotAdd(t *testing.T) {
+	cases := []struct {
+		name      string
+		oldMemory int
+		newMemory int
+		limit     int64
+		increment int64
+		expectErr bool
+	}{
+		{
+			name:      "no limits reported",
+			oldMemory: 2048,
+			newMemory: 4096,
+		},
+		{
+			name:      "within limit",
+			oldMemory: 2048,
+			newMemory: 4096,
+			limit:     4096,
+			increment: 128,
+		},
+		{
+			name:      "above limit",
+			oldMemory: 2048,
+			newMemory: 8192,
+			limit:     4096,
+			expectErr: true,
+		},
+		{
+			name:      "not a multiple of the increment",
+			oldMemory: 2048,
+			newMemory: 2100,
+			increment: 128,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateMemoryHotAdd(tc.oldMemory, tc.newMemory, tc.limit, tc.increment)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestGetMemoryReservationLockedToMax(t *testing.T) {
+	cases := []struct {
+		name     string
+		raw      map[string]interface{}
+		expected *bool
+	}{
+		{
+			name: "locked with unset reservation",
+			raw: map[string]interface{}{
+				"memory":                           2048,
+				"memory_reservation_locked_to_max": true,
+			},
+			expected: structure.BoolPtr(true),
+		},
+		{
+			name: "locked with equal reservation",
+			raw: map[string]interface{}{
+				"memory":                           2048,
+				"memory_reservation":               2048,
+				"memory_reservation_locked_to_max": true,
+			},
+			expected: structure.BoolPtr(true),
+		},
+		{
+			name: "not locked with lower reservation",
+			raw: map[string]interface{}{
+				"memory":             2048,
+				"memory_reservation": 1024,
+			},
+			expected: structure.BoolPtr(false),
+		},
+		{
+			name: "not locked with equal reservation",
+			raw: map[string]interface{}{
+				"memory":             2048,
+				"memory_reservation": 2048,
+			},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, tc.raw)
+			actual := getMemoryReservationLockedToMax(d)
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %s, got %s", spew.Sdump(tc.expected), spew.Sdump(actual))
+			}
+		})
+	}
+}
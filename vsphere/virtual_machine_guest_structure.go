@@ -5,15 +5,102 @@
 package vsphere
 
 import (
+	"fmt"
 	"log"
 	"net"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+const (
+	guestIPVersionIPv4 = "ipv4"
+	guestIPVersionIPv6 = "ipv6"
+)
+
+const (
+	guestConnectionTypeSSH   = "ssh"
+	guestConnectionTypeWinRM = "winrm"
+)
+
+// guestIPSelectionOptions controls how buildAndSelectGuestIPs selects the
+// primary IP address of a virtual machine.
+type guestIPSelectionOptions struct {
+	// The MAC address of the network interface to select the primary IP address
+	// from. All interfaces are considered if empty.
+	PrimaryMAC string
+	// The network, in CIDR notation, to select the primary IP address from. All
+	// networks are considered if empty.
+	PrimaryCIDR string
+	// Prefer IPv6 addresses over IPv4 addresses.
+	PreferIPv6 bool
+	// Include link-local and loopback addresses.
+	IncludeLinkLocal bool
+	// The connection type set in the connection info for provisioners. SSH is
+	// used if empty.
+	ConnectionType string
+}
+
+// expandGuestIPSelectionOptions reads the primary IP address selection options
+// from ResourceData.
+func expandGuestIPSelectionOptions(d *schema.ResourceData) guestIPSelectionOptions {
+	return guestIPSelectionOptions{
+		PrimaryMAC:       d.Get("primary_network_mac").(string),
+		PrimaryCIDR:      d.Get("primary_ip_cidr").(string),
+		PreferIPv6:       d.Get("ip_version_preference").(string) == guestIPVersionIPv6,
+		IncludeLinkLocal: d.Get("include_link_local_guest_ips").(bool),
+		ConnectionType:   guestConnectionType(d.Get("guest_connection_type").(string), d.Get("guest_id").(string)),
+	}
+}
+
+// guestConnectionType returns the connection type to use for provisioners. If
+// no connection type is configured, WinRM is used for Windows guests and SSH
+// for all others.
+func guestConnectionType(connectionType, guestID string) string {
+	if connectionType != "" {
+		return connectionType
+	}
+	if strings.HasPrefix(strings.ToLower(guestID), "win") {
+		return guestConnectionTypeWinRM
+	}
+	return guestConnectionTypeSSH
+}
+
+// schemaVirtualMachineGuestNetworkInterfaces returns the schema for the
+// network interfaces reported by VMware Tools, along with their IP addresses.
+func schemaVirtualMachineGuestNetworkInterfaces() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "The network interfaces of this virtual machine as reported by VMware Tools, sorted by device key, with their IP addresses.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"mac_address": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The MAC address of the network interface.",
+				},
+				"device_config_id": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "The device key of the network interface in the virtual machine configuration.",
+				},
+				"ip_addresses": {
+					Type:        schema.TypeList,
+					Computed:    true,
+					Description: "The IP addresses of the network interface.",
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
 // schemaVirtualMachineGuestInfo returns schema items for the relevant parts of
 // GuestInfo that vsphere_virtual_machine tracks (mostly guest information).
 func schemaVirtualMachineGuestInfo() map[string]*schema.Schema {
@@ -29,7 +116,215 @@ func schemaVirtualMachineGuestInfo() map[string]*schema.Schema {
 			Description: "The current list of IP addresses on this virtual machine.",
 			Elem:        &schema.Schema{Type: schema.TypeString},
 		},
+		"guest_network_interfaces": schemaVirtualMachineGuestNetworkInterfaces(),
+		"guest_dns_servers": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The DNS servers reported by VMware Tools for the guest, merged across all IP stacks.",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"guest_dns_search_domains": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The DNS search domains reported by VMware Tools for the guest, merged across all IP stacks.",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"guest_hostname": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The hostname reported by VMware Tools for the guest.",
+		},
+		"guest_domain": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The DNS domain name reported by VMware Tools for the guest.",
+		},
+		"primary_network_mac": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "The MAC address of the network interface to select default_ip_address from. When not set, addresses on all network interfaces are considered.",
+			ValidateFunc: validation.IsMACAddress,
+		},
+		"primary_ip_cidr": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "The network, in CIDR notation, to select default_ip_address from. When not set, addresses in all networks are considered.",
+			ValidateFunc: validation.IsCIDR,
+		},
+		"ip_version_preference": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      guestIPVersionIPv4,
+			Description:  "The IP version to prefer when selecting default_ip_address. One of ipv4 or ipv6.",
+			ValidateFunc: validation.StringInSlice([]string{guestIPVersionIPv4, guestIPVersionIPv6}, false),
+		},
+		"guest_connection_type": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "The connection type used by provisioners to connect to default_ip_address. One of ssh or winrm. When not set, winrm is used for Windows guests and ssh for all others.",
+			ValidateFunc: validation.StringInSlice([]string{guestConnectionTypeSSH, guestConnectionTypeWinRM}, false),
+		},
+		"include_link_local_guest_ips": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Include link-local and loopback addresses in guest_ip_addresses and the selection of default_ip_address.",
+		},
+		"tools_status":         schemaVirtualMachineToolsStatus(),
+		"tools_running_status": schemaVirtualMachineToolsRunningStatus(),
+		"tools_version_status": schemaVirtualMachineToolsVersionStatus(),
+		"guest_state":          schemaVirtualMachineGuestState(),
+	}
+}
+
+// schemaVirtualMachineToolsStatus returns the schema for the status of VMware
+// Tools in the guest.
+func schemaVirtualMachineToolsStatus() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The status of VMware Tools in the guest. One of toolsOk, toolsOld, toolsNotRunning, or toolsNotInstalled.",
+	}
+}
+
+// schemaVirtualMachineToolsRunningStatus returns the schema for the running
+// status of VMware Tools in the guest.
+func schemaVirtualMachineToolsRunningStatus() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The running status of VMware Tools in the guest, such as guestToolsRunning or guestToolsNotRunning. Empty if VMware Tools is not installed.",
+	}
+}
+
+// schemaVirtualMachineToolsVersionStatus returns the schema for the version
+// status of VMware Tools in the guest.
+func schemaVirtualMachineToolsVersionStatus() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The version status of VMware Tools in the guest, such as guestToolsCurrent or guestToolsNeedUpgrade. Empty if VMware Tools is not installed.",
+	}
+}
+
+// schemaVirtualMachineGuestState returns the schema for the operation mode of
+// the guest operating system.
+func schemaVirtualMachineGuestState() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The operation mode of the guest operating system, such as running or notRunning.",
+	}
+}
+
+// schemaVirtualMachinePowerState returns the schema for the power state of the
+// virtual machine.
+func schemaVirtualMachinePowerState() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The power state of the virtual machine. One of on, off, or suspended.",
+	}
+}
+
+// flattenVirtualMachinePowerState saves the power state of the virtual machine
+// to ResourceData as on, off, or suspended. An unknown power state, such as
+// the state of a virtual machine that is not connected, does not change the
+// saved value.
+func flattenVirtualMachinePowerState(d *schema.ResourceData, state types.VirtualMachinePowerState) {
+	switch state {
+	case types.VirtualMachinePowerStatePoweredOn:
+		_ = d.Set("power_state", "on")
+	case types.VirtualMachinePowerStatePoweredOff:
+		_ = d.Set("power_state", "off")
+	case types.VirtualMachinePowerStateSuspended:
+		_ = d.Set("power_state", "suspended")
+	}
+}
+
+// flattenGuestReadiness saves the status of VMware Tools and the state of the
+// guest operating system to ResourceData.
+func flattenGuestReadiness(d *schema.ResourceData, guest types.GuestInfo) error {
+	if err := d.Set("tools_status", guestToolsStatus(guest)); err != nil {
+		return err
+	}
+	runningStatus, versionStatus := guestToolsRunningAndVersionStatus(guest)
+	if err := d.Set("tools_running_status", runningStatus); err != nil {
+		return err
+	}
+	if err := d.Set("tools_version_status", versionStatus); err != nil {
+		return err
+	}
+	return d.Set("guest_state", guestState(guest))
+}
+
+// guestToolsStatus returns the status of VMware Tools in the guest. The
+// deprecated ToolsStatus property is used when it is reported, otherwise the
+// status is derived from the running and version status of VMware Tools.
+func guestToolsStatus(guest types.GuestInfo) string {
+	if guest.ToolsStatus != "" {
+		return string(guest.ToolsStatus)
+	}
+	switch {
+	case guest.ToolsVersionStatus2 == string(types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled):
+		return string(types.VirtualMachineToolsStatusToolsNotInstalled)
+	case guest.ToolsRunningStatus != string(types.VirtualMachineToolsRunningStatusGuestToolsRunning):
+		return string(types.VirtualMachineToolsStatusToolsNotRunning)
+	}
+	switch types.VirtualMachineToolsVersionStatus(guest.ToolsVersionStatus2) {
+	case types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade,
+		types.VirtualMachineToolsVersionStatusGuestToolsSupportedOld,
+		types.VirtualMachineToolsVersionStatusGuestToolsTooOld:
+		return string(types.VirtualMachineToolsStatusToolsOld)
+	}
+	return string(types.VirtualMachineToolsStatusToolsOk)
+}
+
+// toolsUpgradeNeeded returns true if the virtual machine is powered on, and
+// VMware Tools are installed and running, but need an upgrade.
+func toolsUpgradeNeeded(props *mo.VirtualMachine) bool {
+	if props.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn || props.Guest == nil {
+		return false
+	}
+	runningStatus, versionStatus := guestToolsRunningAndVersionStatus(*props.Guest)
+	return runningStatus == string(types.VirtualMachineToolsRunningStatusGuestToolsRunning) && toolsVersionNeedsUpgrade(versionStatus)
+}
+
+// toolsVersionNeedsUpgrade returns true if the version status of VMware Tools
+// asks for an upgrade. An older version that is still supported is left as
+// is, as an upgrade does not necessarily make it current.
+func toolsVersionNeedsUpgrade(versionStatus string) bool {
+	switch types.VirtualMachineToolsVersionStatus(versionStatus) {
+	case types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade,
+		types.VirtualMachineToolsVersionStatusGuestToolsTooOld:
+		return true
 	}
+	return false
+}
+
+// guestToolsRunningAndVersionStatus returns the running and version status of
+// VMware Tools in the guest, as reported by vSphere. The deprecated
+// ToolsVersionStatus property is used when ToolsVersionStatus2 is not
+// reported. Both are empty if VMware Tools is not installed.
+func guestToolsRunningAndVersionStatus(guest types.GuestInfo) (string, string) {
+	versionStatus := guest.ToolsVersionStatus2
+	if versionStatus == "" {
+		versionStatus = guest.ToolsVersionStatus
+	}
+	if versionStatus == string(types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled) {
+		return "", ""
+	}
+	return guest.ToolsRunningStatus, versionStatus
+}
+
+// guestState returns the operation mode of the guest operating system.
+// notRunning is returned when the state is not reported, such as when the
+// virtual machine is powered off.
+func guestState(guest types.GuestInfo) string {
+	if guest.GuestState == "" {
+		return string(types.VirtualMachineGuestStateNotRunning)
+	}
+	return guest.GuestState
 }
 
 // buildAndSelectGuestIPs builds a list of IP addresses known to VMware Tools.
@@ -38,11 +333,27 @@ func schemaVirtualMachineGuestInfo() map[string]*schema.Schema {
 // satisfied - and sets that as the default_ip_address and also the IP address
 // used for provisioning. The full list of IP addresses is saved to
 // guest_ip_addresses.
-func buildAndSelectGuestIPs(d *schema.ResourceData, guest types.GuestInfo) error {
+//
+// If a primary MAC address or network is set in opts, only addresses on the
+// network interface with that MAC address, or within that network, are
+// considered for the primary IP address. If no address matches,
+// default_ip_address is left empty. If IPv6 is preferred, IPv6 addresses are
+// selected before IPv4 addresses. Link-local and loopback addresses are
+// skipped unless they are included in opts.
+func buildAndSelectGuestIPs(d *schema.ResourceData, guest types.GuestInfo, opts guestIPSelectionOptions) error {
 	log.Printf("[DEBUG] %s: Checking guest networking state", resourceVSphereVirtualMachineIDString(d))
-	var v4primary, v6primary, v4gw, v6gw net.IP
+	var v4primary, v6primary, v4eligible, v6eligible, v4gw, v6gw net.IP
+	var primaryNet *net.IPNet
+	if opts.PrimaryCIDR != "" {
+		var err error
+		if _, primaryNet, err = net.ParseCIDR(opts.PrimaryCIDR); err != nil {
+			return fmt.Errorf("error parsing primary IP CIDR %q: %s", opts.PrimaryCIDR, err)
+		}
+	}
+	filtered := opts.PrimaryMAC != "" || primaryNet != nil
 	var v4net2addrs, v6net2addrs map[string][]string
 	var deviceMacAddresses []string
+	nics := make([]interface{}, 0)
 
 	// Fetch gateways first.
 	for _, s := range guest.IpStack {
@@ -75,15 +386,32 @@ func buildAndSelectGuestIPs(d *schema.ResourceData, guest types.GuestInfo) error
 			v6net2addrs[n.MacAddress] = make([]string, 0)
 			for _, addr := range n.IpConfig.IpAddress {
 				ip := net.ParseIP(addr.IpAddress)
+				if !opts.IncludeLinkLocal && isLinkLocalOrLoopbackIP(ip) {
+					continue
+				}
+				eligible := (opts.PrimaryMAC == "" || strings.EqualFold(n.MacAddress, opts.PrimaryMAC)) &&
+					(primaryNet == nil || (ip != nil && primaryNet.Contains(ip)))
 				var mask net.IPMask
 				if ip.To4() != nil {
 					v4net2addrs[n.MacAddress] = append(v4net2addrs[n.MacAddress], addr.IpAddress)
+					if !eligible {
+						continue
+					}
+					if v4eligible == nil {
+						v4eligible = ip
+					}
 					mask = net.CIDRMask(int(addr.PrefixLength), 32)
 					if v4gw != nil && ip.Mask(mask).Equal(v4gw.Mask(mask)) && v4primary == nil {
 						v4primary = ip
 					}
 				} else {
 					v6net2addrs[n.MacAddress] = append(v6net2addrs[n.MacAddress], addr.IpAddress)
+					if !eligible {
+						continue
+					}
+					if v6eligible == nil && ip != nil {
+						v6eligible = ip
+					}
 					mask = net.CIDRMask(int(addr.PrefixLength), 128)
 					if v6gw != nil && ip.Mask(mask).Equal(v6gw.Mask(mask)) && v6primary == nil {
 						v6primary = ip
@@ -98,10 +426,27 @@ func buildAndSelectGuestIPs(d *schema.ResourceData, guest types.GuestInfo) error
 		addrs = append(addrs, v6net2addrs[deviceMacAddress]...)
 	}
 
+	// Keep the association of the addresses with their network interface.
+	for _, n := range guest.Net {
+		nicAddrs := append(append([]string{}, v4net2addrs[n.MacAddress]...), v6net2addrs[n.MacAddress]...)
+		nics = append(nics, map[string]interface{}{
+			"mac_address":      n.MacAddress,
+			"device_config_id": int(n.DeviceConfigId),
+			"ip_addresses":     nicAddrs,
+		})
+	}
+	if err := d.Set("guest_network_interfaces", nics); err != nil {
+		return err
+	}
+	if err := flattenGuestDNSConfig(d, guest); err != nil {
+		return err
+	}
+
 	// Fall back to the IpAddress property in GuestInfo directly when the
 	// IpStack and Net properties are not populated. This generally means that
-	// an older version of VMTools is in use.
-	if len(addrs) < 1 && guest.IpAddress != "" {
+	// an older version of VMTools is in use. Link-local and loopback addresses
+	// are filtered here as well.
+	if len(addrs) < 1 && guest.IpAddress != "" && (opts.IncludeLinkLocal || !isLinkLocalOrLoopbackIP(net.ParseIP(guest.IpAddress))) {
 		addrs = append(addrs, guest.IpAddress)
 	}
 
@@ -112,13 +457,25 @@ func buildAndSelectGuestIPs(d *schema.ResourceData, guest types.GuestInfo) error
 		log.Printf("[DEBUG] %s: No IP addresses found in guest state", resourceVSphereVirtualMachineIDString(d))
 		return d.Set("guest_ip_addresses", addrs)
 	}
+	preferred, fallback := v4primary, v6primary
+	preferredEligible, fallbackEligible := v4eligible, v6eligible
+	if opts.PreferIPv6 {
+		preferred, fallback = v6primary, v4primary
+		preferredEligible, fallbackEligible = v6eligible, v4eligible
+	}
 	var primary string
 	switch {
-	case v4primary != nil:
-		primary = v4primary.String()
-	case v6primary != nil:
-		primary = v6primary.String()
-	default:
+	case preferred != nil:
+		primary = preferred.String()
+	case fallback != nil:
+		primary = fallback.String()
+	case !filtered && !opts.PreferIPv6:
+		primary = addrs[0]
+	case preferredEligible != nil:
+		primary = preferredEligible.String()
+	case fallbackEligible != nil:
+		primary = fallbackEligible.String()
+	case !filtered:
 		primary = addrs[0]
 	}
 	log.Printf("[DEBUG] %s: Primary IP address: %s", resourceVSphereVirtualMachineIDString(d), primary)
@@ -127,10 +484,68 @@ func buildAndSelectGuestIPs(d *schema.ResourceData, guest types.GuestInfo) error
 	if err := d.Set("guest_ip_addresses", addrs); err != nil {
 		return err
 	}
+	if primary == "" {
+		log.Printf("[WARN] %s: No IP address matches the primary network MAC address %q or IP CIDR %q", resourceVSphereVirtualMachineIDString(d), opts.PrimaryMAC, opts.PrimaryCIDR)
+		return nil
+	}
+	connectionType := opts.ConnectionType
+	if connectionType == "" {
+		connectionType = guestConnectionTypeSSH
+	}
 	d.SetConnInfo(map[string]string{
-		"type": "ssh",
+		"type": connectionType,
 		"host": primary,
 	})
 
 	return nil
 }
+
+// flattenGuestDNSConfig saves the hostname, domain name, DNS servers, and
+// search domains reported for the guest to ResourceData. Guests can report
+// more than one IP stack, so the servers and search domains of all stacks are
+// merged in the order they are reported, without duplicates, and the first
+// hostname and domain name reported are used. All values are empty when
+// VMware Tools is not running.
+func flattenGuestDNSConfig(d *schema.ResourceData, guest types.GuestInfo) error {
+	hostname := guest.HostName
+	var domain string
+	servers := make([]string, 0)
+	domains := make([]string, 0)
+	for _, s := range guest.IpStack {
+		if s.DnsConfig == nil {
+			continue
+		}
+		if hostname == "" {
+			hostname = s.DnsConfig.HostName
+		}
+		if domain == "" {
+			domain = s.DnsConfig.DomainName
+		}
+		for _, v := range s.DnsConfig.IpAddress {
+			if !slices.Contains(servers, v) {
+				servers = append(servers, v)
+			}
+		}
+		for _, v := range s.DnsConfig.SearchDomain {
+			if !slices.Contains(domains, v) {
+				domains = append(domains, v)
+			}
+		}
+	}
+	if err := d.Set("guest_hostname", hostname); err != nil {
+		return err
+	}
+	if err := d.Set("guest_domain", domain); err != nil {
+		return err
+	}
+	if err := d.Set("guest_dns_servers", servers); err != nil {
+		return err
+	}
+	return d.Set("guest_dns_search_domains", domains)
+}
+
+// isLinkLocalOrLoopbackIP returns true if ip is a link-local, such as
+// fe80::/10 or 169.254.0.0/16, or loopback address.
+func isLinkLocalOrLoopbackIP(ip net.IP) bool {
+	return ip != nil && (ip.IsLinkLocalUnicast() || ip.IsLoopback())
+}
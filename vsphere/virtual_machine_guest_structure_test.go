@@ -0,0 +1,640 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/terraform-provider-vsphere/vsphere/internal/helper/structure"
+)
+
+func testGuestInfoMultiHomed(ipv6Gateway bool) types.GuestInfo {
+	guest := types.GuestInfo{
+		IpStack: []types.GuestStackInfo{
+			{
+				IpRouteConfig: &types.NetIpRouteConfigInfo{
+					IpRoute: []types.NetIpRouteConfigInfoIpRoute{
+						{
+							Network: "0.0.0.0",
+							Gateway: types.NetIpRouteConfigInfoGateway{IpAddress: "192.168.1.1"},
+						},
+					},
+				},
+			},
+		},
+		Net: []types.GuestNicInfo{
+			{
+				DeviceConfigId: 4000,
+				MacAddress:     "00:50:56:00:00:01",
+				IpConfig: &types.NetIpConfigInfo{
+					IpAddress: []types.NetIpConfigInfoIpAddress{
+						{IpAddress: "192.168.1.10", PrefixLength: 24},
+					},
+				},
+			},
+			{
+				DeviceConfigId: 4001,
+				MacAddress:     "00:50:56:aa:00:02",
+				IpConfig: &types.NetIpConfigInfo{
+					IpAddress: []types.NetIpConfigInfoIpAddress{
+						{IpAddress: "10.0.0.10", PrefixLength: 8},
+						{IpAddress: "fd00::10", PrefixLength: 64},
+					},
+				},
+			},
+		},
+	}
+	if ipv6Gateway {
+		guest.IpStack[0].IpRouteConfig.IpRoute = append(guest.IpStack[0].IpRouteConfig.IpRoute, types.NetIpRouteConfigInfoIpRoute{
+			Network: "::",
+			Gateway: types.NetIpRouteConfigInfoGateway{IpAddress: "fd00::1"},
+		})
+	}
+	return guest
+}
+
+func TestBuildAndSelectGuestIPs(t *testing.T) {
+	cases := []struct {
+		name        string
+		ipv6Gateway bool
+		opts        guestIPSelectionOptions
+		expected    string
+	}{
+		{
+			name:     "gateway match",
+			expected: "192.168.1.10",
+		},
+		{
+			name:     "MAC address",
+			opts:     guestIPSelectionOptions{PrimaryMAC: "00:50:56:aa:00:02"},
+			expected: "10.0.0.10",
+		},
+		{
+			name:     "MAC address is case insensitive",
+			opts:     guestIPSelectionOptions{PrimaryMAC: "00:50:56:AA:00:02"},
+			expected: "10.0.0.10",
+		},
+		{
+			name:     "CIDR",
+			opts:     guestIPSelectionOptions{PrimaryCIDR: "10.0.0.0/8"},
+			expected: "10.0.0.10",
+		},
+		{
+			name:     "IPv6 CIDR",
+			opts:     guestIPSelectionOptions{PrimaryCIDR: "fd00::/8"},
+			expected: "fd00::10",
+		},
+		{
+			name:     "MAC address and CIDR",
+			opts:     guestIPSelectionOptions{PrimaryMAC: "00:50:56:00:00:01", PrimaryCIDR: "10.0.0.0/8"},
+			expected: "",
+		},
+		{
+			name:     "unknown MAC address",
+			opts:     guestIPSelectionOptions{PrimaryMAC: "00:50:56:00:00:03"},
+			expected: "",
+		},
+		{
+			name:        "IPv6 gateway match with IPv4 preferred",
+			ipv6Gateway: true,
+			expected:    "192.168.1.10",
+		},
+		{
+			name:        "IPv6 gateway match with IPv6 preferred",
+			ipv6Gateway: true,
+			opts:        guestIPSelectionOptions{PreferIPv6: true},
+			expected:    "fd00::10",
+		},
+		{
+			name:     "IPv6 preferred without IPv6 gateway",
+			opts:     guestIPSelectionOptions{PreferIPv6: true},
+			expected: "192.168.1.10",
+		},
+		{
+			name:     "IPv6 preferred without IPv6 address",
+			opts:     guestIPSelectionOptions{PrimaryMAC: "00:50:56:00:00:01", PreferIPv6: true},
+			expected: "192.168.1.10",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+			if err := buildAndSelectGuestIPs(d, testGuestInfoMultiHomed(tc.ipv6Gateway), tc.opts); err != nil {
+				t.Fatal(err)
+			}
+			if actual := d.Get("default_ip_address").(string); tc.expected != actual {
+				t.Fatalf("expected default IP address %q, got %q", tc.expected, actual)
+			}
+			if actual := len(d.Get("guest_ip_addresses").([]interface{})); actual != 3 {
+				t.Fatalf("expected 3 guest IP addresses, got %d", actual)
+			}
+			if tc.expected == "" {
+				return
+			}
+			if actual := d.ConnInfo()["host"]; tc.expected != actual {
+				t.Fatalf("expected connection host %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBuildAndSelectGuestIPsLinkLocal(t *testing.T) {
+	cases := []struct {
+		name          string
+		addrs         []string
+		ipAddress     string
+		opts          guestIPSelectionOptions
+		expected      string
+		expectedAddrs int
+	}{
+		{
+			name:          "link-local and loopback filtered",
+			addrs:         []string{"169.254.1.10", "127.0.0.1", "fe80::10", "10.0.0.10"},
+			expected:      "10.0.0.10",
+			expectedAddrs: 1,
+		},
+		{
+			name:          "link-local and loopback included",
+			addrs:         []string{"169.254.1.10", "127.0.0.1", "fe80::10", "10.0.0.10"},
+			opts:          guestIPSelectionOptions{IncludeLinkLocal: true},
+			expected:      "169.254.1.10",
+			expectedAddrs: 4,
+		},
+		{
+			name:          "fall back to guest IP address",
+			addrs:         []string{"169.254.1.10", "fe80::10"},
+			ipAddress:     "10.0.0.20",
+			expected:      "10.0.0.20",
+			expectedAddrs: 1,
+		},
+		{
+			name:          "link-local guest IP address filtered",
+			addrs:         []string{"fe80::10"},
+			ipAddress:     "169.254.1.20",
+			expected:      "",
+			expectedAddrs: 0,
+		},
+		{
+			name:          "link-local guest IP address included",
+			addrs:         []string{},
+			ipAddress:     "169.254.1.20",
+			opts:          guestIPSelectionOptions{IncludeLinkLocal: true},
+			expected:      "169.254.1.20",
+			expectedAddrs: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ipAddrs []types.NetIpConfigInfoIpAddress
+			for _, addr := range tc.addrs {
+				ipAddrs = append(ipAddrs, types.NetIpConfigInfoIpAddress{IpAddress: addr, PrefixLength: 16})
+			}
+			guest := types.GuestInfo{
+				IpAddress: tc.ipAddress,
+				Net: []types.GuestNicInfo{
+					{
+						DeviceConfigId: 4000,
+						MacAddress:     "00:50:56:00:00:01",
+						IpConfig:       &types.NetIpConfigInfo{IpAddress: ipAddrs},
+					},
+				},
+			}
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+			if err := buildAndSelectGuestIPs(d, guest, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+			if actual := d.Get("default_ip_address").(string); tc.expected != actual {
+				t.Fatalf("expected default IP address %q, got %q", tc.expected, actual)
+			}
+			if actual := len(d.Get("guest_ip_addresses").([]interface{})); tc.expectedAddrs != actual {
+				t.Fatalf("expected %d guest IP addresses, got %d", tc.expectedAddrs, actual)
+			}
+		})
+	}
+}
+
+func TestGuestConnectionType(t *testing.T) {
+	cases := []struct {
+		name           string
+		connectionType string
+		guestID        string
+		expected       string
+	}{
+		{
+			name:     "Linux guest",
+			guestID:  "ubuntu64Guest",
+			expected: "ssh",
+		},
+		{
+			name:     "Windows guest",
+			guestID:  "windows2019srv_64Guest",
+			expected: "winrm",
+		},
+		{
+			name:     "older Windows guest",
+			guestID:  "winNetStandardGuest",
+			expected: "winrm",
+		},
+		{
+			name:           "configured ssh on Windows guest",
+			connectionType: "ssh",
+			guestID:        "windows2019srv_64Guest",
+			expected:       "ssh",
+		},
+		{
+			name:           "configured winrm",
+			connectionType: "winrm",
+			guestID:        "otherGuest64",
+			expected:       "winrm",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+				"guest_id":              tc.guestID,
+				"guest_connection_type": tc.connectionType,
+			})
+			opts := expandGuestIPSelectionOptions(d)
+			if opts.ConnectionType != tc.expected {
+				t.Fatalf("expected connection type %q, got %q", tc.expected, opts.ConnectionType)
+			}
+			if err := buildAndSelectGuestIPs(d, testGuestInfoMultiHomed(false), opts); err != nil {
+				t.Fatal(err)
+			}
+			if actual := d.ConnInfo()["type"]; tc.expected != actual {
+				t.Fatalf("expected connection info type %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBuildAndSelectGuestIPsNetworkInterfaces(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+	if err := buildAndSelectGuestIPs(d, testGuestInfoMultiHomed(false), guestIPSelectionOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		mac    string
+		key    int
+		ipAddr []string
+	}{
+		{mac: "00:50:56:00:00:01", key: 4000, ipAddr: []string{"192.168.1.10"}},
+		{mac: "00:50:56:aa:00:02", key: 4001, ipAddr: []string{"10.0.0.10", "fd00::10"}},
+	}
+	nics := d.Get("guest_network_interfaces").([]interface{})
+	if len(expected) != len(nics) {
+		t.Fatalf("expected %d network interfaces, got %d", len(expected), len(nics))
+	}
+	for i, e := range expected {
+		nic := nics[i].(map[string]interface{})
+		if nic["mac_address"] != e.mac {
+			t.Fatalf("expected network interface %d MAC address %q, got %q", i, e.mac, nic["mac_address"])
+		}
+		if nic["device_config_id"] != e.key {
+			t.Fatalf("expected network interface %d device key %d, got %v", i, e.key, nic["device_config_id"])
+		}
+		addrs := nic["ip_addresses"].([]interface{})
+		if len(e.ipAddr) != len(addrs) {
+			t.Fatalf("expected network interface %d to have %d addresses, got %d", i, len(e.ipAddr), len(addrs))
+		}
+		for j, addr := range e.ipAddr {
+			if addrs[j] != addr {
+				t.Fatalf("expected network interface %d address %d to be %q, got %q", i, j, addr, addrs[j])
+			}
+		}
+	}
+	if actual := len(d.Get("guest_ip_addresses").([]interface{})); actual != 3 {
+		t.Fatalf("expected 3 guest IP addresses, got %d", actual)
+	}
+}
+
+func TestGuestToolsStatus(t *testing.T) {
+	cases := []struct {
+		name     string
+		guest    types.GuestInfo
+		expected string
+	}{
+		{
+			name:     "reported status",
+			guest:    types.GuestInfo{ToolsStatus: types.VirtualMachineToolsStatusToolsOld},
+			expected: "toolsOld",
+		},
+		{
+			name: "running and current",
+			guest: types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsCurrent),
+			},
+			expected: "toolsOk",
+		},
+		{
+			name: "running and needs upgrade",
+			guest: types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade),
+			},
+			expected: "toolsOld",
+		},
+		{
+			name: "not running",
+			guest: types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsNotRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsCurrent),
+			},
+			expected: "toolsNotRunning",
+		},
+		{
+			name: "not installed",
+			guest: types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsNotRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled),
+			},
+			expected: "toolsNotInstalled",
+		},
+		{
+			name:     "nothing reported",
+			guest:    types.GuestInfo{},
+			expected: "toolsNotRunning",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := guestToolsStatus(tc.guest); tc.expected != actual {
+				t.Fatalf("expected tools status %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGuestToolsRunningAndVersionStatus(t *testing.T) {
+	cases := []struct {
+		name            string
+		guest           types.GuestInfo
+		expectedRunning string
+		expectedVersion string
+	}{
+		{
+			name: "running and current",
+			guest: types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsCurrent),
+			},
+			expectedRunning: "guestToolsRunning",
+			expectedVersion: "guestToolsCurrent",
+		},
+		{
+			name: "deprecated version status",
+			guest: types.GuestInfo{
+				ToolsRunningStatus: string(types.VirtualMachineToolsRunningStatusGuestToolsNotRunning),
+				ToolsVersionStatus: string(types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade),
+			},
+			expectedRunning: "guestToolsNotRunning",
+			expectedVersion: "guestToolsNeedUpgrade",
+		},
+		{
+			name: "not installed",
+			guest: types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsNotRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			running, version := guestToolsRunningAndVersionStatus(tc.guest)
+			if running != tc.expectedRunning {
+				t.Fatalf("expected running status %q, got %q", tc.expectedRunning, running)
+			}
+			if version != tc.expectedVersion {
+				t.Fatalf("expected version status %q, got %q", tc.expectedVersion, version)
+			}
+		})
+	}
+}
+
+func TestGuestState(t *testing.T) {
+	cases := []struct {
+		name     string
+		guest    types.GuestInfo
+		expected string
+	}{
+		{
+			name:     "running",
+			guest:    types.GuestInfo{GuestState: string(types.VirtualMachineGuestStateRunning)},
+			expected: "running",
+		},
+		{
+			name:     "shutting down",
+			guest:    types.GuestInfo{GuestState: string(types.VirtualMachineGuestStateShuttingDown)},
+			expected: "shuttingDown",
+		},
+		{
+			name:     "not reported",
+			guest:    types.GuestInfo{},
+			expected: "notRunning",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := guestState(tc.guest); tc.expected != actual {
+				t.Fatalf("expected guest state %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestToolsUpgradeNeeded(t *testing.T) {
+	oldTools := &types.GuestInfo{
+		ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsRunning),
+		ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade),
+	}
+	cases := []struct {
+		name       string
+		powerState types.VirtualMachinePowerState
+		guest      *types.GuestInfo
+		expected   bool
+	}{
+		{
+			name:       "out of date",
+			powerState: types.VirtualMachinePowerStatePoweredOn,
+			guest:      oldTools,
+			expected:   true,
+		},
+		{
+			name:       "current",
+			powerState: types.VirtualMachinePowerStatePoweredOn,
+			guest: &types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsCurrent),
+			},
+		},
+		{
+			name:       "too old",
+			powerState: types.VirtualMachinePowerStatePoweredOn,
+			guest: &types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsTooOld),
+			},
+			expected: true,
+		},
+		{
+			name:       "supported old",
+			powerState: types.VirtualMachinePowerStatePoweredOn,
+			guest: &types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsSupportedOld),
+			},
+		},
+		{
+			name:       "not running",
+			powerState: types.VirtualMachinePowerStatePoweredOn,
+			guest: &types.GuestInfo{
+				ToolsRunningStatus:  string(types.VirtualMachineToolsRunningStatusGuestToolsNotRunning),
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade),
+			},
+		},
+		{
+			name:       "not installed",
+			powerState: types.VirtualMachinePowerStatePoweredOn,
+			guest: &types.GuestInfo{
+				ToolsVersionStatus2: string(types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled),
+			},
+		},
+		{
+			name:       "powered off",
+			powerState: types.VirtualMachinePowerStatePoweredOff,
+			guest:      oldTools,
+		},
+		{
+			name:       "no guest info",
+			powerState: types.VirtualMachinePowerStatePoweredOn,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			props := &mo.VirtualMachine{
+				Runtime: types.VirtualMachineRuntimeInfo{PowerState: tc.powerState},
+				Guest:   tc.guest,
+			}
+			if actual := toolsUpgradeNeeded(props); tc.expected != actual {
+				t.Fatalf("expected tools upgrade needed to be %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFlattenVirtualMachinePowerState(t *testing.T) {
+	cases := []struct {
+		name     string
+		previous string
+		state    types.VirtualMachinePowerState
+		expected string
+	}{
+		{
+			name:     "powered on",
+			state:    types.VirtualMachinePowerStatePoweredOn,
+			expected: "on",
+		},
+		{
+			name:     "powered off",
+			state:    types.VirtualMachinePowerStatePoweredOff,
+			expected: "off",
+		},
+		{
+			name:     "suspended",
+			state:    types.VirtualMachinePowerStateSuspended,
+			expected: "suspended",
+		},
+		{
+			name:     "unknown keeps previous state",
+			previous: "on",
+			state:    "",
+			expected: "on",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+			if tc.previous != "" {
+				_ = d.Set("power_state", tc.previous)
+			}
+			flattenVirtualMachinePowerState(d, tc.state)
+			if actual := d.Get("power_state").(string); tc.expected != actual {
+				t.Fatalf("expected power state %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBuildAndSelectGuestIPsDNSConfig(t *testing.T) {
+	guest := testGuestInfoMultiHomed(false)
+	guest.HostName = "web01"
+	guest.IpStack[0].DnsConfig = &types.NetDnsConfigInfo{
+		HostName:     "web01",
+		DomainName:   "example.com",
+		IpAddress:    []string{"192.168.1.2", "192.168.1.3"},
+		SearchDomain: []string{"example.com"},
+	}
+	guest.IpStack = append(guest.IpStack, types.GuestStackInfo{
+		DnsConfig: &types.NetDnsConfigInfo{
+			DomainName:   "corp.example.com",
+			IpAddress:    []string{"192.168.1.3", "fd00::2"},
+			SearchDomain: []string{"example.com", "corp.example.com"},
+		},
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+	if err := buildAndSelectGuestIPs(d, guest, guestIPSelectionOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		key      string
+		expected []string
+	}{
+		{key: "guest_dns_servers", expected: []string{"192.168.1.2", "192.168.1.3", "fd00::2"}},
+		{key: "guest_dns_search_domains", expected: []string{"example.com", "corp.example.com"}},
+	}
+	for _, tc := range cases {
+		actual := structure.SliceInterfacesToStrings(d.Get(tc.key).([]interface{}))
+		if !reflect.DeepEqual(tc.expected, actual) {
+			t.Fatalf("expected %s to be %v, got %v", tc.key, tc.expected, actual)
+		}
+	}
+	if actual := d.Get("guest_hostname").(string); actual != "web01" {
+		t.Fatalf("expected guest_hostname to be web01, got %q", actual)
+	}
+	if actual := d.Get("guest_domain").(string); actual != "example.com" {
+		t.Fatalf("expected guest_domain to be example.com, got %q", actual)
+	}
+}
+
+func TestBuildAndSelectGuestIPsDNSConfigToolsNotRunning(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataSourceVSphereVirtualMachine().Schema, map[string]interface{}{})
+	if err := buildAndSelectGuestIPs(d, types.GuestInfo{}, guestIPSelectionOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"guest_hostname", "guest_domain"} {
+		if actual := d.Get(key).(string); actual != "" {
+			t.Fatalf("expected %s to be empty, got %q", key, actual)
+		}
+	}
+	if actual := d.Get("guest_dns_servers").([]interface{}); len(actual) != 0 {
+		t.Fatalf("expected no DNS servers, got %v", actual)
+	}
+}